@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+)
+
+// command is an operator subcommand of the binary. Commands go through the
+// service layer like the HTTP handlers do, using the admin service where a
+// request would need a company role.
+type command struct {
+	usage string
+	help  string
+	run   func(ctx context.Context, env commandEnv, args []string) error
+}
+
+type commandEnv struct {
+	db       *sql.DB
+	services *service.Service
+	stdin    io.Reader
+	stdout   io.Writer
+}
+
+var errUsage = errors.New("wrong arguments")
+
+// jwtKeyBytes is the length of generated JWT signing keys, comfortably above
+// the 32 bytes the configuration requires.
+const jwtKeyBytes = 48
+
+var commands = map[string]command{
+	"migrate": {
+		usage: "migrate [up | down [N] | status | force VERSION]",
+		help:  "apply, revert or inspect database migrations",
+		run: func(ctx context.Context, env commandEnv, args []string) error {
+			return runMigrate(ctx, env.db, args)
+		},
+	},
+	"create-admin": {
+		usage: "create-admin EMAIL NAME",
+		help:  "create an admin user; the password is read from stdin",
+		run:   createAdmin,
+	},
+	"companies": {
+		usage: "companies [--archived]",
+		help:  "list companies",
+		run:   listCompanies,
+	},
+	"company": {
+		usage: "company ID",
+		help:  "show a company with its members and teams",
+		run:   showCompany,
+	},
+	"teams": {
+		usage: "teams COMPANY_ID [--archived]",
+		help:  "list the teams of a company",
+		run:   listTeams,
+	},
+	"team": {
+		usage: "team ID",
+		help:  "show a team with its surveys",
+		run:   showTeam,
+	},
+	"close-survey": {
+		usage: "close-survey ID",
+		help:  "complete an active survey",
+		run: func(ctx context.Context, env commandEnv, args []string) error {
+			return setSurveyStatus(ctx, env, args, model.SurveyStatusCompleted)
+		},
+	},
+	"reopen-survey": {
+		usage: "reopen-survey ID",
+		help:  "make a completed survey active again",
+		run: func(ctx context.Context, env commandEnv, args []string) error {
+			return setSurveyStatus(ctx, env, args, model.SurveyStatusActive)
+		},
+	},
+	"results": {
+		usage: "results TEAM_ID [--roll-up]",
+		help:  "recompute a team's results from its responses",
+		run:   teamResults,
+	},
+	"export": {
+		usage: "export COMPANY_ID [FILE]",
+		help:  "export a company's data as JSON to FILE or stdout",
+		run:   exportCompany,
+	},
+	"rotate-webhook-secret": {
+		usage: "rotate-webhook-secret WEBHOOK_ID",
+		help:  "replace a webhook's signing secret and print the new one",
+		run:   rotateWebhookSecret,
+	},
+	"api-keys": {
+		usage: "api-keys USER_ID",
+		help:  "list a user's API keys",
+		run:   listAPIKeys,
+	},
+	"revoke-api-key": {
+		usage: "revoke-api-key KEY_ID",
+		help:  "revoke any user's API key",
+		run:   revokeAPIKey,
+	},
+	"rotate-api-key": {
+		usage: "rotate-api-key KEY_ID",
+		help:  "replace an API key with a new one and print it",
+		run:   rotateAPIKey,
+	},
+	"generate-jwt-key": {
+		usage: "generate-jwt-key",
+		help:  "print a new random JWT signing key",
+		run:   generateJWTKey,
+	},
+}
+
+// runCommand runs the subcommand name, or prints the list of commands for
+// "help".
+func runCommand(ctx context.Context, env commandEnv, name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		printCommands(env.stdout)
+		if name == "help" || name == "-h" || name == "--help" {
+			return nil
+		}
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	err := cmd.run(ctx, env, args)
+	if errors.Is(err, errUsage) {
+		return fmt.Errorf("usage: %s", cmd.usage)
+	}
+	return err
+}
+
+func printCommands(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Without a command the HTTP server starts. Commands:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", commands[name].usage, commands[name].help)
+	}
+	tw.Flush()
+}
+
+func createAdmin(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	password, err := bufio.NewReader(env.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if len(password) < 8 {
+		return errors.New("the password must be at least 8 characters")
+	}
+
+	id, err := env.services.Authorization.CreateUser(ctx, model.User{
+		Email:    args[0],
+		Name:     args[1],
+		Password: password,
+		Role:     string(model.UserRoleAdmin),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "created admin user %d\n", id)
+	return nil
+}
+
+func listCompanies(ctx context.Context, env commandEnv, args []string) error {
+	archived, args := hasFlag(args, "--archived")
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	query := model.ListQuery{Limit: model.MaxListLimit, IncludeArchived: archived}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tTEAMS\tEMPLOYEES\tACTIVE SURVEYS\tARCHIVED")
+	for {
+		page, err := env.services.Admin.GetCompanies(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, company := range page.Data {
+			var teams, employees, active int
+			if company.Stats != nil {
+				teams, employees, active = company.Stats.TeamsCount, company.Stats.EmployeesCount, company.Stats.ActiveSurveysCount
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%s\n", company.ID, company.Name, teams, employees, active, yesNo(company.ArchivedAt != nil))
+		}
+		if page.NextCursor == "" {
+			return tw.Flush()
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func showCompany(ctx context.Context, env commandEnv, args []string) error {
+	id, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	company, err := env.services.Admin.GetCompany(ctx, id)
+	if err != nil {
+		return err
+	}
+	members, err := env.services.Admin.GetCompanyMembers(ctx, id)
+	if err != nil {
+		return err
+	}
+	teams, err := env.services.Admin.GetTeams(ctx, id, model.ListQuery{Limit: model.MaxListLimit, IncludeArchived: true})
+	if err != nil {
+		return err
+	}
+
+	return printJSON(env.stdout, map[string]interface{}{
+		"company":    company,
+		"members":    members,
+		"teams":      teams.Data,
+		"more_teams": teams.NextCursor != "",
+	})
+}
+
+func listTeams(ctx context.Context, env commandEnv, args []string) error {
+	archived, args := hasFlag(args, "--archived")
+	companyID, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	query := model.ListQuery{Limit: model.MaxListLimit, IncludeArchived: archived}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPARENT\tARCHIVED")
+	for {
+		page, err := env.services.Admin.GetTeams(ctx, companyID, query)
+		if err != nil {
+			return err
+		}
+		for _, team := range page.Data {
+			parent := "-"
+			if team.ParentID != nil {
+				parent = strconv.Itoa(*team.ParentID)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", team.ID, team.Name, parent, yesNo(team.ArchivedAt != nil))
+		}
+		if page.NextCursor == "" {
+			return tw.Flush()
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func showTeam(ctx context.Context, env commandEnv, args []string) error {
+	id, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	team, err := env.services.Admin.GetTeam(ctx, id)
+	if err != nil {
+		return err
+	}
+	surveys, err := env.services.Admin.GetSurveys(ctx, id, model.ListQuery{Limit: model.MaxListLimit, IncludeArchived: true})
+	if err != nil {
+		return err
+	}
+
+	return printJSON(env.stdout, map[string]interface{}{
+		"team":         team,
+		"surveys":      surveys.Data,
+		"more_surveys": surveys.NextCursor != "",
+	})
+}
+
+func setSurveyStatus(ctx context.Context, env commandEnv, args []string, status string) error {
+	id, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	survey, err := env.services.Admin.SetSurveyStatus(ctx, id, status)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "survey %d is %s\n", survey.ID, survey.Status)
+	return nil
+}
+
+// teamResults prints results computed from the stored responses; nothing is
+// cached, so the numbers are always current.
+func teamResults(ctx context.Context, env commandEnv, args []string) error {
+	rollUp, args := hasFlag(args, "--roll-up")
+	id, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	results, err := env.services.Admin.GetTeamResults(ctx, id, rollUp)
+	if err != nil {
+		return err
+	}
+	return printJSON(env.stdout, results)
+}
+
+func exportCompany(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errUsage
+	}
+	id, err := idArg(args[:1])
+	if err != nil {
+		return err
+	}
+
+	export, err := env.services.Admin.ExportCompany(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return printJSON(env.stdout, export)
+	}
+
+	file, err := os.OpenFile(args[1], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := printJSON(file, export); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "exported company %d to %s\n", id, args[1])
+	return nil
+}
+
+func rotateWebhookSecret(ctx context.Context, env commandEnv, args []string) error {
+	id, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := env.services.Admin.RotateWebhookSecret(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "new secret of webhook %d (%s):\n%s\n", subscription.ID, subscription.URL, subscription.Secret)
+	return nil
+}
+
+func listAPIKeys(ctx context.Context, env commandEnv, args []string) error {
+	userID, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	keys, err := env.services.Admin.GetAPIKeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tSCOPES\tLAST USED\tREVOKED")
+	for _, key := range keys {
+		lastUsed := "-"
+		if key.LastUsedAt != nil {
+			lastUsed = key.LastUsedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, strings.Join(key.Scopes, ","), lastUsed, yesNo(key.RevokedAt != nil))
+	}
+	return tw.Flush()
+}
+
+func revokeAPIKey(ctx context.Context, env commandEnv, args []string) error {
+	id, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	if err := env.services.Admin.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "api key %d revoked\n", id)
+	return nil
+}
+
+func rotateAPIKey(ctx context.Context, env commandEnv, args []string) error {
+	id, err := idArg(args)
+	if err != nil {
+		return err
+	}
+
+	key, err := env.services.Admin.RotateAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "api key %d revoked, new key %d of user %d:\n%s\n", id, key.ID, key.UserID, key.Key)
+	return nil
+}
+
+// generateJWTKey prints a signing key for JWT_SIGNING_KEY. Access tokens are
+// stateless, so rotating the key means deploying the new one with the old
+// one in JWT_PREVIOUS_SIGNING_KEYS until the tokens it signed have expired.
+func generateJWTKey(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	key := make([]byte, jwtKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(env.stdout, base64.RawURLEncoding.EncodeToString(key))
+	return nil
+}
+
+// idArg parses the only argument as a positive ID.
+func idArg(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errUsage
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return 0, errUsage
+	}
+	return id, nil
+}
+
+// hasFlag reports whether args contain flag and returns args without it.
+func hasFlag(args []string, flag string) (bool, []string) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return found, rest
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
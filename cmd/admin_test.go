@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestRunCommand(t *testing.T) {
+	type mockBehavior func(admin *mocks.Admin, auth *mocks.Authorization)
+
+	testTable := []struct {
+		name           string
+		command        string
+		args           []string
+		stdin          string
+		mockBehavior   mockBehavior
+		expectedOutput string
+		expectedErr    string
+	}{
+		{
+			name:    "Create Admin",
+			command: "create-admin",
+			args:    []string{"root@example.com", "Root"},
+			stdin:   "secret-password\n",
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {
+				auth.On("CreateUser", model.User{
+					Email: "root@example.com", Name: "Root", Password: "secret-password", Role: string(model.UserRoleAdmin),
+				}).Return(1, nil)
+			},
+			expectedOutput: "created admin user 1\n",
+		},
+		{
+			name:         "Create Admin With Short Password",
+			command:      "create-admin",
+			args:         []string{"root@example.com", "Root"},
+			stdin:        "short\n",
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {},
+			expectedErr:  "the password must be at least 8 characters",
+		},
+		{
+			name:    "List Companies",
+			command: "companies",
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {
+				admin.On("GetCompanies", model.ListQuery{Limit: model.MaxListLimit}).Return(model.Page[model.Company]{
+					Data: []model.Company{{ID: 1, Name: "Acme", Stats: &model.CompanyStats{TeamsCount: 2, EmployeesCount: 5, ActiveSurveysCount: 1}}},
+				}, nil)
+			},
+			expectedOutput: "ID  NAME  TEAMS  EMPLOYEES  ACTIVE SURVEYS  ARCHIVED\n" +
+				"1   Acme  2      5          1               no\n",
+		},
+		{
+			name:    "Close Survey",
+			command: "close-survey",
+			args:    []string{"4"},
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {
+				admin.On("SetSurveyStatus", 4, model.SurveyStatusCompleted).Return(model.Survey{ID: 4, Status: model.SurveyStatusCompleted}, nil)
+			},
+			expectedOutput: "survey 4 is completed\n",
+		},
+		{
+			name:    "Rotate Webhook Secret",
+			command: "rotate-webhook-secret",
+			args:    []string{"2"},
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {
+				admin.On("RotateWebhookSecret", 2).Return(model.WebhookSubscription{ID: 2, URL: "https://example.com/hooks", Secret: "s3cret"}, nil)
+			},
+			expectedOutput: "new secret of webhook 2 (https://example.com/hooks):\ns3cret\n",
+		},
+		{
+			name:    "List API Keys",
+			command: "api-keys",
+			args:    []string{"7"},
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {
+				admin.On("GetAPIKeys", 7).Return([]model.APIKey{
+					{ID: 3, UserID: 7, Name: "ci", Prefix: "abcdefgh", Scopes: []string{model.ScopeTeamsRead, model.ScopeSurveysRead}},
+				}, nil)
+			},
+			expectedOutput: "ID  NAME  PREFIX    SCOPES                   LAST USED  REVOKED\n" +
+				"3   ci    abcdefgh  teams:read,surveys:read  -          no\n",
+		},
+		{
+			name:    "Revoke API Key",
+			command: "revoke-api-key",
+			args:    []string{"3"},
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {
+				admin.On("RevokeAPIKey", 3).Return(nil)
+			},
+			expectedOutput: "api key 3 revoked\n",
+		},
+		{
+			name:    "Revoke Missing API Key",
+			command: "revoke-api-key",
+			args:    []string{"9"},
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {
+				admin.On("RevokeAPIKey", 9).Return(model.ErrNotFound)
+			},
+			expectedErr: model.ErrNotFound.Error(),
+		},
+		{
+			name:    "Rotate API Key",
+			command: "rotate-api-key",
+			args:    []string{"3"},
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {
+				admin.On("RotateAPIKey", 3).Return(model.CreatedAPIKey{
+					APIKey: model.APIKey{ID: 4, UserID: 7}, Key: "tdk_ijklmnop_secret",
+				}, nil)
+			},
+			expectedOutput: "api key 3 revoked, new key 4 of user 7:\ntdk_ijklmnop_secret\n",
+		},
+		{
+			name:         "Invalid ID",
+			command:      "rotate-api-key",
+			args:         []string{"abc"},
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {},
+			expectedErr:  "usage: rotate-api-key KEY_ID",
+		},
+		{
+			name:         "Unknown Command",
+			command:      "frobnicate",
+			mockBehavior: func(admin *mocks.Admin, auth *mocks.Authorization) {},
+			expectedErr:  `unknown command "frobnicate"`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			admin := mocks.NewAdmin(t)
+			auth := mocks.NewAuthorization(t)
+			testCase.mockBehavior(admin, auth)
+
+			var stdout bytes.Buffer
+			env := commandEnv{
+				services: &service.Service{Admin: admin, Authorization: auth},
+				stdin:    strings.NewReader(testCase.stdin),
+				stdout:   &stdout,
+			}
+
+			// Run Command
+			err := runCommand(context.Background(), env, testCase.command, testCase.args)
+
+			// Assert
+			if testCase.expectedErr != "" {
+				assert.EqualError(t, err, testCase.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedOutput, stdout.String())
+			admin.AssertExpectations(t)
+			auth.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGenerateJWTKey(t *testing.T) {
+	var first, second bytes.Buffer
+
+	assert.NoError(t, runCommand(context.Background(), commandEnv{stdout: &first}, "generate-jwt-key", nil))
+	assert.NoError(t, runCommand(context.Background(), commandEnv{stdout: &second}, "generate-jwt-key", nil))
+
+	key, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(first.String()))
+	assert.NoError(t, err)
+	assert.Len(t, key, jwtKeyBytes)
+	assert.NotEqual(t, first.String(), second.String())
+}
+
+func TestPrintCommands(t *testing.T) {
+	var stdout bytes.Buffer
+
+	assert.NoError(t, runCommand(context.Background(), commandEnv{stdout: &stdout}, "help", nil))
+
+	for name := range commands {
+		assert.Contains(t, stdout.String(), "  "+name)
+	}
+}
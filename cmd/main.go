@@ -1,31 +1,182 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/teamdetected/internal/config"
+	"github.com/teamdetected/internal/events"
 	"github.com/teamdetected/internal/handler"
+	"github.com/teamdetected/internal/metrics"
+	"github.com/teamdetected/internal/migrate"
+	"github.com/teamdetected/internal/model"
 	"github.com/teamdetected/internal/repository"
 	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/tracing"
+	"github.com/teamdetected/internal/worker"
+	"github.com/teamdetected/migrations"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	db, err := repository.NewPostgresDB()
+	db, err := repository.NewPostgresDB(repository.Config{
+		Host:            cfg.DB.Host,
+		Port:            cfg.DB.Port,
+		User:            cfg.DB.User,
+		Password:        cfg.DB.Password,
+		Name:            cfg.DB.Name,
+		SSLMode:         cfg.DB.SSLMode,
+		MaxOpenConns:    cfg.DB.MaxOpenConns,
+		MaxIdleConns:    cfg.DB.MaxIdleConns,
+		ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+		ConnectTimeout:  cfg.DB.ConnectTimeout,
+		QueryTimeout:    cfg.DB.QueryTimeout,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if err := metrics.RegisterDB(db); err != nil {
+		log.Fatal(err)
+	}
+
 	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
+
+	mailer := newMailer(cfg.SMTP)
+	notifiers := service.Notifiers{service.NewEmailNotifier(mailer)}
+	if url := cfg.Notifications.WebhookURL; url != "" {
+		notifiers = append(notifiers, service.NewWebhookNotifier(url))
+	}
+
+	services := service.NewService(repos, service.Options{
+		Retention:       cfg.Retention.SoftDelete,
+		Mailer:          mailer,
+		Notifier:        notifiers,
+		ReminderOffsets: cfg.Reminders.Offsets,
+		EventSinks:      newEventSinks(cfg.Events.Broker),
+		Tokens: service.TokenConfig{
+			SigningKey:   cfg.Auth.JWTSigningKey,
+			PreviousKeys: cfg.Auth.JWTPreviousKeys,
+			AccessTTL:    cfg.Auth.AccessTokenTTL,
+			ChallengeTTL: cfg.Auth.ChallengeTokenTTL,
+		},
+	})
+
+	// The first SIGINT or SIGTERM cancels ctx and starts a graceful shutdown;
+	// a second one kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if len(args) > 0 {
+		env := commandEnv{db: db, services: services, stdin: os.Stdin, stdout: os.Stdout}
+		err := runCommand(ctx, env, args[0], args[1:])
+		shutdownTracing(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if cfg.MigrateOnStart {
+		if err := migrateOnStart(ctx, db); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	handlers := handler.NewHandler(services)
 
+	var workers worker.Group
+
+	workers.Every(ctx, "purge", time.Hour, func(ctx context.Context) error {
+		result, err := services.Retention.PurgeDeleted(ctx)
+		if err == nil && result.Surveys+result.Teams+result.Companies > 0 {
+			log.Printf("purged %d surveys, %d teams, %d companies", result.Surveys, result.Teams, result.Companies)
+		}
+		return err
+	})
+
+	workers.Every(ctx, "survey-scheduler", time.Minute, func(ctx context.Context) error {
+		run, err := services.Schedule.RunDueSchedules(ctx)
+		if err == nil && len(run.Opened)+len(run.Closed) > 0 {
+			log.Printf("scheduler opened %d surveys, closed %d", len(run.Opened), len(run.Closed))
+		}
+		return err
+	})
+
+	workers.Every(ctx, "survey-reminders", 5*time.Minute, func(ctx context.Context) error {
+		sent, err := services.Reminder.SendDueReminders(ctx)
+		if sent > 0 {
+			log.Printf("sent %d survey reminders", sent)
+		}
+		return err
+	})
+
+	workers.Every(ctx, "outbox-relay", 5*time.Second, func(ctx context.Context) error {
+		_, err := services.Outbox.RelayEvents(ctx)
+		return err
+	})
+
+	workers.Every(ctx, "survey-metrics", time.Minute, func(ctx context.Context) error {
+		activity, err := services.Survey.GetSurveyActivity(ctx)
+		if err == nil {
+			metrics.SetSurveyActivity(activity)
+		}
+		return err
+	})
+
+	workers.Every(ctx, "webhooks", 15*time.Second, func(ctx context.Context) error {
+		delivered, err := services.Webhook.DeliverWebhooks(ctx)
+		if delivered > 0 {
+			log.Printf("delivered %d webhooks", delivered)
+		}
+		return err
+	})
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	router := gin.Default()
+	router.Use(handler.Tracing(), handler.Metrics(), handler.ErrorHandler())
+
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/healthz", handler.Healthz)
+	router.GET("/readyz", handler.Readyz(map[string]handler.Check{
+		"database": db.PingContext,
+		"migrations": func(ctx context.Context) error {
+			pending, err := migrator.Pending(ctx)
+			if err == nil && len(pending) > 0 {
+				err = fmt.Errorf("%d pending migrations", len(pending))
+			}
+			return err
+		},
+	}))
 
 	api := router.Group("/api/v1")
 	{
@@ -34,26 +185,100 @@ func main() {
 			auth.POST("/register", handlers.Register)
 			auth.POST("/login", handlers.Login)
 			auth.DELETE("/users/:id", handlers.UserIdentity, handlers.DeleteUser)
+
+			// Two-factor authentication
+			auth.POST("/2fa/verify", handlers.VerifyTwoFactor)
+			auth.POST("/2fa/enroll", handlers.TwoFactorSetupIdentity, handlers.EnrollTwoFactor)
+			auth.POST("/2fa/activate", handlers.TwoFactorSetupIdentity, handlers.ActivateTwoFactor)
+			auth.POST("/2fa/disable", handlers.UserIdentity, handlers.DisableTwoFactor)
+			auth.POST("/2fa/recovery-codes", handlers.UserIdentity, handlers.RegenerateRecoveryCodes)
+			auth.GET("/2fa/policies", handlers.UserIdentity, handlers.RequireRole(model.UserRoleAdmin), handlers.GetTwoFactorPolicies)
+			auth.PUT("/2fa/policies", handlers.UserIdentity, handlers.RequireRole(model.UserRoleAdmin), handlers.SetTwoFactorPolicy)
+
+			// Personal API keys
+			auth.POST("/api-keys", handlers.UserIdentity, handlers.CreateAPIKey)
+			auth.GET("/api-keys", handlers.UserIdentity, handlers.GetAPIKeys)
+			auth.DELETE("/api-keys/:id", handlers.UserIdentity, handlers.RevokeAPIKey)
+
+			// OpenID Connect single sign-on
+			auth.GET("/oidc/:company_id/login", handlers.SSOLogin)
+			auth.GET("/oidc/callback", handlers.SSOCallback)
+		}
+
+		users := api.Group("/users")
+		{
+			users.POST("/email/verify", handlers.VerifyEmail)
+
+			me := users.Group("/me", handlers.UserIdentity)
+			{
+				me.GET("", handlers.GetProfile)
+				me.PATCH("", handlers.UpdateProfile)
+				me.PUT("/password", handlers.ChangePassword)
+				me.POST("/email", handlers.ChangeEmail)
+				me.GET("/memberships", handlers.GetMemberships)
+				me.POST("/invitations/accept", handlers.AcceptCompanyInvitation)
+				me.GET("/notifications", handlers.GetNotificationSettings)
+				me.PUT("/notifications", handlers.UpdateNotificationSettings)
+			}
 		}
 
-		companies := api.Group("/companies")
+		companies := api.Group("/companies", handlers.APIKeyScope("companies"))
 		{
 			companies.POST("", handlers.UserIdentity, handlers.CreateCompany)
 			companies.GET("", handlers.UserIdentity, handlers.GetCompanies)
 			companies.GET("/:id", handlers.UserIdentity, handlers.GetCompany)
+			companies.PATCH("/:id", handlers.UserIdentity, handlers.UpdateCompany)
+			companies.PUT("/:id", handlers.UserIdentity, handlers.UpdateCompany)
 			companies.DELETE("/:id", handlers.UserIdentity, handlers.DeleteCompany)
+			companies.POST("/:id/restore", handlers.UserIdentity, handlers.RestoreCompany)
+			companies.POST("/:id/archive", handlers.UserIdentity, handlers.ArchiveCompany)
+			companies.POST("/:id/unarchive", handlers.UserIdentity, handlers.UnarchiveCompany)
+			companies.POST("/:id/import", handlers.UserIdentity, handlers.ImportCompany)
+
+			// Members, SSO and webhooks decide who acts for the company, so
+			// no API key scope reaches them.
+			sessionOnly := companies.Group("", handlers.NoAPIKeys)
+			{
+				sessionOnly.GET("/:id/members", handlers.UserIdentity, handlers.GetCompanyMembers)
+				sessionOnly.POST("/:id/members", handlers.UserIdentity, handlers.AddCompanyMember)
+				sessionOnly.PATCH("/:id/members/:user_id", handlers.UserIdentity, handlers.UpdateCompanyMember)
+				sessionOnly.DELETE("/:id/members/:user_id", handlers.UserIdentity, handlers.RemoveCompanyMember)
+				sessionOnly.POST("/:id/transfer-ownership", handlers.UserIdentity, handlers.TransferCompanyOwnership)
+				sessionOnly.GET("/:id/sso", handlers.UserIdentity, handlers.GetSSOConfig)
+				sessionOnly.PUT("/:id/sso", handlers.UserIdentity, handlers.SaveSSOConfig)
+				sessionOnly.POST("/:id/sso/verify-domain", handlers.UserIdentity, handlers.VerifySSODomain)
+				sessionOnly.POST("/:id/sso/link", handlers.UserIdentity, handlers.SSOLink)
+				sessionOnly.GET("/:id/webhooks", handlers.UserIdentity, handlers.GetWebhooks)
+				sessionOnly.POST("/:id/webhooks", handlers.UserIdentity, handlers.CreateWebhook)
+				sessionOnly.PATCH("/:id/webhooks/:webhook_id", handlers.UserIdentity, handlers.UpdateWebhook)
+				sessionOnly.DELETE("/:id/webhooks/:webhook_id", handlers.UserIdentity, handlers.DeleteWebhook)
+				sessionOnly.GET("/:id/webhooks/:webhook_id/deliveries", handlers.UserIdentity, handlers.GetWebhookDeliveries)
+				sessionOnly.POST("/:id/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", handlers.UserIdentity, handlers.RedeliverWebhook)
+			}
 		}
 
-		teams := api.Group("/teams")
+		teams := api.Group("/teams", handlers.APIKeyScope("teams"))
 		{
 			teams.POST("", handlers.UserIdentity, handlers.CreateTeam)
 			teams.GET("/company/:company_id", handlers.UserIdentity, handlers.GetTeams)
 			teams.GET("/team/:id", handlers.UserIdentity, handlers.GetTeam)
+			teams.PATCH("/team/:id", handlers.UserIdentity, handlers.UpdateTeam)
+			teams.PUT("/team/:id", handlers.UserIdentity, handlers.UpdateTeam)
 			teams.DELETE("/team/:id", handlers.UserIdentity, handlers.DeleteTeam)
+			teams.GET("/team/:id/subteams", handlers.UserIdentity, handlers.GetSubteams)
+			teams.PUT("/team/:id/parent", handlers.UserIdentity, handlers.SetTeamParent)
+			teams.GET("/team/:id/results", handlers.UserIdentity, handlers.GetTeamResults)
+			teams.POST("/team/:id/restore", handlers.UserIdentity, handlers.RestoreTeam)
+			teams.POST("/team/:id/archive", handlers.UserIdentity, handlers.ArchiveTeam)
+			teams.POST("/team/:id/unarchive", handlers.UserIdentity, handlers.UnarchiveTeam)
+			teams.GET("/team/:id/schedules", handlers.UserIdentity, handlers.GetSurveySchedules)
+			teams.POST("/team/:id/schedules", handlers.UserIdentity, handlers.CreateSurveySchedule)
+			teams.PATCH("/schedules/:schedule_id", handlers.UserIdentity, handlers.UpdateSurveySchedule)
+			teams.DELETE("/schedules/:schedule_id", handlers.UserIdentity, handlers.DeleteSurveySchedule)
 		}
 
 		// Survey routes
-		survey := api.Group("/surveys", handlers.UserIdentity)
+		survey := api.Group("/surveys", handlers.APIKeyScope("surveys"), handlers.UserIdentity)
 		{
 			// General endpoints
 			survey.GET("/questions", handlers.GetSurveyQuestions)
@@ -61,20 +286,84 @@ func main() {
 			survey.POST("", handlers.CreateSurvey)
 			survey.GET("/team/:team_id", handlers.GetSurveysByTeam)
 			survey.GET("/:survey_id", handlers.GetSurvey)
+			survey.PATCH("/:survey_id", handlers.UpdateSurvey)
+			survey.PUT("/:survey_id", handlers.UpdateSurvey)
 			survey.DELETE("/:survey_id", handlers.DeleteSurvey)
+			survey.POST("/:survey_id/restore", handlers.RestoreSurvey)
+			survey.POST("/:survey_id/archive", handlers.ArchiveSurvey)
+			survey.POST("/:survey_id/unarchive", handlers.UnarchiveSurvey)
 
 			// Survey responses as a nested resource
 			survey.POST("/:survey_id/responses", handlers.CreateSurveyResponse)
+			survey.POST("/:survey_id/responses/batch", handlers.SubmitSurveyResponses)
 			survey.GET("/:survey_id/responses", handlers.GetSurveyResponses)
 		}
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.HTTP.Port),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
 	}
 
-	if err := router.Run(":" + port); err != nil {
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("shutting down, waiting up to %s for requests and workers", cfg.HTTP.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http server shutdown: %v", err)
+	}
+	if err := workers.Wait(shutdownCtx); err != nil {
+		log.Printf("workers shutdown: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("closing database: %v", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("flushing traces: %v", err)
+	}
+	log.Print("shutdown complete")
+}
+
+// newMailer sends email over SMTP when a relay is configured and only logs it
+// otherwise.
+func newMailer(cfg config.SMTP) service.Mailer {
+	if cfg.Addr == "" {
+		return service.NewLogMailer()
+	}
+	return service.NewSMTPMailer(cfg.Addr, cfg.From, cfg.Username, cfg.Password)
+}
+
+// newEventSinks returns the sinks outbox events are relayed to besides the
+// webhook queue: an in-process bus that logs every event and, with the
+// "local" broker, the in-memory broker standing in for NATS. The broker name
+// has been validated by config.Load.
+func newEventSinks(broker string) []events.Sink {
+	bus := events.NewBus()
+	bus.Subscribe("", func(ctx context.Context, event model.Event) error {
+		log.Printf("event %s %s company=%d team=%d", event.Type, event.ID, event.CompanyID, event.TeamID)
+		return nil
+	})
+	sinks := []events.Sink{bus}
+
+	if broker == "local" {
+		sinks = append(sinks, events.NewBrokerSink(events.NewLocalBroker(), "teamdetected"))
 	}
+	return sinks
 }
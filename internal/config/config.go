@@ -0,0 +1,460 @@
+// Package config loads the application configuration. Every setting has a
+// default and can be overridden, in increasing order of precedence, by a
+// YAML or TOML file, environment variables (a .env file is read when
+// present) and command-line flags. Secrets may also be read from files,
+// e.g. Docker secrets: DB_PASSWORD_FILE=/run/secrets/db_password.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// Config is the whole configuration. Each leaf field carries its file key
+// ("db.host"), its environment variable and a description; the flag name is
+// the key with dots and underscores turned into dashes ("-db-host"). Fields
+// tagged secret:"true" may instead name a file holding the value with the
+// _file key, the _FILE variable or the -file flag.
+type Config struct {
+	HTTP          HTTP
+	DB            DB
+	Auth          Auth
+	SMTP          SMTP
+	Notifications Notifications
+	Events        Events
+	Retention     Retention
+	Reminders     Reminders
+	Tracing       Tracing
+
+	MigrateOnStart bool `key:"migrate_on_start" env:"MIGRATE_ON_START" help:"apply pending migrations before the server starts"`
+}
+
+type HTTP struct {
+	Port              int           `key:"http.port" env:"PORT" help:"port the HTTP server listens on"`
+	ReadHeaderTimeout time.Duration `key:"http.read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" help:"time to read request headers"`
+	ReadTimeout       time.Duration `key:"http.read_timeout" env:"HTTP_READ_TIMEOUT" help:"time to read a whole request, 0 for no limit"`
+	WriteTimeout      time.Duration `key:"http.write_timeout" env:"HTTP_WRITE_TIMEOUT" help:"time to handle a request and write the response, 0 for no limit"`
+	IdleTimeout       time.Duration `key:"http.idle_timeout" env:"HTTP_IDLE_TIMEOUT" help:"how long idle keep-alive connections stay open"`
+	ShutdownTimeout   time.Duration `key:"http.shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" help:"how long shutdown waits for requests and workers to finish"`
+}
+
+type DB struct {
+	Host            string        `key:"db.host" env:"DB_HOST" help:"Postgres host"`
+	Port            int           `key:"db.port" env:"DB_PORT" help:"Postgres port"`
+	User            string        `key:"db.user" env:"DB_USER" help:"Postgres user"`
+	Password        string        `key:"db.password" env:"DB_PASSWORD" secret:"true" help:"Postgres password"`
+	Name            string        `key:"db.name" env:"DB_NAME" help:"Postgres database"`
+	SSLMode         string        `key:"db.sslmode" env:"DB_SSLMODE" help:"Postgres sslmode"`
+	MaxOpenConns    int           `key:"db.max_open_conns" env:"DB_MAX_OPEN_CONNS" help:"maximum open connections, 0 for no limit"`
+	MaxIdleConns    int           `key:"db.max_idle_conns" env:"DB_MAX_IDLE_CONNS" help:"maximum idle connections"`
+	ConnMaxLifetime time.Duration `key:"db.conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" help:"how long a connection is reused, 0 for ever"`
+	ConnectTimeout  time.Duration `key:"db.connect_timeout" env:"DB_CONNECT_TIMEOUT" help:"timeout for opening a connection"`
+	QueryTimeout    time.Duration `key:"db.query_timeout" env:"DB_QUERY_TIMEOUT" help:"statement timeout, 0 for none"`
+}
+
+type Auth struct {
+	JWTSigningKey     string        `key:"auth.jwt_signing_key" env:"JWT_SIGNING_KEY" secret:"true" help:"HMAC key signing access tokens, at least 32 bytes"`
+	JWTPreviousKeys   []string      `key:"auth.jwt_previous_signing_keys" env:"JWT_PREVIOUS_SIGNING_KEYS" secret:"true" help:"comma-separated retired signing keys whose tokens are still accepted"`
+	AccessTokenTTL    time.Duration `key:"auth.access_token_ttl" env:"ACCESS_TOKEN_TTL" help:"lifetime of access tokens"`
+	ChallengeTokenTTL time.Duration `key:"auth.challenge_token_ttl" env:"CHALLENGE_TOKEN_TTL" help:"lifetime of two-factor challenge tokens"`
+}
+
+type SMTP struct {
+	Addr     string `key:"smtp.addr" env:"SMTP_ADDR" help:"SMTP relay host:port; email is only logged when empty"`
+	From     string `key:"smtp.from" env:"SMTP_FROM" help:"sender address"`
+	Username string `key:"smtp.username" env:"SMTP_USERNAME" help:"SMTP user"`
+	Password string `key:"smtp.password" env:"SMTP_PASSWORD" secret:"true" help:"SMTP password"`
+}
+
+type Notifications struct {
+	WebhookURL string `key:"notifications.webhook_url" env:"NOTIFICATION_WEBHOOK_URL" help:"URL that also receives user notifications"`
+}
+
+type Events struct {
+	Broker string `key:"events.broker" env:"EVENT_BROKER" help:"message broker for domain events: empty or local"`
+}
+
+type Retention struct {
+	SoftDelete time.Duration `key:"retention.soft_delete" env:"SOFT_DELETE_RETENTION" help:"how long deleted records stay restorable"`
+}
+
+type Reminders struct {
+	Offsets []time.Duration `key:"reminders.offsets" env:"SURVEY_REMINDER_OFFSETS" help:"comma-separated times before a survey closes to remind"`
+}
+
+type Tracing struct {
+	Endpoint    string  `key:"tracing.endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" help:"OTLP/HTTP collector URL; tracing is off when empty"`
+	ServiceName string  `key:"tracing.service_name" env:"OTEL_SERVICE_NAME" help:"service name reported with spans"`
+	SampleRatio float64 `key:"tracing.sample_ratio" env:"TRACING_SAMPLE_RATIO" help:"share of new traces recorded, 0 to 1"`
+}
+
+// minSigningKeyLength is the shortest JWT signing key accepted; HS256 keys
+// should be at least as long as the hash.
+const minSigningKeyLength = 32
+
+// Default returns the configuration used for anything not set elsewhere.
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Port:              8080,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		DB: DB{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Name:            "teamdetected",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnectTimeout:  10 * time.Second,
+			QueryTimeout:    30 * time.Second,
+		},
+		Auth: Auth{
+			AccessTokenTTL:    12 * time.Hour,
+			ChallengeTokenTTL: 5 * time.Minute,
+		},
+		SMTP:      SMTP{From: "noreply@example.com"},
+		Retention: Retention{SoftDelete: 30 * 24 * time.Hour},
+		Reminders: Reminders{Offsets: []time.Duration{48 * time.Hour, 4 * time.Hour}},
+		Tracing:   Tracing{ServiceName: "teamdetected", SampleRatio: 1},
+	}
+}
+
+// Load builds the configuration from the defaults, the file named by the
+// -config flag or CONFIG_FILE, the environment and the flags in args, and
+// validates it. It returns the arguments left after the flags.
+func Load(args []string) (Config, []string, error) {
+	fields := leafFields()
+
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFile := flags.String("config", "", "YAML or TOML configuration file")
+	flagValues := make(map[string]string)
+	for _, field := range fields {
+		field := field
+		flags.Func(field.flag(), field.help, func(value string) error {
+			flagValues[field.key] = value
+			return nil
+		})
+		if field.secret {
+			flags.Func(field.flag()+"-file", "file holding "+field.help, func(value string) error {
+				flagValues[field.key+"_file"] = value
+				return nil
+			})
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			flags.SetOutput(os.Stderr)
+			flags.PrintDefaults()
+		}
+		return Config{}, nil, err
+	}
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil, fmt.Errorf(".env: %w", err)
+	}
+
+	cfg := Default()
+	target := reflect.ValueOf(&cfg).Elem()
+
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		fileValues, err := readFile(*configFile)
+		if err != nil {
+			return Config{}, nil, err
+		}
+		if err := apply(target, fields, fileValues, *configFile); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	envValues := make(map[string]string)
+	for _, field := range fields {
+		if value, ok := os.LookupEnv(field.env); ok {
+			envValues[field.key] = value
+		}
+		if value, ok := os.LookupEnv(field.env + "_FILE"); ok && field.secret {
+			envValues[field.key+"_file"] = value
+		}
+	}
+	if err := apply(target, fields, envValues, "environment"); err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := apply(target, fields, flagValues, "flags"); err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, flags.Args(), cfg.Validate()
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Port > 0 && c.HTTP.Port < 65536, "http.port must be between 1 and 65535")
+	check(c.HTTP.ReadHeaderTimeout > 0, "http.read_header_timeout must be positive")
+	check(c.HTTP.ReadTimeout >= 0, "http.read_timeout must not be negative")
+	check(c.HTTP.WriteTimeout >= 0, "http.write_timeout must not be negative")
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout must not be negative")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+
+	check(c.DB.Host != "", "db.host is required")
+	check(c.DB.Port > 0 && c.DB.Port < 65536, "db.port must be between 1 and 65535")
+	check(c.DB.User != "", "db.user is required")
+	check(c.DB.Name != "", "db.name is required")
+	check(c.DB.MaxOpenConns >= 0, "db.max_open_conns must not be negative")
+	check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns must not be negative")
+	check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "db.max_idle_conns must not exceed db.max_open_conns")
+	check(c.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime must not be negative")
+	check(c.DB.ConnectTimeout >= time.Second, "db.connect_timeout must be at least 1s")
+	check(c.DB.QueryTimeout >= 0, "db.query_timeout must not be negative")
+
+	check(len(c.Auth.JWTSigningKey) >= minSigningKeyLength, "auth.jwt_signing_key must be at least %d bytes", minSigningKeyLength)
+	for _, key := range c.Auth.JWTPreviousKeys {
+		check(len(key) >= minSigningKeyLength, "auth.jwt_previous_signing_keys must be at least %d bytes each", minSigningKeyLength)
+	}
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl must be positive")
+	check(c.Auth.ChallengeTokenTTL > 0, "auth.challenge_token_ttl must be positive")
+
+	if c.Notifications.WebhookURL != "" {
+		u, err := url.Parse(c.Notifications.WebhookURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "notifications.webhook_url must be an http or https URL")
+	}
+	if c.Tracing.Endpoint != "" {
+		u, err := url.Parse(c.Tracing.Endpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "tracing.endpoint must be an http or https URL")
+	}
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+	check(c.Events.Broker == "" || c.Events.Broker == "local", "events.broker must be empty or local")
+
+	check(c.Retention.SoftDelete > 0, "retention.soft_delete must be positive")
+	for _, offset := range c.Reminders.Offsets {
+		check(offset > 0, "reminders.offsets must be positive")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+}
+
+// field is a leaf setting of Config.
+type field struct {
+	key    string
+	env    string
+	help   string
+	secret bool
+	index  []int
+}
+
+func (f field) flag() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(f.key)
+}
+
+func leafFields() []field {
+	var fields []field
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			path := append(append([]int(nil), index...), i)
+			if key, ok := sf.Tag.Lookup("key"); ok {
+				fields = append(fields, field{
+					key:    key,
+					env:    sf.Tag.Get("env"),
+					help:   sf.Tag.Get("help"),
+					secret: sf.Tag.Get("secret") == "true",
+					index:  path,
+				})
+				continue
+			}
+			if sf.Type.Kind() == reflect.Struct {
+				walk(sf.Type, path)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), nil)
+	return fields
+}
+
+// apply sets the fields named in values, keyed by file key. A secret's
+// _file key is replaced by the content of the file it names.
+func apply(target reflect.Value, fields []field, values map[string]string, source string) error {
+	known := make(map[string]bool)
+	for _, field := range fields {
+		known[field.key] = true
+		if field.secret {
+			known[field.key+"_file"] = true
+		}
+
+		value, ok := values[field.key]
+		if path, isFile := values[field.key+"_file"]; isFile && field.secret {
+			if ok {
+				return fmt.Errorf("%w: %s: set %s or %s_file, not both", ErrInvalidConfig, source, field.key, field.key)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %s_file: %w", ErrInvalidConfig, source, field.key, err)
+			}
+			value, ok = strings.TrimRight(string(content), "\r\n"), true
+		}
+		if !ok {
+			continue
+		}
+
+		if err := set(target.FieldByIndex(field.index), value); err != nil {
+			return fmt.Errorf("%w: %s: %s: %w", ErrInvalidConfig, source, field.key, err)
+		}
+	}
+
+	var unknown []string
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: %s: unknown settings %s", ErrInvalidConfig, source, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func set(v reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.Slice && v.Type().Elem() == durationType:
+		var durations []time.Duration
+		for _, part := range strings.Split(value, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			d, err := time.ParseDuration(strings.TrimSpace(part))
+			if err != nil {
+				return err
+			}
+			durations = append(durations, d)
+		}
+		v.Set(reflect.ValueOf(durations))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var parts []string
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		v.Set(reflect.ValueOf(parts))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// readFile parses a YAML (.yaml, .yml) or TOML (.toml) file into values keyed
+// by dotted key. Lists become comma-separated values.
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		return nil, fmt.Errorf("%w: %s: use a .yaml, .yml or .toml file", ErrInvalidConfig, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+	}
+
+	values := make(map[string]string)
+	var flatten func(prefix string, node interface{})
+	flatten = func(prefix string, node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			for key, child := range node {
+				if prefix != "" {
+					key = prefix + "." + key
+				}
+				flatten(key, child)
+			}
+		case []interface{}:
+			parts := make([]string, len(node))
+			for i, item := range node {
+				parts[i] = fmt.Sprint(item)
+			}
+			values[prefix] = strings.Join(parts, ",")
+		case nil:
+		default:
+			values[prefix] = fmt.Sprint(node)
+		}
+	}
+	flatten("", tree)
+
+	return values, nil
+}
@@ -0,0 +1,154 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/config"
+)
+
+const signingKey = "0123456789abcdef0123456789abcdef"
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Setenv("JWT_SIGNING_KEY", signingKey)
+
+	cfg, args, err := config.Load([]string{"migrate", "up"})
+	require.NoError(t, err)
+
+	expected := config.Default()
+	expected.Auth.JWTSigningKey = signingKey
+	assert.Equal(t, expected, cfg)
+	assert.Equal(t, []string{"migrate", "up"}, args)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+http:
+  port: 9000
+db:
+  host: db.internal
+  port: 6432
+  query_timeout: 5s
+auth:
+  jwt_signing_key: `+signingKey+`
+reminders:
+  offsets: [24h, 1h]
+`)
+	t.Setenv("DB_HOST", "db.env")
+	t.Setenv("DB_QUERY_TIMEOUT", "10s")
+
+	cfg, args, err := config.Load([]string{"-config", yamlFile, "-db-query-timeout", "15s", "companies"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9000, cfg.HTTP.Port)
+	assert.Equal(t, "db.env", cfg.DB.Host)
+	assert.Equal(t, 6432, cfg.DB.Port)
+	assert.Equal(t, 15*time.Second, cfg.DB.QueryTimeout)
+	assert.Equal(t, []time.Duration{24 * time.Hour, time.Hour}, cfg.Reminders.Offsets)
+	assert.Equal(t, []string{"companies"}, args)
+}
+
+func TestLoad_TOML(t *testing.T) {
+	tomlFile := writeFile(t, "config.toml", `
+migrate_on_start = true
+
+[auth]
+jwt_signing_key = "`+signingKey+`"
+access_token_ttl = "1h"
+
+[events]
+broker = "local"
+`)
+	t.Setenv("CONFIG_FILE", tomlFile)
+
+	cfg, _, err := config.Load(nil)
+	require.NoError(t, err)
+
+	assert.True(t, cfg.MigrateOnStart)
+	assert.Equal(t, time.Hour, cfg.Auth.AccessTokenTTL)
+	assert.Equal(t, "local", cfg.Events.Broker)
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	t.Setenv("JWT_SIGNING_KEY_FILE", writeFile(t, "jwt", signingKey+"\n"))
+	passwordFile := writeFile(t, "db_password", "s3cret\n")
+
+	cfg, _, err := config.Load([]string{"-db-password-file", passwordFile})
+	require.NoError(t, err)
+
+	assert.Equal(t, signingKey, cfg.Auth.JWTSigningKey)
+	assert.Equal(t, "s3cret", cfg.DB.Password)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	testTable := []struct {
+		name string
+		env  map[string]string
+		args []string
+		file string
+	}{
+		{
+			name: "Missing Signing Key",
+		},
+		{
+			name: "Short Signing Key",
+			env:  map[string]string{"JWT_SIGNING_KEY": "your-secret-key"},
+		},
+		{
+			name: "Bad Duration",
+			env:  map[string]string{"JWT_SIGNING_KEY": signingKey, "DB_QUERY_TIMEOUT": "30"},
+		},
+		{
+			name: "Bad Port",
+			env:  map[string]string{"JWT_SIGNING_KEY": signingKey},
+			args: []string{"-http-port", "70000"},
+		},
+		{
+			name: "Unknown Broker",
+			env:  map[string]string{"JWT_SIGNING_KEY": signingKey, "EVENT_BROKER": "kafka"},
+		},
+		{
+			name: "Idle Above Open",
+			env:  map[string]string{"JWT_SIGNING_KEY": signingKey, "DB_MAX_OPEN_CONNS": "5", "DB_MAX_IDLE_CONNS": "10"},
+		},
+		{
+			name: "Sample Ratio Above One",
+			env:  map[string]string{"JWT_SIGNING_KEY": signingKey, "TRACING_SAMPLE_RATIO": "1.5"},
+		},
+		{
+			name: "Secret Twice",
+			env:  map[string]string{"JWT_SIGNING_KEY": signingKey, "JWT_SIGNING_KEY_FILE": "/run/secrets/jwt"},
+		},
+		{
+			name: "Unknown File Key",
+			env:  map[string]string{"JWT_SIGNING_KEY": signingKey},
+			file: "db:\n  hostname: db.internal\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			for key, value := range testCase.env {
+				t.Setenv(key, value)
+			}
+			args := testCase.args
+			if testCase.file != "" {
+				args = append([]string{"-config", writeFile(t, "config.yaml", testCase.file)}, args...)
+			}
+
+			_, _, err := config.Load(args)
+
+			assert.ErrorIs(t, err, config.ErrInvalidConfig)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// Package cron parses schedule rules: five-field cron expressions
+// ("minute hour day-of-month month day-of-week"), the @hourly, @daily,
+// @weekly, @monthly and @yearly shortcuts, and "@every <duration>".
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("invalid schedule rule")
+
+// Schedule yields the activation times of a rule.
+type Schedule interface {
+	// Next returns the first activation strictly after after, evaluated in
+	// after's location, or the zero time if there is none.
+	Next(after time.Time) time.Time
+}
+
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses rule. Cron fields accept *, numbers, ranges (a-b), lists
+// (a,b) and steps (*/n, a-b/n); day-of-week 0 and 7 are both Sunday.
+func Parse(rule string) (Schedule, error) {
+	rule = strings.TrimSpace(rule)
+
+	if value, ok := strings.CutPrefix(rule, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || interval < time.Minute {
+			return nil, fmt.Errorf("%w: @every needs a duration of at least 1m", ErrInvalidRule)
+		}
+		return every(interval), nil
+	}
+	if expr, ok := shortcuts[rule]; ok {
+		rule = expr
+	}
+
+	fields := strings.Fields(rule)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidRule, len(fields))
+	}
+
+	var s spec
+	var err error
+	if s.minute, _, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if s.hour, _, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if s.dom, s.domStar, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if s.month, _, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if s.dow, s.dowStar, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	if s.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("%w: %q never fires", ErrInvalidRule, rule)
+	}
+
+	return s, nil
+}
+
+// parseField returns the bit set of values allowed by field and whether the
+// field starts with "*".
+func parseField(field string, min, max int) (uint64, bool, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, false, fmt.Errorf("%w: bad step in %q", ErrInvalidRule, part)
+			}
+		}
+
+		low, high := min, max
+		if rangePart != "*" {
+			lowText, highText, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(lowText); err != nil {
+				return 0, false, fmt.Errorf("%w: bad value in %q", ErrInvalidRule, part)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highText); err != nil {
+					return 0, false, fmt.Errorf("%w: bad value in %q", ErrInvalidRule, part)
+				}
+			} else if hasStep {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, false, fmt.Errorf("%w: %q is out of range %d-%d", ErrInvalidRule, part, min, max)
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, strings.HasPrefix(field, "*"), nil
+}
+
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+type spec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// Next walks forward field by field, skipping whole months, days and hours
+// that cannot match. It gives up after five years.
+func (s spec) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// dayMatches follows cron: when both day fields are restricted a day matching
+// either of them fires.
+func (s spec) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
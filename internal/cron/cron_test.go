@@ -0,0 +1,93 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/cron"
+)
+
+func TestParse_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name     string
+		rule     string
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Weekly Monday",
+			rule:     "0 9 * * 1",
+			after:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), // Wednesday
+			expected: time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly Shortcut",
+			rule:     "@monthly",
+			after:    time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Strictly After",
+			rule:     "30 8 * * *",
+			after:    time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+			expected: time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "Steps And Lists",
+			rule:     "*/20 9,17 * * 1-5",
+			after:    time.Date(2024, 5, 3, 17, 45, 0, 0, time.UTC), // Friday
+			expected: time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Day Of Month Or Weekday",
+			rule:     "0 0 15 * 0",
+			after:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Sunday As Seven",
+			rule:     "0 0 * * 7",
+			after:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Leap Day",
+			rule:     "0 0 29 2 *",
+			after:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Location",
+			rule:     "0 9 * * *",
+			after:    time.Date(2024, 5, 1, 10, 0, 0, 0, berlin),
+			expected: time.Date(2024, 5, 2, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:     "Every",
+			rule:     "@every 336h",
+			after:    time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			schedule, err := cron.Parse(testCase.rule)
+			require.NoError(t, err)
+
+			assert.True(t, testCase.expected.Equal(schedule.Next(testCase.after)), "got %v", schedule.Next(testCase.after))
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, rule := range []string{"", "* * * *", "60 * * * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "0 0 30 2 *", "@every 10s", "@sometimes"} {
+		_, err := cron.Parse(rule)
+		assert.ErrorIs(t, err, cron.ErrInvalidRule, rule)
+	}
+}
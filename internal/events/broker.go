@@ -0,0 +1,88 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/teamdetected/internal/model"
+)
+
+// Broker is the publishing side of a NATS-style message broker: messages are
+// byte payloads on dot-separated subjects.
+type Broker interface {
+	Publish(subject string, data []byte) error
+}
+
+// BrokerSink publishes every event as JSON on prefix + "." + event type, e.g.
+// "teamdetected.survey.opened".
+type BrokerSink struct {
+	broker Broker
+	prefix string
+}
+
+func NewBrokerSink(broker Broker, prefix string) *BrokerSink {
+	return &BrokerSink{broker: broker, prefix: prefix}
+}
+
+func (s *BrokerSink) Publish(ctx context.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.broker.Publish(s.prefix+"."+event.Type, data)
+}
+
+// MessageHandler receives a message published on a LocalBroker.
+type MessageHandler func(subject string, data []byte)
+
+// LocalBroker is an in-memory stand-in for a NATS server. Subscriptions use
+// NATS subject wildcards: "*" matches one token and a trailing ">" matches
+// one or more.
+type LocalBroker struct {
+	mu            sync.RWMutex
+	subscriptions []localSubscription
+}
+
+type localSubscription struct {
+	pattern []string
+	handler MessageHandler
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{}
+}
+
+func (b *LocalBroker) Subscribe(pattern string, handler MessageHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscriptions = append(b.subscriptions, localSubscription{pattern: strings.Split(pattern, "."), handler: handler})
+}
+
+func (b *LocalBroker) Publish(subject string, data []byte) error {
+	tokens := strings.Split(subject, ".")
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subscription := range b.subscriptions {
+		if matchSubject(subscription.pattern, tokens) {
+			subscription.handler(subject, data)
+		}
+	}
+	return nil
+}
+
+func matchSubject(pattern, tokens []string) bool {
+	for i, token := range pattern {
+		if token == ">" {
+			return i == len(pattern)-1 && len(tokens) > i
+		}
+		if i >= len(tokens) || token != "*" && token != tokens[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(tokens)
+}
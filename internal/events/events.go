@@ -0,0 +1,32 @@
+// Package events delivers domain events relayed from the outbox to sinks: an
+// in-process Bus, a message broker through BrokerSink, or any other Sink such
+// as the webhook queue. Delivery is at least once, so sinks and their
+// consumers must tolerate an event ID they have already seen.
+package events
+
+import (
+	"context"
+	"errors"
+
+	"github.com/teamdetected/internal/model"
+)
+
+// Sink receives relayed events. A returned error makes the relay retry the
+// event later.
+type Sink interface {
+	Publish(ctx context.Context, event model.Event) error
+}
+
+// Sinks fans an event out to every sink. All sinks are tried and their errors
+// are joined; a retry delivers the event to every sink again.
+type Sinks []Sink
+
+func (s Sinks) Publish(ctx context.Context, event model.Event) error {
+	var errs []error
+	for _, sink := range s {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/events"
+	"github.com/teamdetected/internal/model"
+)
+
+func TestLocalBroker_Subscribe(t *testing.T) {
+	testTable := []struct {
+		pattern string
+		subject string
+		matches bool
+	}{
+		{pattern: "teamdetected.survey.opened", subject: "teamdetected.survey.opened", matches: true},
+		{pattern: "teamdetected.survey.*", subject: "teamdetected.survey.closed", matches: true},
+		{pattern: "teamdetected.*", subject: "teamdetected.survey.closed", matches: false},
+		{pattern: "teamdetected.>", subject: "teamdetected.survey.closed", matches: true},
+		{pattern: "teamdetected.>", subject: "teamdetected", matches: false},
+		{pattern: "teamdetected.team.created", subject: "teamdetected.survey.opened", matches: false},
+		{pattern: "teamdetected.survey", subject: "teamdetected.survey.opened", matches: false},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.pattern+" "+testCase.subject, func(t *testing.T) {
+			broker := events.NewLocalBroker()
+			received := false
+			broker.Subscribe(testCase.pattern, func(subject string, data []byte) {
+				received = true
+			})
+
+			assert.NoError(t, broker.Publish(testCase.subject, []byte("{}")))
+			assert.Equal(t, testCase.matches, received)
+		})
+	}
+}
+
+func TestBrokerSink_Publish(t *testing.T) {
+	broker := events.NewLocalBroker()
+	var subjects []string
+	broker.Subscribe("teamdetected.>", func(subject string, data []byte) {
+		subjects = append(subjects, subject)
+	})
+
+	sink := events.NewBrokerSink(broker, "teamdetected")
+	assert.NoError(t, sink.Publish(context.Background(), model.Event{ID: "1", Type: model.EventTeamCreated, Data: []byte("{}")}))
+	assert.Equal(t, []string{"teamdetected.team.created"}, subjects)
+}
+
+func TestSinks_Publish(t *testing.T) {
+	bus := events.NewBus()
+	var seen []string
+	bus.Subscribe(model.EventSurveyOpened, func(ctx context.Context, event model.Event) error {
+		seen = append(seen, "opened:"+event.ID)
+		return nil
+	})
+	bus.Subscribe("", func(ctx context.Context, event model.Event) error {
+		seen = append(seen, "all:"+event.ID)
+		return nil
+	})
+
+	failing := events.NewBus()
+	failing.Subscribe("", func(ctx context.Context, event model.Event) error {
+		return errors.New("unavailable")
+	})
+
+	err := events.Sinks{failing, bus}.Publish(context.Background(), model.Event{ID: "7", Type: model.EventSurveyOpened})
+	assert.EqualError(t, err, "unavailable")
+	assert.Equal(t, []string{"opened:7", "all:7"}, seen)
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+func (h *Handler) GetProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	user, err := h.services.Account.GetProfile(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var input model.UpdateProfileInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	user, err := h.services.Account.UpdateProfile(c.Request.Context(), userID.(int), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var input model.ChangePasswordInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	if err := h.services.Account.ChangePassword(c.Request.Context(), userID.(int), input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+}
+
+func (h *Handler) ChangeEmail(c *gin.Context) {
+	var input model.ChangeEmailInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	if err := h.services.Account.RequestEmailChange(c.Request.Context(), userID.(int), input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
+
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var input model.VerifyEmailInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.Account.ConfirmEmailChange(c.Request.Context(), input.Token); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email changed successfully"})
+}
+
+func (h *Handler) GetMemberships(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	memberships, err := h.services.Account.GetMemberships(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, memberships)
+}
+
+func (h *Handler) GetNotificationSettings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	settings, err := h.services.Account.GetNotificationSettings(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *Handler) UpdateNotificationSettings(c *gin.Context) {
+	var input model.UpdateNotificationSettingsInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	settings, err := h.services.Account.UpdateNotificationSettings(c.Request.Context(), userID.(int), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestHandler_ChangePassword(t *testing.T) {
+	type mockBehavior func(s *mocks.Account, input model.ChangePasswordInput)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		input               model.ChangePasswordInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			inputBody: `{
+				"current_password": "old-password",
+				"new_password": "new-password"
+			}`,
+			input: model.ChangePasswordInput{CurrentPassword: "old-password", NewPassword: "new-password"},
+			mockBehavior: func(s *mocks.Account, input model.ChangePasswordInput) {
+				s.On("ChangePassword", 1, input).Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"password changed successfully"}`,
+		},
+		{
+			name: "Wrong Current Password",
+			inputBody: `{
+				"current_password": "wrong",
+				"new_password": "new-password"
+			}`,
+			input: model.ChangePasswordInput{CurrentPassword: "wrong", NewPassword: "new-password"},
+			mockBehavior: func(s *mocks.Account, input model.ChangePasswordInput) {
+				s.On("ChangePassword", 1, input).Return(model.ErrInvalidPassword)
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"error":{"code":"unauthorized","message":"invalid password"}}`,
+		},
+		{
+			name: "Short Password",
+			inputBody: `{
+				"current_password": "old-password",
+				"new_password": "short"
+			}`,
+			mockBehavior:        func(s *mocks.Account, input model.ChangePasswordInput) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'ChangePasswordInput.NewPassword' Error:Field validation for 'NewPassword' failed on the 'min' tag"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			accountMock := mocks.NewAccount(t)
+			testCase.mockBehavior(accountMock, testCase.input)
+
+			services := &service.Service{Account: accountMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.PUT("/api/v1/users/me/password", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.ChangePassword(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/v1/users/me/password",
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_ChangeEmail(t *testing.T) {
+	type mockBehavior func(s *mocks.Account, input model.ChangeEmailInput)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		input               model.ChangeEmailInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			inputBody: `{
+				"new_email": "new@test.com",
+				"password": "password"
+			}`,
+			input: model.ChangeEmailInput{NewEmail: "new@test.com", Password: "password"},
+			mockBehavior: func(s *mocks.Account, input model.ChangeEmailInput) {
+				s.On("RequestEmailChange", 1, input).Return(nil)
+			},
+			expectedStatusCode:  http.StatusAccepted,
+			expectedRequestBody: `{"message":"verification email sent"}`,
+		},
+		{
+			name: "Email Taken",
+			inputBody: `{
+				"new_email": "taken@test.com",
+				"password": "password"
+			}`,
+			input: model.ChangeEmailInput{NewEmail: "taken@test.com", Password: "password"},
+			mockBehavior: func(s *mocks.Account, input model.ChangeEmailInput) {
+				s.On("RequestEmailChange", 1, input).Return(model.ErrEmailTaken)
+			},
+			expectedStatusCode:  http.StatusConflict,
+			expectedRequestBody: `{"error":{"code":"conflict","message":"email is already in use"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			accountMock := mocks.NewAccount(t)
+			testCase.mockBehavior(accountMock, testCase.input)
+
+			services := &service.Service{Account: accountMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/users/me/email", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.ChangeEmail(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/users/me/email",
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_UpdateNotificationSettings(t *testing.T) {
+	type mockBehavior func(s *mocks.Account, input model.UpdateNotificationSettingsInput)
+
+	optOut := false
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		input               model.UpdateNotificationSettingsInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "Opt Out",
+			inputBody: `{"survey_reminders": false}`,
+			input:     model.UpdateNotificationSettingsInput{SurveyReminders: &optOut},
+			mockBehavior: func(s *mocks.Account, input model.UpdateNotificationSettingsInput) {
+				s.On("UpdateNotificationSettings", 1, input).Return(model.NotificationSettings{SurveyReminders: false}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"survey_reminders":false}`,
+		},
+		{
+			name:                "Missing Field",
+			inputBody:           `{}`,
+			mockBehavior:        func(s *mocks.Account, input model.UpdateNotificationSettingsInput) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'UpdateNotificationSettingsInput.SurveyReminders' Error:Field validation for 'SurveyReminders' failed on the 'required' tag"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			accountMock := mocks.NewAccount(t)
+			testCase.mockBehavior(accountMock, testCase.input)
+
+			services := &service.Service{Account: accountMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.PUT("/api/v1/users/me/notifications", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.UpdateNotificationSettings(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/v1/users/me/notifications",
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var input model.CreateAPIKeyInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	key, err := h.services.APIKey.CreateAPIKey(c.Request.Context(), userID.(int), input)
+	if errors.Is(err, model.ErrInvalidInput) {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	keys, err := h.services.APIKey.GetAPIKeys(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	err = h.services.APIKey.RevokeAPIKey(c.Request.Context(), userID.(int), id)
+	if errors.Is(err, model.ErrNotFound) {
+		newErrorResponse(c, http.StatusNotFound, "api key not found")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "api key revoked successfully"})
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestHandler_UserIdentityAPIKey(t *testing.T) {
+	type mockBehavior func(s *mocks.APIKey)
+
+	const rawKey = "tdk_abcdefgh_secret"
+
+	testTable := []struct {
+		name                string
+		method              string
+		resource            string
+		noAPIKeys           bool
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:     "Read Scope",
+			method:   "GET",
+			resource: "teams",
+			mockBehavior: func(s *mocks.APIKey) {
+				s.On("AuthenticateAPIKey", rawKey).Return(model.APIKeyPrincipal{
+					KeyID: 3, UserID: 7, UserRole: "manager", Scopes: []string{model.ScopeTeamsRead},
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"user_id":7}`,
+		},
+		{
+			name:     "Write Scope Implies Read",
+			method:   "GET",
+			resource: "teams",
+			mockBehavior: func(s *mocks.APIKey) {
+				s.On("AuthenticateAPIKey", rawKey).Return(model.APIKeyPrincipal{
+					KeyID: 3, UserID: 7, Scopes: []string{model.ScopeTeamsWrite},
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"user_id":7}`,
+		},
+		{
+			name:     "Missing Write Scope",
+			method:   "POST",
+			resource: "teams",
+			mockBehavior: func(s *mocks.APIKey) {
+				s.On("AuthenticateAPIKey", rawKey).Return(model.APIKeyPrincipal{
+					KeyID: 3, UserID: 7, Scopes: []string{model.ScopeTeamsRead},
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"api key scope does not allow this request"}}`,
+		},
+		{
+			name:                "Route Without API Key Access",
+			method:              "GET",
+			mockBehavior:        func(s *mocks.APIKey) {},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"api keys are not allowed for this endpoint"}}`,
+		},
+		{
+			name:                "Session-Only Route In Scoped Group",
+			method:              "GET",
+			resource:            "companies",
+			noAPIKeys:           true,
+			mockBehavior:        func(s *mocks.APIKey) {},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"api keys are not allowed for this endpoint"}}`,
+		},
+		{
+			name:     "Revoked Key",
+			method:   "GET",
+			resource: "teams",
+			mockBehavior: func(s *mocks.APIKey) {
+				s.On("AuthenticateAPIKey", rawKey).Return(model.APIKeyPrincipal{}, model.ErrInvalidAPIKey)
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"error":{"code":"unauthorized","message":"invalid api key"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			apiKeyMock := mocks.NewAPIKey(t)
+			testCase.mockBehavior(apiKeyMock)
+
+			services := &service.Service{APIKey: apiKeyMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			group := c.Group("/api/v1/resource")
+			if testCase.resource != "" {
+				group.Use(handler.APIKeyScope(testCase.resource))
+			}
+			if testCase.noAPIKeys {
+				group.Use(handler.NoAPIKeys)
+			}
+			group.Handle(testCase.method, "", handler.UserIdentity, func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt("userID")})
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, "/api/v1/resource", nil)
+			req.Header.Set("Authorization", "Bearer "+rawKey)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_RevokeAPIKey(t *testing.T) {
+	type mockBehavior func(s *mocks.APIKey)
+
+	testTable := []struct {
+		name                string
+		inputID             string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			inputID: "1",
+			mockBehavior: func(s *mocks.APIKey) {
+				s.On("RevokeAPIKey", 1, 1).Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"api key revoked successfully"}`,
+		},
+		{
+			name:    "Not Found",
+			inputID: "2",
+			mockBehavior: func(s *mocks.APIKey) {
+				s.On("RevokeAPIKey", 1, 2).Return(model.ErrNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"api key not found"}}`,
+		},
+		{
+			name:                "Invalid ID",
+			inputID:             "invalid",
+			mockBehavior:        func(s *mocks.APIKey) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid id"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			apiKeyMock := mocks.NewAPIKey(t)
+			testCase.mockBehavior(apiKeyMock)
+
+			services := &service.Service{APIKey: apiKeyMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.DELETE("/api/v1/auth/api-keys/:id", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.RevokeAPIKey(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/api/v1/auth/api-keys/"+testCase.inputID, nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
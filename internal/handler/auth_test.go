@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -27,17 +28,36 @@ func TestHandler_Register(t *testing.T) {
 	}{
 		{
 			name: "OK",
+			inputBody: `{
+				"email": "test@test.com",
+				"password": "test123456",
+				"name": "Test User"
+			}`,
+			inputUser: model.User{
+				Email:    "test@test.com",
+				Password: "test123456",
+				Name:     "Test User",
+				Role:     "team",
+			},
+			mockBehavior: func(s *mocks.Authorization, user model.User) {
+				s.On("CreateUser", user).Return(1, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"id":1}`,
+		},
+		{
+			name: "Role Ignored",
 			inputBody: `{
 				"email": "test@test.com",
 				"password": "test123456",
 				"name": "Test User",
-				"role": "user"
+				"role": "admin"
 			}`,
 			inputUser: model.User{
 				Email:    "test@test.com",
 				Password: "test123456",
 				Name:     "Test User",
-				Role:     "user",
+				Role:     "team",
 			},
 			mockBehavior: func(s *mocks.Authorization, user model.User) {
 				s.On("CreateUser", user).Return(1, nil)
@@ -45,17 +65,35 @@ func TestHandler_Register(t *testing.T) {
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"id":1}`,
 		},
+		{
+			name: "Email Taken",
+			inputBody: `{
+				"email": "test@test.com",
+				"password": "test123456",
+				"name": "Test User"
+			}`,
+			inputUser: model.User{
+				Email:    "test@test.com",
+				Password: "test123456",
+				Name:     "Test User",
+				Role:     "team",
+			},
+			mockBehavior: func(s *mocks.Authorization, user model.User) {
+				s.On("CreateUser", user).Return(0, model.ErrEmailTaken)
+			},
+			expectedStatusCode:  http.StatusConflict,
+			expectedRequestBody: `{"error":{"code":"conflict","message":"email is already in use"}}`,
+		},
 		{
 			name: "Empty Fields",
 			inputBody: `{
 				"email": "",
 				"password": "",
-				"name": "",
-				"role": ""
+				"name": ""
 			}`,
 			mockBehavior:        func(s *mocks.Authorization, user model.User) {},
 			expectedStatusCode:  http.StatusBadRequest,
-			expectedRequestBody: `{"error":"Key: 'SignUpInput.Email' Error:Field validation for 'Email' failed on the 'required' tag\nKey: 'SignUpInput.Password' Error:Field validation for 'Password' failed on the 'required' tag\nKey: 'SignUpInput.Name' Error:Field validation for 'Name' failed on the 'required' tag\nKey: 'SignUpInput.Role' Error:Field validation for 'Role' failed on the 'required' tag"}`,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'SignUpInput.Email' Error:Field validation for 'Email' failed on the 'required' tag\nKey: 'SignUpInput.Password' Error:Field validation for 'Password' failed on the 'required' tag\nKey: 'SignUpInput.Name' Error:Field validation for 'Name' failed on the 'required' tag"}}`,
 		},
 	}
 
@@ -63,6 +101,7 @@ func TestHandler_Register(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			authMock := mocks.NewAuthorization(t)
 			testCase.mockBehavior(authMock, testCase.inputUser)
 
@@ -108,11 +147,28 @@ func TestHandler_Login(t *testing.T) {
 			email:    "test@test.com",
 			password: "test123",
 			mockBehavior: func(s *mocks.Authorization, email, password string) {
-				s.On("GenerateToken", email, password).Return("test-token", nil)
+				s.On("GenerateToken", email, password).Return(model.SignInResult{Token: "test-token"}, nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"token":"test-token"}`,
 		},
+		{
+			name: "Two-Factor Challenge",
+			inputBody: `{
+				"email": "test@test.com",
+				"password": "test123"
+			}`,
+			email:    "test@test.com",
+			password: "test123",
+			mockBehavior: func(s *mocks.Authorization, email, password string) {
+				s.On("GenerateToken", email, password).Return(model.SignInResult{
+					ChallengeToken:    "challenge-token",
+					TwoFactorRequired: true,
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"challenge_token":"challenge-token","two_factor_required":true}`,
+		},
 		{
 			name: "Wrong Input",
 			inputBody: `{
@@ -120,7 +176,7 @@ func TestHandler_Login(t *testing.T) {
 			}`,
 			mockBehavior:        func(s *mocks.Authorization, email, password string) {},
 			expectedStatusCode:  http.StatusBadRequest,
-			expectedRequestBody: `{"error":"Key: 'SignInInput.Password' Error:Field validation for 'Password' failed on the 'required' tag"}`,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'SignInInput.Password' Error:Field validation for 'Password' failed on the 'required' tag"}}`,
 		},
 	}
 
@@ -128,6 +184,7 @@ func TestHandler_Login(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			authMock := mocks.NewAuthorization(t)
 			testCase.mockBehavior(authMock, testCase.email, testCase.password)
 
@@ -153,30 +210,65 @@ func TestHandler_Login(t *testing.T) {
 }
 
 func TestHandler_DeleteUser(t *testing.T) {
-	type mockBehavior func(s *mocks.Authorization, id int)
+	type mockBehavior func(s *mocks.Authorization, requester model.Requester, id int)
 
 	testTable := []struct {
 		name                string
 		inputID             string
+		query               string
+		requester           model.Requester
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
 	}{
 		{
-			name:    "OK",
-			inputID: "1",
-			mockBehavior: func(s *mocks.Authorization, id int) {
-				s.On("DeleteUser", id).Return(nil)
+			name:      "OK",
+			inputID:   "1",
+			requester: model.Requester{UserID: 1, Role: "manager"},
+			mockBehavior: func(s *mocks.Authorization, requester model.Requester, id int) {
+				s.On("DeleteUser", requester, id, 0).Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"user deleted successfully"}`,
+		},
+		{
+			name:      "Admin With Transfer",
+			inputID:   "2",
+			query:     "?transfer_to=3",
+			requester: model.Requester{UserID: 1, Role: "admin"},
+			mockBehavior: func(s *mocks.Authorization, requester model.Requester, id int) {
+				s.On("DeleteUser", requester, id, 3).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"user deleted successfully"}`,
 		},
+		{
+			name:      "Other User",
+			inputID:   "2",
+			requester: model.Requester{UserID: 1, Role: "manager"},
+			mockBehavior: func(s *mocks.Authorization, requester model.Requester, id int) {
+				s.On("DeleteUser", requester, id, 0).Return(model.ErrForbidden)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"only the user or an admin can delete this account"}}`,
+		},
+		{
+			name:      "Owns Companies",
+			inputID:   "1",
+			requester: model.Requester{UserID: 1, Role: "manager"},
+			mockBehavior: func(s *mocks.Authorization, requester model.Requester, id int) {
+				s.On("DeleteUser", requester, id, 0).Return(model.ErrOwnsCompanies)
+			},
+			expectedStatusCode:  http.StatusConflict,
+			expectedRequestBody: `{"error":{"code":"conflict","message":"user owns companies; pass transfer_to to hand them over"}}`,
+		},
 		{
 			name:                "Invalid ID",
 			inputID:             "invalid",
-			mockBehavior:        func(s *mocks.Authorization, id int) {},
+			requester:           model.Requester{UserID: 1, Role: "manager"},
+			mockBehavior:        func(s *mocks.Authorization, requester model.Requester, id int) {},
 			expectedStatusCode:  http.StatusBadRequest,
-			expectedRequestBody: `{"error":"invalid id"}`,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid id"}}`,
 		},
 	}
 
@@ -184,19 +276,101 @@ func TestHandler_DeleteUser(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			authMock := mocks.NewAuthorization(t)
 			id, _ := strconv.Atoi(testCase.inputID)
-			testCase.mockBehavior(authMock, id)
+			testCase.mockBehavior(authMock, testCase.requester, id)
+
+			services := &service.Service{Authorization: authMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.DELETE("/api/v1/auth/users/:id", func(c *gin.Context) {
+				c.Set("userID", testCase.requester.UserID)
+				c.Set("userRole", testCase.requester.Role)
+				handler.DeleteUser(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/api/v1/auth/users/"+testCase.inputID+testCase.query, nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_UserIdentity(t *testing.T) {
+	type mockBehavior func(s *mocks.Authorization)
+
+	testTable := []struct {
+		name                string
+		header              string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			header: "Bearer token",
+			mockBehavior: func(s *mocks.Authorization) {
+				s.On("AuthenticateToken", "token").Return(model.AccessClaims{UserID: 1, Role: "team"}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"user_id":1}`,
+		},
+		{
+			name:                "Invalid Header",
+			header:              "Token token",
+			mockBehavior:        func(s *mocks.Authorization) {},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"error":{"code":"unauthorized","message":"invalid auth header"}}`,
+		},
+		{
+			name:   "Deleted User",
+			header: "Bearer token",
+			mockBehavior: func(s *mocks.Authorization) {
+				s.On("AuthenticateToken", "token").Return(model.AccessClaims{}, fmt.Errorf("%w: user no longer exists", model.ErrUnauthorized))
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"error":{"code":"unauthorized","message":"unauthorized: user no longer exists"}}`,
+		},
+		{
+			name:   "Two-Factor Setup Required",
+			header: "Bearer token",
+			mockBehavior: func(s *mocks.Authorization) {
+				s.On("AuthenticateToken", "token").Return(model.AccessClaims{UserID: 1, Role: "admin", TwoFactorSetupRequired: true}, nil)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"two-factor authentication setup required"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			authMock := mocks.NewAuthorization(t)
+			testCase.mockBehavior(authMock)
 
 			services := &service.Service{Authorization: authMock}
 			handler := NewHandler(services)
 
 			// Test Server
-			c.DELETE("/api/v1/auth/users/:id", handler.DeleteUser)
+			c.GET("/api/v1/resource", handler.UserIdentity, func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt("userID")})
+			})
 
 			// Test Request
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("DELETE", "/api/v1/auth/users/"+testCase.inputID, nil)
+			req := httptest.NewRequest("GET", "/api/v1/resource", nil)
+			req.Header.Set("Authorization", testCase.header)
 
 			// Perform Request
 			c.ServeHTTP(w, req)
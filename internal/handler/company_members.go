@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+func (h *Handler) GetCompanyMembers(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	members, err := h.services.Company.GetCompanyMembers(c.Request.Context(), userID.(int), companyID)
+	if err != nil {
+		memberErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *Handler) AddCompanyMember(c *gin.Context) {
+	var input model.AddCompanyMemberInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.services.Company.AddCompanyMember(c.Request.Context(), userID.(int), companyID, input); err != nil {
+		memberErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "invitation sent"})
+}
+
+func (h *Handler) UpdateCompanyMember(c *gin.Context) {
+	var input model.UpdateCompanyMemberInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	companyID, memberID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Company.UpdateCompanyMember(c.Request.Context(), userID.(int), companyID, memberID, input); err != nil {
+		memberErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member updated successfully"})
+}
+
+func (h *Handler) RemoveCompanyMember(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	companyID, memberID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Company.RemoveCompanyMember(c.Request.Context(), userID.(int), companyID, memberID); err != nil {
+		memberErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member removed successfully"})
+}
+
+func (h *Handler) TransferCompanyOwnership(c *gin.Context) {
+	var input model.TransferOwnershipInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.services.Company.TransferCompanyOwnership(c.Request.Context(), userID.(int), companyID, input.UserID); err != nil {
+		memberErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ownership transferred successfully"})
+}
+
+// AcceptCompanyInvitation joins the signed-in user to the company of an
+// invitation sent by an import.
+func (h *Handler) AcceptCompanyInvitation(c *gin.Context) {
+	var input model.AcceptInvitationInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	companyID, err := h.services.Company.AcceptCompanyInvitation(c.Request.Context(), userID.(int), input.Token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"company_id": companyID})
+}
+
+func memberParams(c *gin.Context) (int, int, bool) {
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return 0, 0, false
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid user id")
+		return 0, 0, false
+	}
+
+	return companyID, memberID, true
+}
+
+func memberErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrForbidden):
+		newErrorResponse(c, http.StatusForbidden, "insufficient company role")
+	case errors.Is(err, model.ErrNotFound):
+		newErrorResponse(c, http.StatusNotFound, "user not found")
+	case errors.Is(err, model.ErrInvalidInput):
+		newErrorResponse(c, http.StatusBadRequest, "invalid member or role")
+	default:
+		c.Error(err)
+	}
+}
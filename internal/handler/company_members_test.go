@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestHandler_AddCompanyMember(t *testing.T) {
+	type mockBehavior func(s *mocks.Company, input model.AddCompanyMemberInput)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		input               model.AddCompanyMemberInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"email": "manager@example.com", "role": "manager"}`,
+			input:     model.AddCompanyMemberInput{Email: "manager@example.com", Role: model.CompanyRoleManager},
+			mockBehavior: func(s *mocks.Company, input model.AddCompanyMemberInput) {
+				s.On("AddCompanyMember", 1, 5, input).Return(nil)
+			},
+			expectedStatusCode:  http.StatusAccepted,
+			expectedRequestBody: `{"message":"invitation sent"}`,
+		},
+		{
+			name:      "Insufficient Role",
+			inputBody: `{"email": "admin@example.com", "role": "admin"}`,
+			input:     model.AddCompanyMemberInput{Email: "admin@example.com", Role: model.CompanyRoleAdmin},
+			mockBehavior: func(s *mocks.Company, input model.AddCompanyMemberInput) {
+				s.On("AddCompanyMember", 1, 5, input).Return(model.ErrForbidden)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"insufficient company role"}}`,
+		},
+		{
+			name:                "Missing Role",
+			inputBody:           `{"email": "manager@example.com"}`,
+			mockBehavior:        func(s *mocks.Company, input model.AddCompanyMemberInput) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'AddCompanyMemberInput.Role' Error:Field validation for 'Role' failed on the 'required' tag"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			companyMock := mocks.NewCompany(t)
+			testCase.mockBehavior(companyMock, testCase.input)
+
+			services := &service.Service{Company: companyMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/companies/:id/members", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.AddCompanyMember(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/companies/5/members", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_TransferCompanyOwnership(t *testing.T) {
+	type mockBehavior func(s *mocks.Company)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"user_id": 2}`,
+			mockBehavior: func(s *mocks.Company) {
+				s.On("TransferCompanyOwnership", 1, 5, 2).Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"ownership transferred successfully"}`,
+		},
+		{
+			name:      "Not Owner",
+			inputBody: `{"user_id": 2}`,
+			mockBehavior: func(s *mocks.Company) {
+				s.On("TransferCompanyOwnership", 1, 5, 2).Return(model.ErrForbidden)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"insufficient company role"}}`,
+		},
+		{
+			name:      "Not A Member",
+			inputBody: `{"user_id": 3}`,
+			mockBehavior: func(s *mocks.Company) {
+				s.On("TransferCompanyOwnership", 1, 5, 3).Return(model.ErrInvalidInput)
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid member or role"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			companyMock := mocks.NewCompany(t)
+			testCase.mockBehavior(companyMock)
+
+			services := &service.Service{Company: companyMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/companies/:id/transfer-ownership", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.TransferCompanyOwnership(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/companies/5/transfer-ownership", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_AcceptCompanyInvitation(t *testing.T) {
+	type mockBehavior func(s *mocks.Company)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"token": "invite-token"}`,
+			mockBehavior: func(s *mocks.Company) {
+				s.On("AcceptCompanyInvitation", 1, "invite-token").Return(5, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"company_id":5}`,
+		},
+		{
+			name:      "Invalid Or Other Email",
+			inputBody: `{"token": "invite-token"}`,
+			mockBehavior: func(s *mocks.Company) {
+				s.On("AcceptCompanyInvitation", 1, "invite-token").Return(0, model.ErrInvalidInvitation)
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invitation is invalid or expired"}}`,
+		},
+		{
+			name:                "Missing Token",
+			inputBody:           `{}`,
+			mockBehavior:        func(s *mocks.Company) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'AcceptInvitationInput.Token' Error:Field validation for 'Token' failed on the 'required' tag"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			companyMock := mocks.NewCompany(t)
+			testCase.mockBehavior(companyMock)
+
+			services := &service.Service{Company: companyMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/users/me/invitations/accept", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.AcceptCompanyInvitation(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/users/me/invitations/accept", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -25,6 +25,32 @@ func TestHandler_CreateCompany(t *testing.T) {
 		expectedStatusCode  int
 		expectedRequestBody string
 	}{
+		{
+			name: "With First Team",
+			inputBody: `{
+				"name": "Test Company",
+				"first_team": {"name": "Platform"}
+			}`,
+			inputCompany: model.Company{
+				Name:      "Test Company",
+				CreatedBy: 1,
+			},
+			mockBehavior: func(s *mocks.Company, company model.Company) {
+				s.On("CreateCompanyWithTeam", company, model.Team{Name: "Platform"}).Return(1, 4, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"id":1,"team_id":4}`,
+		},
+		{
+			name: "First Team Without Name",
+			inputBody: `{
+				"name": "Test Company",
+				"first_team": {"description": "Platform"}
+			}`,
+			mockBehavior:        func(s *mocks.Company, company model.Company) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'CreateCompanyInput.FirstTeam.Name' Error:Field validation for 'Name' failed on the 'required' tag"}}`,
+		},
 		{
 			name: "OK",
 			inputBody: `{
@@ -50,7 +76,7 @@ func TestHandler_CreateCompany(t *testing.T) {
 			}`,
 			mockBehavior:        func(s *mocks.Company, company model.Company) {},
 			expectedStatusCode:  http.StatusBadRequest,
-			expectedRequestBody: `{"error":"Key: 'CreateCompanyInput.Name' Error:Field validation for 'Name' failed on the 'required' tag"}`,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'CreateCompanyInput.Name' Error:Field validation for 'Name' failed on the 'required' tag"}}`,
 		},
 	}
 
@@ -58,6 +84,7 @@ func TestHandler_CreateCompany(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			companyMock := mocks.NewCompany(t)
 			testCase.mockBehavior(companyMock, testCase.inputCompany)
 
@@ -99,24 +126,26 @@ func TestHandler_GetCompanies(t *testing.T) {
 			name:   "OK",
 			userID: "1",
 			mockBehavior: func(s *mocks.Company, userID int) {
-				s.On("GetCompaniesByUserID", userID).Return([]model.Company{
-					{
-						ID:          1,
-						Name:        "Test Company",
-						Description: "Test Description",
-						CreatedBy:   1,
+				s.On("GetCompaniesByUserID", userID, model.ListQuery{}).Return(model.Page[model.Company]{
+					Data: []model.Company{
+						{
+							ID:          1,
+							Name:        "Test Company",
+							Description: "Test Description",
+							CreatedBy:   1,
+						},
 					},
 				}, nil)
 			},
 			expectedStatusCode:  http.StatusOK,
-			expectedRequestBody: `[{"id":1,"name":"Test Company","description":"Test Description","created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]`,
+			expectedRequestBody: `{"data":[{"id":1,"name":"Test Company","description":"Test Description","created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]}`,
 		},
 		{
 			name:                "Unauthorized",
 			userID:              "",
 			mockBehavior:        func(s *mocks.Company, userID int) {},
 			expectedStatusCode:  http.StatusUnauthorized,
-			expectedRequestBody: `{"error":"user not authenticated"}`,
+			expectedRequestBody: `{"error":{"code":"unauthorized","message":"user not authenticated"}}`,
 		},
 	}
 
@@ -124,6 +153,7 @@ func TestHandler_GetCompanies(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			companyMock := mocks.NewCompany(t)
 			userID, _ := strconv.Atoi(testCase.userID)
 			testCase.mockBehavior(companyMock, userID)
@@ -153,6 +183,84 @@ func TestHandler_GetCompanies(t *testing.T) {
 	}
 }
 
+func TestHandler_GetCompany(t *testing.T) {
+	type mockBehavior func(s *mocks.Company, id int)
+
+	rate := 0.75
+
+	testTable := []struct {
+		name                string
+		inputID             string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			inputID: "1",
+			mockBehavior: func(s *mocks.Company, id int) {
+				s.On("GetCompanyByID", 1, id).Return(model.Company{
+					ID:        1,
+					Name:      "Test Company",
+					CreatedBy: 1,
+					Stats: &model.CompanyStats{
+						TeamsCount:                 3,
+						EmployeesCount:             12,
+						ActiveSurveysCount:         1,
+						LatestSurveyCompletionRate: &rate,
+					},
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"id":1,"name":"Test Company","description":"","created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","stats":{"teams_count":3,"employees_count":12,"active_surveys_count":1,"latest_survey_completion_rate":0.75}}`,
+		},
+		{
+			name:    "No Surveys",
+			inputID: "2",
+			mockBehavior: func(s *mocks.Company, id int) {
+				s.On("GetCompanyByID", 1, id).Return(model.Company{
+					ID:        2,
+					Name:      "New Company",
+					CreatedBy: 1,
+					Stats:     &model.CompanyStats{EmployeesCount: 1},
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"id":2,"name":"New Company","description":"","created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","stats":{"teams_count":0,"employees_count":1,"active_surveys_count":0,"latest_survey_completion_rate":null}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			companyMock := mocks.NewCompany(t)
+			id, _ := strconv.Atoi(testCase.inputID)
+			testCase.mockBehavior(companyMock, id)
+
+			services := &service.Service{Company: companyMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.GET("/api/v1/companies/:id", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.GetCompany)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/v1/companies/"+testCase.inputID, nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
 func TestHandler_DeleteCompany(t *testing.T) {
 	type mockBehavior func(s *mocks.Company, id int)
 
@@ -167,17 +275,35 @@ func TestHandler_DeleteCompany(t *testing.T) {
 			name:    "OK",
 			inputID: "1",
 			mockBehavior: func(s *mocks.Company, id int) {
-				s.On("DeleteCompany", id).Return(nil)
+				s.On("DeleteCompany", 1, id).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"company deleted successfully"}`,
 		},
+		{
+			name:    "Not Found",
+			inputID: "1",
+			mockBehavior: func(s *mocks.Company, id int) {
+				s.On("DeleteCompany", 1, id).Return(model.ErrNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"not found"}}`,
+		},
+		{
+			name:    "Not An Owner Or Admin",
+			inputID: "1",
+			mockBehavior: func(s *mocks.Company, id int) {
+				s.On("DeleteCompany", 1, id).Return(model.ErrForbidden)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"forbidden"}}`,
+		},
 		{
 			name:                "Invalid ID",
 			inputID:             "invalid",
 			mockBehavior:        func(s *mocks.Company, id int) {},
 			expectedStatusCode:  http.StatusBadRequest,
-			expectedRequestBody: `{"error":"invalid id"}`,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid id"}}`,
 		},
 	}
 
@@ -185,6 +311,7 @@ func TestHandler_DeleteCompany(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			companyMock := mocks.NewCompany(t)
 			id, _ := strconv.Atoi(testCase.inputID)
 			testCase.mockBehavior(companyMock, id)
@@ -193,7 +320,9 @@ func TestHandler_DeleteCompany(t *testing.T) {
 			handler := NewHandler(services)
 
 			// Test Server
-			c.DELETE("/api/v1/companies/:id", handler.DeleteCompany)
+			c.DELETE("/api/v1/companies/:id", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.DeleteCompany)
 
 			// Test Request
 			w := httptest.NewRecorder()
@@ -208,3 +337,73 @@ func TestHandler_DeleteCompany(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_RestoreCompany(t *testing.T) {
+	type mockBehavior func(s *mocks.Company, id int)
+
+	testTable := []struct {
+		name                string
+		inputID             string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			inputID: "1",
+			mockBehavior: func(s *mocks.Company, id int) {
+				s.On("RestoreCompany", 1, id).Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"company restored successfully"}`,
+		},
+		{
+			name:    "Window Expired",
+			inputID: "1",
+			mockBehavior: func(s *mocks.Company, id int) {
+				s.On("RestoreCompany", 1, id).Return(model.ErrRestoreWindowExpired)
+			},
+			expectedStatusCode:  http.StatusGone,
+			expectedRequestBody: `{"error":{"code":"gone","message":"restore window has expired"}}`,
+		},
+		{
+			name:    "Not Deleted",
+			inputID: "1",
+			mockBehavior: func(s *mocks.Company, id int) {
+				s.On("RestoreCompany", 1, id).Return(model.ErrNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"not found"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			companyMock := mocks.NewCompany(t)
+			id, _ := strconv.Atoi(testCase.inputID)
+			testCase.mockBehavior(companyMock, id)
+
+			services := &service.Service{Company: companyMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/companies/:id/restore", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.RestoreCompany)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/companies/"+testCase.inputID+"/restore", nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
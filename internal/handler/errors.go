@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+// errorBody is the envelope of every error response:
+//
+//	{"error":{"code":"not_found","message":"team not found"}}
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// httpError is an error whose status and client-facing message were chosen by
+// the handler.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+// newErrorResponse aborts the request with an explicit status and message.
+// The response itself is written by ErrorHandler.
+func newErrorResponse(c *gin.Context, status int, message string) {
+	_ = c.Error(&httpError{status: status, message: message})
+	c.Abort()
+}
+
+// errorStatuses maps model errors to HTTP statuses. Repository errors already
+// arrive translated, so a missing row is model.ErrNotFound and a unique
+// violation is model.ErrConflict.
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{model.ErrInvalidInput, http.StatusBadRequest},
+	{model.ErrInvalidEmailToken, http.StatusBadRequest},
+	{model.ErrInvalidInvitation, http.StatusBadRequest},
+	{model.ErrUnauthorized, http.StatusUnauthorized},
+	{model.ErrInvalidPassword, http.StatusUnauthorized},
+	{model.ErrInvalidTwoFactorCode, http.StatusUnauthorized},
+	{model.ErrInvalidAPIKey, http.StatusUnauthorized},
+	{model.ErrSSOLoginExpired, http.StatusUnauthorized},
+	{model.ErrSSOUserNotAllowed, http.StatusUnauthorized},
+	{model.ErrForbidden, http.StatusForbidden},
+	{model.ErrTwoFactorPolicy, http.StatusForbidden},
+	{model.ErrNotTeamMember, http.StatusForbidden},
+	{model.ErrNotFound, http.StatusNotFound},
+	{model.ErrSSONotConfigured, http.StatusNotFound},
+	{model.ErrConflict, http.StatusConflict},
+	{model.ErrEmailTaken, http.StatusConflict},
+	{model.ErrOwnsCompanies, http.StatusConflict},
+	{model.ErrSurveyClosed, http.StatusConflict},
+	{model.ErrTwoFactorNotEnrolled, http.StatusConflict},
+	{model.ErrTwoFactorAlreadyActive, http.StatusConflict},
+	{model.ErrRestoreWindowExpired, http.StatusGone},
+	{model.ErrPreconditionFailed, http.StatusPreconditionFailed},
+	{model.ErrUnprocessable, http.StatusUnprocessableEntity},
+	{model.ErrTwoFactorLocked, http.StatusTooManyRequests},
+	{model.ErrSSODomainNotVerified, http.StatusUnprocessableEntity},
+	{model.ErrTimeout, http.StatusGatewayTimeout},
+}
+
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "invalid_input",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusConflict:              "conflict",
+	http.StatusGone:                  "gone",
+	http.StatusPreconditionFailed:    "precondition_failed",
+	http.StatusRequestEntityTooLarge: "too_large",
+	http.StatusUnprocessableEntity:   "unprocessable",
+	http.StatusTooManyRequests:       "too_many_requests",
+	http.StatusInternalServerError:   "internal",
+	http.StatusGatewayTimeout:        "timeout",
+}
+
+// errorStatus returns the status and message reported for err. Unknown errors
+// are internal and their text is never sent to the client.
+func errorStatus(err error) (int, string) {
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		return httpErr.status, httpErr.message
+	}
+
+	for _, mapping := range errorStatuses {
+		if errors.Is(err, mapping.err) {
+			return mapping.status, err.Error()
+		}
+	}
+
+	return http.StatusInternalServerError, "internal server error"
+}
+
+// ErrorHandler must be the first middleware of the router. Handlers report
+// failures with c.Error or newErrorResponse and ErrorHandler renders the last
+// one as an errorBody, logging internal errors.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		// A client that went away cancelled the request context; there is
+		// nobody to answer.
+		if errors.Is(c.Request.Context().Err(), context.Canceled) {
+			return
+		}
+
+		status, message := errorStatus(last.Err)
+		if status == http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, last.Err)
+		}
+
+		code, ok := errorCodes[status]
+		if !ok {
+			code = "error"
+		}
+
+		c.JSON(status, errorBody{Error: errorDetail{Code: code, Message: message}})
+	}
+}
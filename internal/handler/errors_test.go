@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+)
+
+func TestErrorHandler(t *testing.T) {
+	testTable := []struct {
+		name                string
+		err                 error
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:                "Explicit",
+			err:                 &httpError{status: http.StatusBadRequest, message: "invalid id"},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid id"}}`,
+		},
+		{
+			name:                "Not Found",
+			err:                 fmt.Errorf("team 7: %w", model.ErrNotFound),
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"team 7: not found"}}`,
+		},
+		{
+			name:                "Conflict",
+			err:                 model.ErrConflict,
+			expectedStatusCode:  http.StatusConflict,
+			expectedRequestBody: `{"error":{"code":"conflict","message":"resource already exists"}}`,
+		},
+		{
+			name:                "Unprocessable",
+			err:                 model.ErrUnprocessable,
+			expectedStatusCode:  http.StatusUnprocessableEntity,
+			expectedRequestBody: `{"error":{"code":"unprocessable","message":"request violates a data constraint"}}`,
+		},
+		{
+			name:                "Timeout",
+			err:                 model.ErrTimeout,
+			expectedStatusCode:  http.StatusGatewayTimeout,
+			expectedRequestBody: `{"error":{"code":"timeout","message":"request timed out"}}`,
+		},
+		{
+			name:                "Internal",
+			err:                 errors.New(`pq: relation "teams" does not exist`),
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"error":{"code":"internal","message":"internal server error"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gin.New()
+			c.Use(ErrorHandler())
+			c.GET("/fail", func(c *gin.Context) {
+				c.Error(testCase.err)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/fail", nil)
+
+			c.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
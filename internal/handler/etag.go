@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag derives a resource version from its updated_at timestamp. Postgres
+// stores microseconds, so the value round-trips exactly.
+func etag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// ifMatchVersion returns the version a client expects to modify, taken from
+// the If-Match header. A missing header or "*" means an unconditional update.
+func ifMatchVersion(c *gin.Context) (*time.Time, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	value := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	micros, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	version := time.UnixMicro(micros)
+	return &version, true
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -21,7 +22,7 @@ func (h *Handler) Register(c *gin.Context) {
 	var input model.SignUpInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -29,12 +30,12 @@ func (h *Handler) Register(c *gin.Context) {
 		Email:    input.Email,
 		Password: input.Password,
 		Name:     input.Name,
-		Role:     input.Role,
+		Role:     string(model.UserRoleTeam),
 	}
 
-	id, err := h.services.Authorization.CreateUser(user)
+	id, err := h.services.Authorization.CreateUser(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -45,29 +46,59 @@ func (h *Handler) Login(c *gin.Context) {
 	var input model.SignInInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	token, err := h.services.Authorization.GenerateToken(input.Email, input.Password)
+	result, err := h.services.Authorization.GenerateToken(c.Request.Context(), input.Email, input.Password)
+	if errors.Is(err, model.ErrUnauthorized) {
+		newErrorResponse(c, http.StatusUnauthorized, "invalid email or password")
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, result)
 }
 
 func (h *Handler) DeleteUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
 		return
 	}
 
-	err = h.services.Authorization.DeleteUser(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	transferTo := 0
+	if value := c.Query("transfer_to"); value != "" {
+		transferTo, err = strconv.Atoi(value)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid transfer_to")
+			return
+		}
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	requester := model.Requester{UserID: userID.(int), Role: c.GetString("userRole")}
+
+	err = h.services.Authorization.DeleteUser(c.Request.Context(), requester, id, transferTo)
+	switch {
+	case errors.Is(err, model.ErrForbidden):
+		newErrorResponse(c, http.StatusForbidden, "only the user or an admin can delete this account")
+		return
+	case errors.Is(err, model.ErrNotFound):
+		newErrorResponse(c, http.StatusNotFound, "user not found")
+		return
+	case errors.Is(err, model.ErrInvalidInput):
+		newErrorResponse(c, http.StatusBadRequest, "invalid transfer_to")
+		return
+	case err != nil:
+		c.Error(err)
 		return
 	}
 
@@ -78,13 +109,13 @@ func (h *Handler) CreateCompany(c *gin.Context) {
 	var input model.CreateCompanyInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
@@ -94,9 +125,21 @@ func (h *Handler) CreateCompany(c *gin.Context) {
 		CreatedBy:   userID.(int),
 	}
 
-	id, err := h.services.Company.CreateCompany(company)
+	if input.FirstTeam != nil {
+		team := model.Team{Name: input.FirstTeam.Name, Description: input.FirstTeam.Description}
+		id, teamID, err := h.services.Company.CreateCompanyWithTeam(c.Request.Context(), company, team)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"id": id, "team_id": teamID})
+		return
+	}
+
+	id, err := h.services.Company.CreateCompany(c.Request.Context(), company)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -107,7 +150,7 @@ func (h *Handler) CreateTeam(c *gin.Context) {
 	var input model.CreateTeamInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -116,12 +159,17 @@ func (h *Handler) CreateTeam(c *gin.Context) {
 		Name:        input.Name,
 		Description: input.Description,
 		CompanyID:   input.CompanyID,
+		ParentID:    input.ParentID,
 		CreatedBy:   userID.(int),
 	}
 
-	id, err := h.services.Team.CreateTeam(team)
+	id, err := h.services.Team.CreateTeam(c.Request.Context(), team)
+	if errors.Is(err, model.ErrInvalidInput) {
+		newErrorResponse(c, http.StatusBadRequest, "invalid company or parent team")
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -131,13 +179,19 @@ func (h *Handler) CreateTeam(c *gin.Context) {
 func (h *Handler) GetCompanies(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
-	companies, err := h.services.Company.GetCompaniesByUserID(userID.(int))
+	query, err := parseListQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	companies, err := h.services.Company.GetCompaniesByUserID(c.Request.Context(), userID.(int), query)
+	if err != nil {
+		listErrorResponse(c, err)
 		return
 	}
 
@@ -147,13 +201,25 @@ func (h *Handler) GetCompanies(c *gin.Context) {
 func (h *Handler) GetTeams(c *gin.Context) {
 	companyID, err := strconv.Atoi(c.Param("company_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid company id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid company id")
+		return
+	}
+
+	query, err := parseListQuery(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
-	teams, err := h.services.Team.GetTeamsByCompanyID(companyID)
+	teams, err := h.services.Team.GetTeamsByCompanyID(c.Request.Context(), userID.(int), companyID, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		listErrorResponse(c, err)
 		return
 	}
 
@@ -163,63 +229,141 @@ func (h *Handler) GetTeams(c *gin.Context) {
 func (h *Handler) GetCompany(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
-	company, err := h.services.Company.GetCompanyByID(id)
+	company, err := h.services.Company.GetCompanyByID(c.Request.Context(), userID.(int), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", etag(company.UpdatedAt))
 	c.JSON(http.StatusOK, company)
 }
 
-func (h *Handler) DeleteCompany(c *gin.Context) {
+// UpdateCompany serves PATCH (partial update) and PUT (full replacement).
+// An If-Match header makes the update conditional on the company's ETag.
+func (h *Handler) UpdateCompany(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var input model.UpdateCompanyInput
+	if c.Request.Method == http.MethodPut {
+		var replacement model.ReplaceCompanyInput
+		if err := c.ShouldBindJSON(&replacement); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		input = model.UpdateCompanyInput{Name: &replacement.Name, Description: &replacement.Description}
+	} else if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		newErrorResponse(c, http.StatusBadRequest, "invalid If-Match header")
 		return
 	}
 
-	err = h.services.Company.DeleteCompany(id)
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	company, err := h.services.Company.UpdateCompany(c.Request.Context(), userID.(int), id, input, version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "company deleted successfully"})
+	c.Header("ETag", etag(company.UpdatedAt))
+	c.JSON(http.StatusOK, company)
+}
+
+func (h *Handler) DeleteCompany(c *gin.Context) {
+	lifecycleAction(c, "id", "invalid id", h.services.Company.DeleteCompany, "company deleted successfully")
 }
 
 func (h *Handler) GetTeam(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
 		return
 	}
 
-	team, err := h.services.Team.GetTeamByID(id)
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	team, err := h.services.Team.GetTeamByID(c.Request.Context(), userID.(int), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", etag(team.UpdatedAt))
 	c.JSON(http.StatusOK, team)
 }
 
-func (h *Handler) DeleteTeam(c *gin.Context) {
+// UpdateTeam serves PATCH (partial update) and PUT (full replacement).
+// An If-Match header makes the update conditional on the team's ETag.
+func (h *Handler) UpdateTeam(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var input model.UpdateTeamInput
+	if c.Request.Method == http.MethodPut {
+		var replacement model.ReplaceTeamInput
+		if err := c.ShouldBindJSON(&replacement); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		input = model.UpdateTeamInput{Name: &replacement.Name, Description: &replacement.Description}
+	} else if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		newErrorResponse(c, http.StatusBadRequest, "invalid If-Match header")
 		return
 	}
 
-	err = h.services.Team.DeleteTeam(id)
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	team, err := h.services.Team.UpdateTeam(c.Request.Context(), userID.(int), id, input, version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "team deleted successfully"})
+	c.Header("ETag", etag(team.UpdatedAt))
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *Handler) DeleteTeam(c *gin.Context) {
+	lifecycleAction(c, "id", "invalid id", h.services.Team.DeleteTeam, "team deleted successfully")
 }
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds all readiness checks of one probe together.
+const readinessTimeout = 5 * time.Second
+
+// Check is a readiness check; a non-nil error means the instance should not
+// receive traffic.
+type Check func(ctx context.Context) error
+
+// Healthz is the liveness probe: it answers as long as the process serves
+// HTTP and checks no dependencies, so a database outage does not get the
+// instance restarted.
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz returns the readiness probe, which runs every check and answers 503
+// naming the failed ones when any check fails:
+//
+//	{"status":"unavailable","checks":{"database":"ok","migrations":"unavailable"}}
+//
+// The probe is unauthenticated, so the reasons are only logged.
+func Readyz(checks map[string]Check) gin.HandlerFunc {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		status, code := "ok", http.StatusOK
+		results := make(map[string]string, len(names))
+		for _, name := range names {
+			results[name] = "ok"
+			if err := checks[name](ctx); err != nil {
+				log.Printf("readiness check %s: %v", name, err)
+				results[name] = "unavailable"
+				status, code = "unavailable", http.StatusServiceUnavailable
+			}
+		}
+
+		c.JSON(code, gin.H{"status": status, "checks": results})
+	}
+}
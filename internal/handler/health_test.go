@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthz(t *testing.T) {
+	r := gin.New()
+	r.GET("/healthz", Healthz)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/healthz", nil)
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+
+	testTable := []struct {
+		name                string
+		checks              map[string]Check
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:                "Ready",
+			checks:              map[string]Check{"database": ok, "migrations": ok},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"checks":{"database":"ok","migrations":"ok"},"status":"ok"}`,
+		},
+		{
+			name: "Pending Migrations",
+			checks: map[string]Check{
+				"database":   ok,
+				"migrations": func(ctx context.Context) error { return errors.New("2 pending migrations") },
+			},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedRequestBody: `{"checks":{"database":"ok","migrations":"unavailable"},"status":"unavailable"}`,
+		},
+		{
+			name: "Database Down",
+			checks: map[string]Check{
+				"database": func(ctx context.Context) error { return errors.New("dial tcp: connection refused") },
+			},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedRequestBody: `{"checks":{"database":"unavailable"},"status":"unavailable"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/readyz", Readyz(testCase.checks))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/readyz", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
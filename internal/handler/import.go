@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+// maxImportSize bounds the uploaded import file.
+const maxImportSize = 5 << 20
+
+// ImportCompany accepts a multipart "file" field with a CSV or XLSX sheet of
+// employees (email, name, team, parent_team, role). With dry_run=true the
+// import is validated and rolled back. A report with row errors is returned
+// with 422 and nothing is written.
+func (h *Handler) ImportCompany(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "dry_run must be a boolean")
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	header, err := c.FormFile("file")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "file is required and must not exceed 5 MB")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.services.Import.ImportCompanyStructure(c.Request.Context(), userID.(int), companyID, header.Filename, data, dryRun)
+	switch {
+	case errors.Is(err, model.ErrForbidden):
+		newErrorResponse(c, http.StatusForbidden, "only company owners and admins can import")
+		return
+	case errors.Is(err, model.ErrInvalidInput):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		c.Error(err)
+		return
+	}
+
+	if len(report.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestHandler_ImportCompany(t *testing.T) {
+	type mockBehavior func(s *mocks.Import, data []byte)
+
+	csv := []byte("email,name,team\nann@example.com,Ann,Backend\n")
+
+	testTable := []struct {
+		name                string
+		query               string
+		withFile            bool
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:     "Dry Run",
+			query:    "?dry_run=true",
+			withFile: true,
+			mockBehavior: func(s *mocks.Import, data []byte) {
+				s.On("ImportCompanyStructure", 1, 5, "people.csv", data, true).Return(model.ImportReport{
+					DryRun: true, Rows: 2, TeamsCreated: 1, InvitationsCreated: 1, TeamMembersAdded: 1,
+					Invited: []string{"new@example.com"}, Errors: []model.ImportError{},
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"dry_run":true,"applied":false,"rows":2,"teams_created":1,"invitations_created":1,"team_members_added":1,"invited":["new@example.com"],"errors":[]}`,
+		},
+		{
+			name:     "Row Errors",
+			withFile: true,
+			mockBehavior: func(s *mocks.Import, data []byte) {
+				s.On("ImportCompanyStructure", 1, 5, "people.csv", data, false).Return(model.ImportReport{
+					Rows:    1,
+					Invited: []string{},
+					Errors:  []model.ImportError{{Line: 2, Field: "email", Message: "invalid email"}},
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusUnprocessableEntity,
+			expectedRequestBody: `{"dry_run":false,"applied":false,"rows":1,"teams_created":0,"invitations_created":0,"team_members_added":0,"invited":[],"errors":[{"line":2,"field":"email","message":"invalid email"}]}`,
+		},
+		{
+			name:     "Forbidden",
+			withFile: true,
+			mockBehavior: func(s *mocks.Import, data []byte) {
+				s.On("ImportCompanyStructure", 1, 5, "people.csv", data, false).Return(model.ImportReport{}, model.ErrForbidden)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"only company owners and admins can import"}}`,
+		},
+		{
+			name:                "Missing File",
+			mockBehavior:        func(s *mocks.Import, data []byte) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"file is required and must not exceed 5 MB"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			importMock := mocks.NewImport(t)
+			testCase.mockBehavior(importMock, csv)
+
+			services := &service.Service{Import: importMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/companies/:id/import", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.ImportCompany(c)
+			})
+
+			// Test Request
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			if testCase.withFile {
+				part, err := form.CreateFormFile("file", "people.csv")
+				require.NoError(t, err)
+				_, err = part.Write(csv)
+				require.NoError(t, err)
+			}
+			require.NoError(t, form.Close())
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/companies/5/import"+testCase.query, &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
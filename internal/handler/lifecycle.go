@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+// lifecycleAction runs a delete, restore or archive operation by the current
+// user on the resource identified by param and answers with message on
+// success. invalidID is the error reported when param is not a number.
+func lifecycleAction(c *gin.Context, param, invalidID string, action func(ctx context.Context, userID, id int) error, message string) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, invalidID)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	err = action(c.Request.Context(), userID.(int), id)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": message})
+	case errors.Is(err, model.ErrInvalidInput):
+		newErrorResponse(c, http.StatusConflict, "parent resource is deleted")
+	default:
+		c.Error(err)
+	}
+}
+
+func (h *Handler) RestoreCompany(c *gin.Context) {
+	lifecycleAction(c, "id", "invalid id", h.services.Company.RestoreCompany, "company restored successfully")
+}
+
+func (h *Handler) ArchiveCompany(c *gin.Context) {
+	lifecycleAction(c, "id", "invalid id", h.services.Company.ArchiveCompany, "company archived successfully")
+}
+
+func (h *Handler) UnarchiveCompany(c *gin.Context) {
+	lifecycleAction(c, "id", "invalid id", h.services.Company.UnarchiveCompany, "company unarchived successfully")
+}
+
+func (h *Handler) RestoreTeam(c *gin.Context) {
+	lifecycleAction(c, "id", "invalid id", h.services.Team.RestoreTeam, "team restored successfully")
+}
+
+func (h *Handler) ArchiveTeam(c *gin.Context) {
+	lifecycleAction(c, "id", "invalid id", h.services.Team.ArchiveTeam, "team archived successfully")
+}
+
+func (h *Handler) UnarchiveTeam(c *gin.Context) {
+	lifecycleAction(c, "id", "invalid id", h.services.Team.UnarchiveTeam, "team unarchived successfully")
+}
+
+func (h *Handler) RestoreSurvey(c *gin.Context) {
+	lifecycleAction(c, "survey_id", "invalid survey id", h.services.Survey.RestoreSurvey, "survey restored successfully")
+}
+
+func (h *Handler) ArchiveSurvey(c *gin.Context) {
+	lifecycleAction(c, "survey_id", "invalid survey id", h.services.Survey.ArchiveSurvey, "survey archived successfully")
+}
+
+func (h *Handler) UnarchiveSurvey(c *gin.Context) {
+	lifecycleAction(c, "survey_id", "invalid survey id", h.services.Survey.UnarchiveSurvey, "survey unarchived successfully")
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+// parseListQuery reads the query parameters shared by list endpoints:
+//
+//	limit=20&cursor=...&sort=-created_at&status=active
+//	&created_after=2024-01-01T00:00:00Z&created_before=...&include_archived=true
+//
+// A leading "-" in sort means descending order.
+func parseListQuery(c *gin.Context) (model.ListQuery, error) {
+	query := model.ListQuery{
+		Cursor: c.Query("cursor"),
+		Status: c.Query("status"),
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > model.MaxListLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", model.MaxListLimit)
+		}
+		query.Limit = limit
+	}
+
+	sort := c.Query("sort")
+	query.Desc = strings.HasPrefix(sort, "-")
+	query.Sort = strings.TrimPrefix(sort, "-")
+
+	for param, target := range map[string]**time.Time{
+		"created_after":  &query.CreatedAfter,
+		"created_before": &query.CreatedBefore,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+		}
+		*target = &t
+	}
+
+	if value := c.Query("include_archived"); value != "" {
+		include, err := strconv.ParseBool(value)
+		if err != nil {
+			return query, errors.New("include_archived must be a boolean")
+		}
+		query.IncludeArchived = include
+	}
+
+	return query, nil
+}
+
+func listErrorResponse(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrInvalidInput) {
+		newErrorResponse(c, http.StatusBadRequest, "invalid sort, status or cursor")
+		return
+	}
+	c.Error(err)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/metrics"
+	"github.com/teamdetected/internal/model"
+)
+
+func TestMetrics(t *testing.T) {
+	testTable := []struct {
+		name           string
+		path           string
+		expectedRoute  string
+		expectedStatus string
+	}{
+		{
+			name:           "OK",
+			path:           "/teams/7",
+			expectedRoute:  "/teams/:id",
+			expectedStatus: "200",
+		},
+		{
+			name:           "Error",
+			path:           "/teams/0",
+			expectedRoute:  "/teams/:id",
+			expectedStatus: "404",
+		},
+		{
+			name:           "Unmatched",
+			path:           "/nope",
+			expectedRoute:  "unmatched",
+			expectedStatus: "404",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Metrics(), ErrorHandler())
+			r.GET("/teams/:id", func(c *gin.Context) {
+				if c.Param("id") == "0" {
+					c.Error(model.ErrNotFound)
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			counter := metrics.HTTPRequests.WithLabelValues("GET", testCase.expectedRoute, testCase.expectedStatus)
+			before := testutil.ToFloat64(counter)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
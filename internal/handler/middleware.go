@@ -5,60 +5,121 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-)
-
-const (
-	signingKey = "your-secret-key" // Должен совпадать с ключом в auth_service.go
+	"github.com/teamdetected/internal/model"
 )
 
 func (h *Handler) UserIdentity(c *gin.Context) {
+	h.identify(c, false)
+}
+
+// TwoFactorSetupIdentity is UserIdentity for the enrollment endpoints: it also
+// accepts tokens issued to users who still have to set up two-factor
+// authentication required by their role policy.
+func (h *Handler) TwoFactorSetupIdentity(c *gin.Context) {
+	h.identify(c, true)
+}
+
+func (h *Handler) identify(c *gin.Context, allowTwoFactorSetup bool) {
 	header := c.GetHeader("Authorization")
 	if header == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "empty auth header"})
-		c.Abort()
+		newErrorResponse(c, http.StatusUnauthorized, "empty auth header")
 		return
 	}
 
 	headerParts := strings.Split(header, " ")
 	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid auth header"})
-		c.Abort()
+		newErrorResponse(c, http.StatusUnauthorized, "invalid auth header")
 		return
 	}
 
 	if len(headerParts[1]) == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "token is empty"})
-		c.Abort()
+		newErrorResponse(c, http.StatusUnauthorized, "token is empty")
 		return
 	}
 
-	token, err := jwt.Parse(headerParts[1], func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(signingKey), nil
-	})
+	if strings.HasPrefix(headerParts[1], model.APIKeyPrefix) {
+		h.identifyAPIKey(c, headerParts[1])
+		return
+	}
 
+	claims, err := h.services.Authorization.AuthenticateToken(c.Request.Context(), headerParts[1])
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		c.Abort()
 		return
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-		c.Abort()
+	if claims.TwoFactorSetupRequired && !allowTwoFactorSetup {
+		newErrorResponse(c, http.StatusForbidden, "two-factor authentication setup required")
+		return
+	}
+
+	c.Set("userID", claims.UserID)
+	c.Set("userRole", claims.Role)
+}
+
+// APIKeyScope marks a route group as usable with API keys. resource is the
+// scope resource ("companies", "teams", "surveys"); safe methods need its read
+// scope and everything else its write scope. Routes without it reject API keys.
+func (h *Handler) APIKeyScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("apiKeyResource", resource)
+	}
+}
+
+// NoAPIKeys takes routes out of their group's API key scope. It guards
+// endpoints that change who can act for a company, such as members, SSO and
+// webhooks, which only a signed-in user may call.
+func (h *Handler) NoAPIKeys(c *gin.Context) {
+	c.Set("apiKeyResource", "")
+}
+
+func (h *Handler) identifyAPIKey(c *gin.Context, rawKey string) {
+	resource := c.GetString("apiKeyResource")
+	if resource == "" {
+		newErrorResponse(c, http.StatusForbidden, "api keys are not allowed for this endpoint")
 		return
 	}
 
-	userID, ok := claims["user_id"].(float64)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+	principal, err := h.services.APIKey.AuthenticateAPIKey(c.Request.Context(), rawKey)
+	if err != nil {
+		c.Error(err)
 		c.Abort()
 		return
 	}
 
-	c.Set("userID", int(userID))
+	readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+	if !hasScope(principal.Scopes, resource, readOnly) {
+		newErrorResponse(c, http.StatusForbidden, "api key scope does not allow this request")
+		return
+	}
+
+	c.Set("userID", principal.UserID)
+	c.Set("userRole", principal.UserRole)
+	c.Set("apiKeyID", principal.KeyID)
+}
+
+func hasScope(scopes []string, resource string, readOnly bool) bool {
+	for _, scope := range scopes {
+		if scope == resource+":write" || (readOnly && scope == resource+":read") {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole must run after UserIdentity and rejects users whose role is not
+// one of roles. The role is the one stored for the user, loaded on every
+// request, so it cannot be forged by the client or outlive a demotion.
+func (h *Handler) RequireRole(roles ...model.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("userRole")
+		for _, allowed := range roles {
+			if role == string(allowed) {
+				return
+			}
+		}
+
+		newErrorResponse(c, http.StatusForbidden, "insufficient permissions")
+	}
 }
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+func (h *Handler) CreateSurveySchedule(c *gin.Context) {
+	var input model.CreateSurveyScheduleInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	schedule, err := h.services.Schedule.CreateSurveySchedule(c.Request.Context(), userID.(int), teamID, input)
+	if err != nil {
+		scheduleErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *Handler) GetSurveySchedules(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	schedules, err := h.services.Schedule.GetSurveySchedules(c.Request.Context(), userID.(int), teamID)
+	if err != nil {
+		scheduleErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (h *Handler) UpdateSurveySchedule(c *gin.Context) {
+	var input model.UpdateSurveyScheduleInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	scheduleID, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid schedule id")
+		return
+	}
+
+	schedule, err := h.services.Schedule.UpdateSurveySchedule(c.Request.Context(), userID.(int), scheduleID, input)
+	if err != nil {
+		scheduleErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *Handler) DeleteSurveySchedule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	scheduleID, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid schedule id")
+		return
+	}
+
+	if err := h.services.Schedule.DeleteSurveySchedule(c.Request.Context(), userID.(int), scheduleID); err != nil {
+		scheduleErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "schedule deleted successfully"})
+}
+
+func scheduleErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrForbidden):
+		newErrorResponse(c, http.StatusForbidden, "only company owners, admins and managers can manage schedules")
+	case errors.Is(err, model.ErrInvalidInput):
+		newErrorResponse(c, http.StatusBadRequest, "invalid rule, timezone, title template or duration")
+	default:
+		c.Error(err)
+	}
+}
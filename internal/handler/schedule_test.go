@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestHandler_CreateSurveySchedule(t *testing.T) {
+	type mockBehavior func(s *mocks.Schedule, input model.CreateSurveyScheduleInput)
+
+	nextRun := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		input               model.CreateSurveyScheduleInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"rule": "0 9 * * 1", "duration_hours": 72}`,
+			input:     model.CreateSurveyScheduleInput{Rule: "0 9 * * 1", DurationHours: 72},
+			mockBehavior: func(s *mocks.Schedule, input model.CreateSurveyScheduleInput) {
+				s.On("CreateSurveySchedule", 1, 3, input).Return(model.SurveySchedule{
+					ID: 7, TeamID: 3, Rule: "0 9 * * 1", Timezone: "UTC", TitleTemplate: model.DefaultSurveyTitleTemplate,
+					DurationHours: 72, Enabled: true, NextRunAt: nextRun, CreatedBy: 1, CreatedAt: nextRun, UpdatedAt: nextRun,
+				}, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedRequestBody: `{"id":7,"team_id":3,"rule":"0 9 * * 1","timezone":"UTC","title_template":"Pulse survey {date}",` +
+				`"duration_hours":72,"enabled":true,"next_run_at":"2024-05-06T09:00:00Z","created_by":1,` +
+				`"created_at":"2024-05-06T09:00:00Z","updated_at":"2024-05-06T09:00:00Z"}`,
+		},
+		{
+			name:      "Invalid Rule",
+			inputBody: `{"rule": "every monday", "duration_hours": 72}`,
+			input:     model.CreateSurveyScheduleInput{Rule: "every monday", DurationHours: 72},
+			mockBehavior: func(s *mocks.Schedule, input model.CreateSurveyScheduleInput) {
+				s.On("CreateSurveySchedule", 1, 3, input).Return(model.SurveySchedule{}, model.ErrInvalidInput)
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid rule, timezone, title template or duration"}}`,
+		},
+		{
+			name:      "Viewer",
+			inputBody: `{"rule": "@monthly", "duration_hours": 72}`,
+			input:     model.CreateSurveyScheduleInput{Rule: "@monthly", DurationHours: 72},
+			mockBehavior: func(s *mocks.Schedule, input model.CreateSurveyScheduleInput) {
+				s.On("CreateSurveySchedule", 1, 3, input).Return(model.SurveySchedule{}, model.ErrForbidden)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"only company owners, admins and managers can manage schedules"}}`,
+		},
+		{
+			name:      "Team Not Found",
+			inputBody: `{"rule": "@monthly", "duration_hours": 72}`,
+			input:     model.CreateSurveyScheduleInput{Rule: "@monthly", DurationHours: 72},
+			mockBehavior: func(s *mocks.Schedule, input model.CreateSurveyScheduleInput) {
+				s.On("CreateSurveySchedule", 1, 3, input).Return(model.SurveySchedule{}, model.ErrNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"not found"}}`,
+		},
+		{
+			name:                "Missing Duration",
+			inputBody:           `{"rule": "@monthly"}`,
+			mockBehavior:        func(s *mocks.Schedule, input model.CreateSurveyScheduleInput) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'CreateSurveyScheduleInput.DurationHours' Error:Field validation for 'DurationHours' failed on the 'required' tag"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			scheduleMock := mocks.NewSchedule(t)
+			testCase.mockBehavior(scheduleMock, testCase.input)
+
+			services := &service.Service{Schedule: scheduleMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/teams/team/:id/schedules", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.CreateSurveySchedule(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/teams/team/3/schedules", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+func (h *Handler) SSOLogin(c *gin.Context) {
+	companyID, err := strconv.Atoi(c.Param("company_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid company id")
+		return
+	}
+
+	authURL, err := h.services.SSO.BeginSSOLogin(c.Request.Context(), companyID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// SSOLink returns the provider URL for linking the signed-in user's account to
+// the company's identity provider; the flow ends at SSOCallback.
+func (h *Handler) SSOLink(c *gin.Context) {
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	authURL, err := h.services.SSO.BeginSSOLink(c.Request.Context(), userID.(int), companyID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": authURL})
+}
+
+func (h *Handler) SSOCallback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		message := providerError
+		if description := c.Query("error_description"); description != "" {
+			message += ": " + description
+		}
+		newErrorResponse(c, http.StatusUnauthorized, message)
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		newErrorResponse(c, http.StatusBadRequest, "missing state or code")
+		return
+	}
+
+	result, err := h.services.SSO.CompleteSSOLogin(c.Request.Context(), state, code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) GetSSOConfig(c *gin.Context) {
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	config, err := h.services.SSO.GetSSOConfig(c.Request.Context(), userID.(int), companyID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+func (h *Handler) SaveSSOConfig(c *gin.Context) {
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var input model.SSOConfigInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	config := model.SSOConfig{
+		CompanyID:     companyID,
+		Issuer:        input.Issuer,
+		ClientID:      input.ClientID,
+		ClientSecret:  input.ClientSecret,
+		RedirectURL:   input.RedirectURL,
+		EmailDomain:   input.EmailDomain,
+		AutoProvision: input.AutoProvision,
+		DefaultRole:   input.DefaultRole,
+		Enabled:       input.Enabled == nil || *input.Enabled,
+	}
+
+	if err := h.services.SSO.SaveSSOConfig(c.Request.Context(), userID.(int), config); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sso configuration saved"})
+}
+
+// VerifySSODomain checks the DNS TXT record proving the company owns its SSO
+// email domain; auto-provisioning stays off until it succeeds.
+func (h *Handler) VerifySSODomain(c *gin.Context) {
+	companyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	config, err := h.services.SSO.VerifySSODomain(c.Request.Context(), userID.(int), companyID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestHandler_SSOLogin(t *testing.T) {
+	type mockBehavior func(s *mocks.SSO)
+
+	testTable := []struct {
+		name               string
+		companyID          string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedLocation   string
+	}{
+		{
+			name:      "OK",
+			companyID: "1",
+			mockBehavior: func(s *mocks.SSO) {
+				s.On("BeginSSOLogin", 1).Return("https://idp.example.com/authorize?state=abc", nil)
+			},
+			expectedStatusCode: http.StatusFound,
+			expectedLocation:   "https://idp.example.com/authorize?state=abc",
+		},
+		{
+			name:      "Not Configured",
+			companyID: "2",
+			mockBehavior: func(s *mocks.SSO) {
+				s.On("BeginSSOLogin", 2).Return("", model.ErrSSONotConfigured)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Invalid ID",
+			companyID:          "invalid",
+			mockBehavior:       func(s *mocks.SSO) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			ssoMock := mocks.NewSSO(t)
+			testCase.mockBehavior(ssoMock)
+
+			services := &service.Service{SSO: ssoMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.GET("/api/v1/auth/oidc/:company_id/login", handler.SSOLogin)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/v1/auth/oidc/"+testCase.companyID+"/login", nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedLocation, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHandler_SSOLink(t *testing.T) {
+	type mockBehavior func(s *mocks.SSO)
+
+	testTable := []struct {
+		name                string
+		companyID           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			companyID: "1",
+			mockBehavior: func(s *mocks.SSO) {
+				s.On("BeginSSOLink", 1, 1).Return("https://idp.example.com/authorize?state=abc", nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"url":"https://idp.example.com/authorize?state=abc"}`,
+		},
+		{
+			name:      "Not A Member",
+			companyID: "2",
+			mockBehavior: func(s *mocks.SSO) {
+				s.On("BeginSSOLink", 1, 2).Return("", model.ErrForbidden)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"forbidden"}}`,
+		},
+		{
+			name:                "Invalid ID",
+			companyID:           "invalid",
+			mockBehavior:        func(s *mocks.SSO) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid id"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			ssoMock := mocks.NewSSO(t)
+			testCase.mockBehavior(ssoMock)
+
+			services := &service.Service{SSO: ssoMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/companies/:id/sso/link", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.SSOLink)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/companies/"+testCase.companyID+"/sso/link", nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_VerifySSODomain(t *testing.T) {
+	type mockBehavior func(s *mocks.SSO)
+
+	testTable := []struct {
+		name                string
+		companyID           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			companyID: "1",
+			mockBehavior: func(s *mocks.SSO) {
+				s.On("VerifySSODomain", 1, 1).Return(model.SSOConfig{CompanyID: 1, EmailDomain: "example.com"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"company_id":1,"issuer":"","client_id":"","redirect_url":"","email_domain":"example.com",` +
+				`"auto_provision":false,"default_role":"","enabled":false,"domain_verified_at":null,` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:      "Record Not Found",
+			companyID: "1",
+			mockBehavior: func(s *mocks.SSO) {
+				s.On("VerifySSODomain", 1, 1).Return(model.SSOConfig{}, model.ErrSSODomainNotVerified)
+			},
+			expectedStatusCode:  http.StatusUnprocessableEntity,
+			expectedRequestBody: `{"error":{"code":"unprocessable","message":"email domain verification record not found"}}`,
+		},
+		{
+			name:                "Invalid ID",
+			companyID:           "invalid",
+			mockBehavior:        func(s *mocks.SSO) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid id"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			ssoMock := mocks.NewSSO(t)
+			testCase.mockBehavior(ssoMock)
+
+			services := &service.Service{SSO: ssoMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/companies/:id/sso/verify-domain", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.VerifySSODomain)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/companies/"+testCase.companyID+"/sso/verify-domain", nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_SSOCallback(t *testing.T) {
+	type mockBehavior func(s *mocks.SSO)
+
+	testTable := []struct {
+		name                string
+		query               string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:  "OK",
+			query: "?state=abc&code=xyz",
+			mockBehavior: func(s *mocks.SSO) {
+				s.On("CompleteSSOLogin", "abc", "xyz").Return(model.SignInResult{Token: "test-token"}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"token":"test-token"}`,
+		},
+		{
+			name:  "Expired State",
+			query: "?state=old&code=xyz",
+			mockBehavior: func(s *mocks.SSO) {
+				s.On("CompleteSSOLogin", "old", "xyz").Return(model.SignInResult{}, model.ErrSSOLoginExpired)
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"error":{"code":"unauthorized","message":"single sign-on login expired or was already used"}}`,
+		},
+		{
+			name:                "Provider Error",
+			query:               "?error=access_denied&error_description=denied",
+			mockBehavior:        func(s *mocks.SSO) {},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"error":{"code":"unauthorized","message":"access_denied: denied"}}`,
+		},
+		{
+			name:                "Missing Code",
+			query:               "?state=abc",
+			mockBehavior:        func(s *mocks.SSO) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"missing state or code"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			ssoMock := mocks.NewSSO(t)
+			testCase.mockBehavior(ssoMock)
+
+			services := &service.Service{SSO: ssoMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.GET("/api/v1/auth/oidc/callback", handler.SSOCallback)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/v1/auth/oidc/callback"+testCase.query, nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
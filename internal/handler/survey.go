@@ -12,24 +12,25 @@ func (h *Handler) CreateSurvey(c *gin.Context) {
 	var input model.CreateSurveyInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	survey := model.Survey{
 		TeamID:    input.TeamID,
+		Title:     input.Title,
 		CreatedBy: userID.(int),
 	}
 
-	id, err := h.services.Survey.CreateSurvey(survey)
+	id, err := h.services.Survey.CreateSurvey(c.Request.Context(), survey)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -39,62 +40,113 @@ func (h *Handler) CreateSurvey(c *gin.Context) {
 func (h *Handler) GetSurvey(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("survey_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid survey id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid survey id")
 		return
 	}
 
-	survey, err := h.services.Survey.GetSurveyByID(id)
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	survey, err := h.services.Survey.GetSurveyByID(c.Request.Context(), userID.(int), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", etag(survey.UpdatedAt))
 	c.JSON(http.StatusOK, survey)
 }
 
-func (h *Handler) GetSurveysByTeam(c *gin.Context) {
-	teamID, err := strconv.Atoi(c.Param("team_id"))
+// UpdateSurvey serves PATCH (partial update) and PUT (full replacement).
+// An If-Match header makes the update conditional on the survey's ETag.
+func (h *Handler) UpdateSurvey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("survey_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid survey id")
+		return
+	}
+
+	var input model.UpdateSurveyInput
+	if c.Request.Method == http.MethodPut {
+		var replacement model.ReplaceSurveyInput
+		if err := c.ShouldBindJSON(&replacement); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		input = model.UpdateSurveyInput{Status: &replacement.Status}
+	} else if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		newErrorResponse(c, http.StatusBadRequest, "invalid If-Match header")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
-	surveys, err := h.services.Survey.GetSurveysByTeamID(teamID)
+	survey, err := h.services.Survey.UpdateSurvey(c.Request.Context(), userID.(int), id, input, version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, surveys)
+	c.Header("ETag", etag(survey.UpdatedAt))
+	c.JSON(http.StatusOK, survey)
 }
 
-func (h *Handler) DeleteSurvey(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("survey_id"))
+func (h *Handler) GetSurveysByTeam(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("team_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid survey id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid team id")
+		return
+	}
+
+	query, err := parseListQuery(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
-	err = h.services.Survey.DeleteSurvey(id)
+	surveys, err := h.services.Survey.GetSurveysByTeamID(c.Request.Context(), userID.(int), teamID, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		listErrorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "survey deleted successfully"})
+	c.JSON(http.StatusOK, surveys)
+}
+
+func (h *Handler) DeleteSurvey(c *gin.Context) {
+	lifecycleAction(c, "survey_id", "invalid survey id", h.services.Survey.DeleteSurvey, "survey deleted successfully")
 }
 
 func (h *Handler) CreateSurveyResponse(c *gin.Context) {
 	var input model.CreateSurveyResponseInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
@@ -105,25 +157,69 @@ func (h *Handler) CreateSurveyResponse(c *gin.Context) {
 		OptionID:   input.OptionID,
 	}
 
-	id, err := h.services.Survey.CreateSurveyResponse(response)
+	id, err := h.services.Survey.CreateSurveyResponse(c.Request.Context(), response)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"id": id})
 }
 
+// SubmitSurveyResponses stores several answers of the user at once. If one
+// answer is rejected, e.g. because it repeats an existing answer, none is
+// stored.
+func (h *Handler) SubmitSurveyResponses(c *gin.Context) {
+	var input model.SubmitSurveyResponsesInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	surveyID, err := strconv.Atoi(c.Param("survey_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid survey id")
+		return
+	}
+
+	ids, err := h.services.Survey.SubmitSurveyResponses(c.Request.Context(), userID.(int), surveyID, input.Answers)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ids": ids})
+}
+
 func (h *Handler) GetSurveyResponses(c *gin.Context) {
 	surveyID, err := strconv.Atoi(c.Param("survey_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid survey id"})
+		newErrorResponse(c, http.StatusBadRequest, "invalid survey id")
+		return
+	}
+
+	query, err := parseListQuery(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
-	responses, err := h.services.Survey.GetSurveyResponses(surveyID)
+	responses, err := h.services.Survey.GetSurveyResponses(c.Request.Context(), userID.(int), surveyID, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		listErrorResponse(c, err)
 		return
 	}
 
@@ -131,9 +227,9 @@ func (h *Handler) GetSurveyResponses(c *gin.Context) {
 }
 
 func (h *Handler) GetSurveyOptions(c *gin.Context) {
-	options, err := h.services.Survey.GetSurveyOptions()
+	options, err := h.services.Survey.GetSurveyOptions(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -141,9 +237,9 @@ func (h *Handler) GetSurveyOptions(c *gin.Context) {
 }
 
 func (h *Handler) GetSurveyQuestions(c *gin.Context) {
-	questions, err := h.services.Survey.GetSurveyQuestions()
+	questions, err := h.services.Survey.GetSurveyQuestions(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+// GetSubteams lists all sub-teams of a team, parents before children. The
+// tree can be rebuilt from parent_id.
+func (h *Handler) GetSubteams(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	teams, err := h.services.Team.GetTeamDescendants(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		hierarchyErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, teams)
+}
+
+func (h *Handler) SetTeamParent(c *gin.Context) {
+	var input model.SetTeamParentInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		newErrorResponse(c, http.StatusBadRequest, "invalid If-Match header")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	team, err := h.services.Team.SetTeamParent(c.Request.Context(), userID.(int), id, input.ParentID, version)
+	if err != nil {
+		hierarchyErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(team.UpdatedAt))
+	c.JSON(http.StatusOK, team)
+}
+
+// GetTeamResults returns averaged survey answers. Results roll up sub-teams
+// unless rollup=false is passed.
+func (h *Handler) GetTeamResults(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	rollUp := true
+	if value := c.Query("rollup"); value != "" {
+		if rollUp, err = strconv.ParseBool(value); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "rollup must be a boolean")
+			return
+		}
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	results, err := h.services.Team.GetTeamResults(c.Request.Context(), userID.(int), id, rollUp)
+	if err != nil {
+		hierarchyErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+func hierarchyErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		newErrorResponse(c, http.StatusNotFound, "team not found")
+	case errors.Is(err, model.ErrInvalidInput):
+		newErrorResponse(c, http.StatusBadRequest, "parent must be another team of the same company outside this subtree")
+	default:
+		c.Error(err)
+	}
+}
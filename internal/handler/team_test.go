@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -53,7 +54,7 @@ func TestHandler_CreateTeam(t *testing.T) {
 			}`,
 			mockBehavior:        func(s *mocks.Team, team model.Team) {},
 			expectedStatusCode:  http.StatusBadRequest,
-			expectedRequestBody: `{"error":"Key: 'CreateTeamInput.Name' Error:Field validation for 'Name' failed on the 'required' tag\nKey: 'CreateTeamInput.CompanyID' Error:Field validation for 'CompanyID' failed on the 'required' tag"}`,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'CreateTeamInput.Name' Error:Field validation for 'Name' failed on the 'required' tag\nKey: 'CreateTeamInput.CompanyID' Error:Field validation for 'CompanyID' failed on the 'required' tag"}}`,
 		},
 	}
 
@@ -61,6 +62,7 @@ func TestHandler_CreateTeam(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			teamMock := mocks.NewTeam(t)
 			testCase.mockBehavior(teamMock, testCase.inputTeam)
 
@@ -94,6 +96,7 @@ func TestHandler_GetTeams(t *testing.T) {
 	testTable := []struct {
 		name                string
 		companyID           string
+		rawQuery            string
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
@@ -102,25 +105,59 @@ func TestHandler_GetTeams(t *testing.T) {
 			name:      "OK",
 			companyID: "1",
 			mockBehavior: func(s *mocks.Team, companyID int) {
-				s.On("GetTeamsByCompanyID", companyID).Return([]model.Team{
-					{
-						ID:          1,
-						Name:        "Test Team",
-						Description: "Test Description",
-						CompanyID:   1,
-						CreatedBy:   1,
+				s.On("GetTeamsByCompanyID", 1, companyID, model.ListQuery{}).Return(model.Page[model.Team]{
+					Data: []model.Team{
+						{
+							ID:          1,
+							Name:        "Test Team",
+							Description: "Test Description",
+							CompanyID:   1,
+							CreatedBy:   1,
+						},
 					},
 				}, nil)
 			},
 			expectedStatusCode:  http.StatusOK,
-			expectedRequestBody: `[{"id":1,"name":"Test Team","description":"Test Description","company_id":1,"created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]`,
+			expectedRequestBody: `{"data":[{"id":1,"name":"Test Team","description":"Test Description","company_id":1,"created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}]}`,
+		},
+		{
+			name:      "Paginated",
+			companyID: "1",
+			rawQuery:  "?limit=1&sort=-name&cursor=abc&include_archived=true",
+			mockBehavior: func(s *mocks.Team, companyID int) {
+				query := model.ListQuery{Limit: 1, Cursor: "abc", Sort: "name", Desc: true, IncludeArchived: true}
+				s.On("GetTeamsByCompanyID", 1, companyID, query).Return(model.Page[model.Team]{
+					Data:       []model.Team{{ID: 2, Name: "B", CompanyID: 1, CreatedBy: 1}},
+					NextCursor: "next",
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"data":[{"id":2,"name":"B","description":"","company_id":1,"created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}],"next_cursor":"next"}`,
+		},
+		{
+			name:      "Invalid Cursor",
+			companyID: "1",
+			rawQuery:  "?cursor=bad",
+			mockBehavior: func(s *mocks.Team, companyID int) {
+				s.On("GetTeamsByCompanyID", 1, companyID, model.ListQuery{Cursor: "bad"}).Return(model.Page[model.Team]{}, model.ErrInvalidInput)
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid sort, status or cursor"}}`,
+		},
+		{
+			name:                "Invalid Limit",
+			companyID:           "1",
+			rawQuery:            "?limit=1000",
+			mockBehavior:        func(s *mocks.Team, companyID int) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"limit must be between 1 and 100"}}`,
 		},
 		{
 			name:                "Invalid Company ID",
 			companyID:           "invalid",
 			mockBehavior:        func(s *mocks.Team, companyID int) {},
 			expectedStatusCode:  http.StatusBadRequest,
-			expectedRequestBody: `{"error":"invalid company id"}`,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid company id"}}`,
 		},
 	}
 
@@ -128,6 +165,7 @@ func TestHandler_GetTeams(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			teamMock := mocks.NewTeam(t)
 			companyID, _ := strconv.Atoi(testCase.companyID)
 			testCase.mockBehavior(teamMock, companyID)
@@ -136,11 +174,13 @@ func TestHandler_GetTeams(t *testing.T) {
 			handler := NewHandler(services)
 
 			// Test Server
-			c.GET("/api/v1/teams/company/:company_id", handler.GetTeams)
+			c.GET("/api/v1/teams/company/:company_id", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.GetTeams)
 
 			// Test Request
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", "/api/v1/teams/company/"+testCase.companyID, nil)
+			req := httptest.NewRequest("GET", "/api/v1/teams/company/"+testCase.companyID+testCase.rawQuery, nil)
 
 			// Perform Request
 			c.ServeHTTP(w, req)
@@ -166,7 +206,7 @@ func TestHandler_DeleteTeam(t *testing.T) {
 			name:    "OK",
 			inputID: "1",
 			mockBehavior: func(s *mocks.Team, id int) {
-				s.On("DeleteTeam", id).Return(nil)
+				s.On("DeleteTeam", 1, id).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"team deleted successfully"}`,
@@ -176,7 +216,7 @@ func TestHandler_DeleteTeam(t *testing.T) {
 			inputID:             "invalid",
 			mockBehavior:        func(s *mocks.Team, id int) {},
 			expectedStatusCode:  http.StatusBadRequest,
-			expectedRequestBody: `{"error":"invalid id"}`,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid id"}}`,
 		},
 	}
 
@@ -184,6 +224,7 @@ func TestHandler_DeleteTeam(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			// Init Dependencies
 			c := gin.New()
+			c.Use(ErrorHandler())
 			teamMock := mocks.NewTeam(t)
 			id, _ := strconv.Atoi(testCase.inputID)
 			testCase.mockBehavior(teamMock, id)
@@ -192,7 +233,9 @@ func TestHandler_DeleteTeam(t *testing.T) {
 			handler := NewHandler(services)
 
 			// Test Server
-			c.DELETE("/api/v1/teams/team/:id", handler.DeleteTeam)
+			c.DELETE("/api/v1/teams/team/:id", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.DeleteTeam)
 
 			// Test Request
 			w := httptest.NewRecorder()
@@ -207,3 +250,210 @@ func TestHandler_DeleteTeam(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_UpdateTeam(t *testing.T) {
+	type mockBehavior func(s *mocks.Team)
+
+	name := "Renamed Team"
+	description := ""
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	version := time.UnixMicro(updatedAt.UnixMicro())
+
+	testTable := []struct {
+		name                string
+		method              string
+		ifMatch             string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedETag        string
+		expectedRequestBody string
+	}{
+		{
+			name:      "Patch OK",
+			method:    "PATCH",
+			ifMatch:   etag(updatedAt),
+			inputBody: `{"name": "Renamed Team"}`,
+			mockBehavior: func(s *mocks.Team) {
+				s.On("UpdateTeam", 1, 1, model.UpdateTeamInput{Name: &name}, &version).Return(model.Team{
+					ID: 1, Name: name, CompanyID: 1, CreatedBy: 1, UpdatedAt: updatedAt.Add(time.Second),
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedETag:        etag(updatedAt.Add(time.Second)),
+			expectedRequestBody: `{"id":1,"name":"Renamed Team","description":"","company_id":1,"created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"2024-05-01T12:00:01Z"}`,
+		},
+		{
+			name:      "Put Without If-Match",
+			method:    "PUT",
+			inputBody: `{"name": "Renamed Team"}`,
+			mockBehavior: func(s *mocks.Team) {
+				var noVersion *time.Time
+				s.On("UpdateTeam", 1, 1, model.UpdateTeamInput{Name: &name, Description: &description}, noVersion).Return(model.Team{
+					ID: 1, Name: name, CompanyID: 1, CreatedBy: 1, UpdatedAt: updatedAt,
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedETag:        etag(updatedAt),
+			expectedRequestBody: `{"id":1,"name":"Renamed Team","description":"","company_id":1,"created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"2024-05-01T12:00:00Z"}`,
+		},
+		{
+			name:      "Stale Version",
+			method:    "PATCH",
+			ifMatch:   etag(updatedAt),
+			inputBody: `{"name": "Renamed Team"}`,
+			mockBehavior: func(s *mocks.Team) {
+				s.On("UpdateTeam", 1, 1, model.UpdateTeamInput{Name: &name}, &version).Return(model.Team{}, model.ErrPreconditionFailed)
+			},
+			expectedStatusCode:  http.StatusPreconditionFailed,
+			expectedRequestBody: `{"error":{"code":"precondition_failed","message":"resource was modified by another request"}}`,
+		},
+		{
+			name:                "Put Missing Name",
+			method:              "PUT",
+			inputBody:           `{"description": "Only description"}`,
+			mockBehavior:        func(s *mocks.Team) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'ReplaceTeamInput.Name' Error:Field validation for 'Name' failed on the 'required' tag"}}`,
+		},
+		{
+			name:                "Invalid If-Match",
+			method:              "PATCH",
+			ifMatch:             `"abc"`,
+			inputBody:           `{"name": "Renamed Team"}`,
+			mockBehavior:        func(s *mocks.Team) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid If-Match header"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			teamMock := mocks.NewTeam(t)
+			testCase.mockBehavior(teamMock)
+
+			services := &service.Service{Team: teamMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.Handle(testCase.method, "/api/v1/teams/team/:id", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.UpdateTeam)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, "/api/v1/teams/team/1",
+				bytes.NewBufferString(testCase.inputBody))
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedETag, w.Header().Get("ETag"))
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_SetTeamParent(t *testing.T) {
+	type mockBehavior func(s *mocks.Team)
+
+	parentID := 2
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	version := time.UnixMicro(updatedAt.UnixMicro())
+	var noVersion *time.Time
+
+	testTable := []struct {
+		name                string
+		ifMatch             string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"parent_id": 2}`,
+			mockBehavior: func(s *mocks.Team) {
+				s.On("SetTeamParent", 1, 1, &parentID, noVersion).Return(model.Team{ID: 1, Name: "Backend", CompanyID: 1, ParentID: &parentID, CreatedBy: 1}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"id":1,"name":"Backend","description":"","company_id":1,"parent_id":2,"created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:      "Top Level",
+			inputBody: `{"parent_id": null}`,
+			mockBehavior: func(s *mocks.Team) {
+				s.On("SetTeamParent", 1, 1, (*int)(nil), noVersion).Return(model.Team{ID: 1, Name: "Backend", CompanyID: 1, CreatedBy: 1}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"id":1,"name":"Backend","description":"","company_id":1,"created_by":1,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:      "Cycle",
+			inputBody: `{"parent_id": 2}`,
+			mockBehavior: func(s *mocks.Team) {
+				s.On("SetTeamParent", 1, 1, &parentID, noVersion).Return(model.Team{}, model.ErrInvalidInput)
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"parent must be another team of the same company outside this subtree"}}`,
+		},
+		{
+			name:      "Stale Version",
+			ifMatch:   etag(updatedAt),
+			inputBody: `{"parent_id": 2}`,
+			mockBehavior: func(s *mocks.Team) {
+				s.On("SetTeamParent", 1, 1, &parentID, &version).Return(model.Team{}, model.ErrPreconditionFailed)
+			},
+			expectedStatusCode:  http.StatusPreconditionFailed,
+			expectedRequestBody: `{"error":{"code":"precondition_failed","message":"resource was modified by another request"}}`,
+		},
+		{
+			name:                "Invalid If-Match",
+			ifMatch:             `"abc"`,
+			inputBody:           `{"parent_id": 2}`,
+			mockBehavior:        func(s *mocks.Team) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid If-Match header"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			teamMock := mocks.NewTeam(t)
+			testCase.mockBehavior(teamMock)
+
+			services := &service.Service{Team: teamMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.PUT("/api/v1/teams/team/:id/parent", func(c *gin.Context) {
+				c.Set("userID", 1)
+			}, handler.SetTeamParent)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/v1/teams/team/1/parent", bytes.NewBufferString(testCase.inputBody))
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	defer otel.SetTextMapPropagator(otel.GetTextMapPropagator())
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	testTable := []struct {
+		name               string
+		path               string
+		traceparent        string
+		expectedName       string
+		expectedStatusCode int
+		expectedStatus     codes.Code
+		expectedTraceID    string
+	}{
+		{
+			name:               "OK",
+			path:               "/teams/7",
+			expectedName:       "GET /teams/:id",
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     codes.Unset,
+		},
+		{
+			name:               "Continues Trace",
+			path:               "/teams/7",
+			traceparent:        "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expectedName:       "GET /teams/:id",
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     codes.Unset,
+			expectedTraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:               "Server Error",
+			path:               "/teams/0",
+			expectedName:       "GET /teams/:id",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedStatus:     codes.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var handlerSpan trace.SpanContext
+
+			r := gin.New()
+			r.Use(Tracing(), ErrorHandler())
+			r.GET("/teams/:id", func(c *gin.Context) {
+				handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+				if c.Param("id") == "0" {
+					c.Error(errors.New("connection reset"))
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.path, nil)
+			if testCase.traceparent != "" {
+				req.Header.Set("traceparent", testCase.traceparent)
+			}
+
+			r.ServeHTTP(w, req)
+
+			spans := recorder.Ended()
+			require.NotEmpty(t, spans)
+			span := spans[len(spans)-1]
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedName, span.Name())
+			assert.Equal(t, testCase.expectedStatus, span.Status().Code)
+			assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", testCase.expectedStatusCode))
+			assert.Equal(t, span.SpanContext(), handlerSpan)
+			if testCase.expectedTraceID != "" {
+				assert.Equal(t, testCase.expectedTraceID, span.SpanContext().TraceID().String())
+				assert.True(t, span.Parent().IsRemote())
+			}
+		})
+	}
+}
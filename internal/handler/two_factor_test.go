@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestHandler_VerifyTwoFactor(t *testing.T) {
+	type mockBehavior func(s *mocks.TwoFactor)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			inputBody: `{
+				"challenge_token": "challenge-token",
+				"code": "123456"
+			}`,
+			mockBehavior: func(s *mocks.TwoFactor) {
+				s.On("VerifyTwoFactor", "challenge-token", "123456", "").Return("test-token", nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"token":"test-token"}`,
+		},
+		{
+			name: "Invalid Code",
+			inputBody: `{
+				"challenge_token": "challenge-token",
+				"code": "000000"
+			}`,
+			mockBehavior: func(s *mocks.TwoFactor) {
+				s.On("VerifyTwoFactor", "challenge-token", "000000", "").Return("", model.ErrInvalidTwoFactorCode)
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"error":{"code":"unauthorized","message":"invalid two-factor code"}}`,
+		},
+		{
+			name: "Wrong Input",
+			inputBody: `{
+				"code": "123456"
+			}`,
+			mockBehavior:        func(s *mocks.TwoFactor) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'TwoFactorVerifyInput.ChallengeToken' Error:Field validation for 'ChallengeToken' failed on the 'required' tag"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			twoFactorMock := mocks.NewTwoFactor(t)
+			testCase.mockBehavior(twoFactorMock)
+
+			services := &service.Service{TwoFactor: twoFactorMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/auth/2fa/verify", handler.VerifyTwoFactor)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/auth/2fa/verify",
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_SetTwoFactorPolicy(t *testing.T) {
+	type mockBehavior func(s *mocks.TwoFactor)
+
+	testTable := []struct {
+		name                string
+		role                string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			role: "admin",
+			inputBody: `{
+				"role": "manager",
+				"required": true
+			}`,
+			mockBehavior: func(s *mocks.TwoFactor) {
+				s.On("SetTwoFactorPolicy", "manager", true).Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"two-factor policy updated"}`,
+		},
+		{
+			name: "Not Admin",
+			role: "manager",
+			inputBody: `{
+				"role": "manager",
+				"required": true
+			}`,
+			mockBehavior:        func(s *mocks.TwoFactor) {},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"insufficient permissions"}}`,
+		},
+		{
+			name: "Unknown Role",
+			role: "admin",
+			inputBody: `{
+				"role": "guest",
+				"required": false
+			}`,
+			mockBehavior: func(s *mocks.TwoFactor) {
+				s.On("SetTwoFactorPolicy", "guest", false).Return(model.ErrInvalidInput)
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid input"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			twoFactorMock := mocks.NewTwoFactor(t)
+			testCase.mockBehavior(twoFactorMock)
+
+			services := &service.Service{TwoFactor: twoFactorMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.PUT("/api/v1/auth/2fa/policies", func(c *gin.Context) {
+				c.Set("userID", 1)
+				c.Set("userRole", testCase.role)
+			}, handler.RequireRole(model.UserRoleAdmin), handler.SetTwoFactorPolicy)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/v1/auth/2fa/policies",
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
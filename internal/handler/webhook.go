@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/teamdetected/internal/model"
+)
+
+// CreateWebhook subscribes a URL to company events. The response is the only
+// place the signing secret is ever shown.
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var input model.CreateWebhookInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, companyID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.services.Webhook.CreateWebhook(c.Request.Context(), userID, companyID, input)
+	if err != nil {
+		webhookErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+func (h *Handler) GetWebhooks(c *gin.Context) {
+	userID, companyID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.services.Webhook.GetWebhooks(c.Request.Context(), userID, companyID)
+	if err != nil {
+		webhookErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	var input model.UpdateWebhookInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, companyID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	webhookID, ok := intParam(c, "webhook_id", "invalid webhook id")
+	if !ok {
+		return
+	}
+
+	subscription, err := h.services.Webhook.UpdateWebhook(c.Request.Context(), userID, companyID, webhookID, input)
+	if err != nil {
+		webhookErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	userID, companyID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	webhookID, ok := intParam(c, "webhook_id", "invalid webhook id")
+	if !ok {
+		return
+	}
+
+	if err := h.services.Webhook.DeleteWebhook(c.Request.Context(), userID, companyID, webhookID); err != nil {
+		webhookErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries returns the delivery log of a subscription, newest
+// last unless sort=-id. It accepts the common list parameters and
+// status=pending|succeeded|failed.
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	userID, companyID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	webhookID, ok := intParam(c, "webhook_id", "invalid webhook id")
+	if !ok {
+		return
+	}
+
+	query, err := parseListQuery(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deliveries, err := h.services.Webhook.GetWebhookDeliveries(c.Request.Context(), userID, companyID, webhookID, query)
+	if errors.Is(err, model.ErrInvalidInput) {
+		listErrorResponse(c, err)
+		return
+	}
+	if err != nil {
+		webhookErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverWebhook queues the event of a logged delivery again.
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	userID, companyID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	webhookID, ok := intParam(c, "webhook_id", "invalid webhook id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := intParam(c, "delivery_id", "invalid delivery id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.services.Webhook.RedeliverWebhook(c.Request.Context(), userID, companyID, webhookID, deliveryID)
+	if err != nil {
+		webhookErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// webhookParams reads the requester and the company id shared by every
+// webhook route, responding with an error when either is missing.
+func webhookParams(c *gin.Context) (int, int, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		newErrorResponse(c, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, false
+	}
+
+	companyID, ok := intParam(c, "id", "invalid id")
+	return userID.(int), companyID, ok
+}
+
+func intParam(c *gin.Context, name, message string) (int, bool) {
+	value, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return value, true
+}
+
+func webhookErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrForbidden):
+		newErrorResponse(c, http.StatusForbidden, "only company owners and admins can manage webhooks")
+	case errors.Is(err, model.ErrInvalidInput):
+		newErrorResponse(c, http.StatusBadRequest, "unknown event type")
+	default:
+		c.Error(err)
+	}
+}
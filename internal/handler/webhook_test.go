@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/service"
+	"github.com/teamdetected/internal/service/mocks"
+)
+
+func TestHandler_CreateWebhook(t *testing.T) {
+	type mockBehavior func(s *mocks.Webhook, input model.CreateWebhookInput)
+
+	createdAt := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		input               model.CreateWebhookInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"url": "https://example.com/hooks", "events": ["survey.opened", "survey.closed"]}`,
+			input:     model.CreateWebhookInput{URL: "https://example.com/hooks", Events: []string{"survey.opened", "survey.closed"}},
+			mockBehavior: func(s *mocks.Webhook, input model.CreateWebhookInput) {
+				s.On("CreateWebhook", 1, 2, input).Return(model.WebhookSubscription{
+					ID: 5, CompanyID: 2, URL: "https://example.com/hooks", Secret: "c2VjcmV0",
+					Events: []string{"survey.opened", "survey.closed"}, Active: true, CreatedBy: 1,
+					CreatedAt: createdAt, UpdatedAt: createdAt,
+				}, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedRequestBody: `{"id":5,"company_id":2,"url":"https://example.com/hooks","secret":"c2VjcmV0",` +
+				`"events":["survey.opened","survey.closed"],"active":true,"created_by":1,` +
+				`"created_at":"2024-05-06T09:00:00Z","updated_at":"2024-05-06T09:00:00Z"}`,
+		},
+		{
+			name:      "Unknown Event",
+			inputBody: `{"url": "https://example.com/hooks", "events": ["survey.deleted"]}`,
+			input:     model.CreateWebhookInput{URL: "https://example.com/hooks", Events: []string{"survey.deleted"}},
+			mockBehavior: func(s *mocks.Webhook, input model.CreateWebhookInput) {
+				s.On("CreateWebhook", 1, 2, input).Return(model.WebhookSubscription{}, model.ErrInvalidInput)
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"unknown event type"}}`,
+		},
+		{
+			name:      "Not Admin",
+			inputBody: `{"url": "https://example.com/hooks", "events": ["team.created"]}`,
+			input:     model.CreateWebhookInput{URL: "https://example.com/hooks", Events: []string{"team.created"}},
+			mockBehavior: func(s *mocks.Webhook, input model.CreateWebhookInput) {
+				s.On("CreateWebhook", 1, 2, input).Return(model.WebhookSubscription{}, model.ErrForbidden)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"only company owners and admins can manage webhooks"}}`,
+		},
+		{
+			name:                "Invalid URL",
+			inputBody:           `{"url": "not a url", "events": ["team.created"]}`,
+			mockBehavior:        func(s *mocks.Webhook, input model.CreateWebhookInput) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"Key: 'CreateWebhookInput.URL' Error:Field validation for 'URL' failed on the 'url' tag"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			webhookMock := mocks.NewWebhook(t)
+			testCase.mockBehavior(webhookMock, testCase.input)
+
+			services := &service.Service{Webhook: webhookMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/companies/:id/webhooks", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.CreateWebhook(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/companies/2/webhooks", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_RedeliverWebhook(t *testing.T) {
+	type mockBehavior func(s *mocks.Webhook)
+
+	createdAt := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		path                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			path: "/api/v1/companies/2/webhooks/5/deliveries/9/redeliver",
+			mockBehavior: func(s *mocks.Webhook) {
+				s.On("RedeliverWebhook", 1, 2, 5, 9).Return(model.WebhookDelivery{
+					ID: 10, SubscriptionID: 5, EventID: "abc", EventType: model.EventSurveyOpened,
+					Payload: []byte(`{"id":"abc"}`), Status: model.WebhookDeliveryPending,
+					NextAttemptAt: createdAt, CreatedAt: createdAt,
+				}, nil)
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedRequestBody: `{"id":10,"subscription_id":5,"event_id":"abc","event_type":"survey.opened",` +
+				`"payload":{"id":"abc"},"status":"pending","attempts":0,"next_attempt_at":"2024-05-06T09:00:00Z",` +
+				`"created_at":"2024-05-06T09:00:00Z"}`,
+		},
+		{
+			name: "Delivery Of Another Webhook",
+			path: "/api/v1/companies/2/webhooks/5/deliveries/11/redeliver",
+			mockBehavior: func(s *mocks.Webhook) {
+				s.On("RedeliverWebhook", 1, 2, 5, 11).Return(model.WebhookDelivery{}, model.ErrNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"not found"}}`,
+		},
+		{
+			name:                "Invalid Delivery ID",
+			path:                "/api/v1/companies/2/webhooks/5/deliveries/abc/redeliver",
+			mockBehavior:        func(s *mocks.Webhook) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"error":{"code":"invalid_input","message":"invalid delivery id"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Dependencies
+			c := gin.New()
+			c.Use(ErrorHandler())
+			webhookMock := mocks.NewWebhook(t)
+			testCase.mockBehavior(webhookMock)
+
+			services := &service.Service{Webhook: webhookMock}
+			handler := NewHandler(services)
+
+			// Test Server
+			c.POST("/api/v1/companies/:id/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", func(c *gin.Context) {
+				c.Set("userID", 1)
+				handler.RedeliverWebhook(c)
+			})
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", testCase.path, nil)
+
+			// Perform Request
+			c.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package importer reads employee and team structure sheets in CSV or XLSX
+// format into records keyed by their header names.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// MaxRows bounds the number of data rows accepted from one file.
+const MaxRows = 5000
+
+var (
+	ErrEmptyFile   = errors.New("importer: file has no header row")
+	ErrTooManyRows = fmt.Errorf("importer: file has more than %d rows", MaxRows)
+)
+
+// Record is one data row. Line is the 1-based line (CSV) or row number
+// (XLSX) in the source file, so errors can point at it.
+type Record struct {
+	Line   int
+	Values map[string]string
+}
+
+// Get returns the trimmed value of column, or "" when the file lacks it.
+func (r Record) Get(column string) string {
+	return r.Values[column]
+}
+
+type row struct {
+	line  int
+	cells []string
+}
+
+// Read parses a CSV or XLSX file. XLSX is recognised by the file extension
+// or the zip signature, anything else is read as CSV. Header names are
+// lower-cased and trimmed; blank rows are skipped.
+func Read(name string, data []byte) ([]Record, error) {
+	var (
+		rows []row
+		err  error
+	)
+
+	if strings.EqualFold(filepath.Ext(name), ".xlsx") || bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		rows, err = readXLSX(data)
+	} else {
+		rows, err = readCSV(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return records(rows)
+}
+
+func readCSV(data []byte) ([]row, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []row
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("importer: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, row{line: line, cells: cells})
+		if len(rows) > MaxRows+1 {
+			return nil, ErrTooManyRows
+		}
+	}
+}
+
+func records(rows []row) ([]Record, error) {
+	header := -1
+	for i, r := range rows {
+		if !blank(r.cells) {
+			header = i
+			break
+		}
+	}
+	if header < 0 {
+		return nil, ErrEmptyFile
+	}
+
+	columns := make([]string, len(rows[header].cells))
+	for i, name := range rows[header].cells {
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	var result []Record
+	for _, r := range rows[header+1:] {
+		if blank(r.cells) {
+			continue
+		}
+		if len(result) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+
+		values := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if column == "" || i >= len(r.cells) {
+				continue
+			}
+			values[column] = strings.TrimSpace(r.cells[i])
+		}
+		result = append(result, Record{Line: r.line, Values: values})
+	}
+
+	return result, nil
+}
+
+func blank(cells []string) bool {
+	for _, value := range cells {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
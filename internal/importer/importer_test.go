@@ -0,0 +1,69 @@
+package importer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/importer"
+)
+
+func TestRead_CSV(t *testing.T) {
+	data := []byte("\xef\xbb\xbfEmail, Name,Team\n" +
+		"ann@example.com,Ann,Backend\n" +
+		"\n" +
+		"bob@example.com,\"Bob, Jr.\",Frontend,extra\n")
+
+	records, err := importer.Read("people.csv", data)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, 2, records[0].Line)
+	assert.Equal(t, "ann@example.com", records[0].Get("email"))
+	assert.Equal(t, "Backend", records[0].Get("team"))
+	assert.Equal(t, 4, records[1].Line)
+	assert.Equal(t, "Bob, Jr.", records[1].Get("name"))
+	assert.Equal(t, "", records[1].Get("role"))
+}
+
+func TestRead_XLSX(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="People" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>email</t></si><si><t>team</t></si><si><r><t>Back</t></r><r><t>end</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+			<row r="3"><c r="A3" t="inlineStr"><is><t>ann@example.com</t></is></c><c r="C3" t="s"><v>2</v></c></row>
+			</sheetData></worksheet>`,
+	}
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+
+	records, err := importer.Read("people.xlsx", buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	assert.Equal(t, 3, records[0].Line)
+	assert.Equal(t, "ann@example.com", records[0].Get("email"))
+	assert.Equal(t, "Backend", records[0].Get("team"))
+}
+
+func TestRead_Empty(t *testing.T) {
+	_, err := importer.Read("people.csv", []byte("\n\n"))
+	assert.ErrorIs(t, err, importer.ErrEmptyFile)
+
+	_, err = importer.Read("people.xlsx", []byte("not a zip"))
+	assert.ErrorIs(t, err, importer.ErrInvalidXLSX)
+}
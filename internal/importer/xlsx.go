@@ -0,0 +1,211 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXPart bounds how much of a single workbook part is decompressed.
+const maxXLSXPart = 64 << 20
+
+var ErrInvalidXLSX = errors.New("importer: invalid xlsx file")
+
+// readXLSX returns the cell values of the first worksheet. Only what an
+// export from Excel, LibreOffice or Google Sheets needs is supported: shared
+// strings, inline strings and plain values. Formulas yield their cached
+// value.
+func readXLSX(data []byte) ([]row, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalidXLSX
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheet, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return readSheet(sheet, shared)
+}
+
+// firstSheet resolves the first sheet listed in the workbook, falling back to
+// the lowest numbered worksheet part.
+func firstSheet(files map[string]*zip.File) (*zip.File, error) {
+	var workbook struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if wb, ok := files["xl/workbook.xml"]; ok && decodePart(wb, &workbook) == nil && len(workbook.Sheets) > 0 {
+		if rf, ok := files["xl/_rels/workbook.xml.rels"]; ok && decodePart(rf, &rels) == nil {
+			for _, rel := range rels.Relationships {
+				if rel.ID != workbook.Sheets[0].RID {
+					continue
+				}
+				target := strings.TrimPrefix(rel.Target, "/")
+				if !strings.HasPrefix(target, "xl/") {
+					target = path.Join("xl", target)
+				}
+				if f, ok := files[target]; ok {
+					return f, nil
+				}
+			}
+		}
+	}
+
+	var names []string
+	for name := range files {
+		if strings.HasPrefix(name, "xl/worksheets/") && strings.HasSuffix(name, ".xml") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, ErrInvalidXLSX
+	}
+	sort.Strings(names)
+
+	return files[names[0]], nil
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodePart(f, &sst); err != nil {
+		return nil, err
+	}
+
+	shared := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		if len(item.Runs) == 0 {
+			shared[i] = item.Text
+			continue
+		}
+		var sb strings.Builder
+		for _, run := range item.Runs {
+			sb.WriteString(run.Text)
+		}
+		shared[i] = sb.String()
+	}
+
+	return shared, nil
+}
+
+func readSheet(f *zip.File, shared []string) ([]row, error) {
+	var sheet struct {
+		Rows []struct {
+			Number int `xml:"r,attr"`
+			Cells  []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					Text string `xml:"t"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodePart(f, &sheet); err != nil {
+		return nil, err
+	}
+	if len(sheet.Rows) > MaxRows+1 {
+		return nil, ErrTooManyRows
+	}
+
+	rows := make([]row, 0, len(sheet.Rows))
+	for i, r := range sheet.Rows {
+		line := r.Number
+		if line == 0 {
+			line = i + 1
+		}
+
+		var cells []string
+		for j, c := range r.Cells {
+			column := j
+			if c.Ref != "" {
+				column = columnIndex(c.Ref)
+			}
+			if column < 0 || column > 1<<14 {
+				return nil, ErrInvalidXLSX
+			}
+
+			value := c.Value
+			switch c.Type {
+			case "s":
+				index, err := strconv.Atoi(c.Value)
+				if err != nil || index < 0 || index >= len(shared) {
+					return nil, ErrInvalidXLSX
+				}
+				value = shared[index]
+			case "inlineStr":
+				value = c.Inline.Text
+			}
+
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+			cells[column] = value
+		}
+		rows = append(rows, row{line: line, cells: cells})
+	}
+
+	return rows, nil
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a
+// 0-based column index.
+func columnIndex(ref string) int {
+	index := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		index = index*26 + int(ch-'A'+1)
+	}
+	return index - 1
+}
+
+func decodePart(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return ErrInvalidXLSX
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, maxXLSXPart)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidXLSX, f.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package metrics defines the Prometheus metrics of the service. They are
+// registered with the default registry, which also carries the Go runtime and
+// process metrics, and served by Handler.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/teamdetected/internal/model"
+)
+
+const namespace = "teamdetected"
+
+// HTTP metrics, recorded by the handler middleware. route is the gin route
+// pattern, e.g. /api/v1/teams/team/:id, so ids do not blow up cardinality.
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Database metrics, recorded by the repository for every statement.
+// operation is the SQL verb: SELECT, INSERT, UPDATE, DELETE or OTHER.
+var (
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database statement latency by operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"operation"})
+
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Failed database statements by operation; missing rows are not failures.",
+	}, []string{"operation"})
+)
+
+// Survey metrics. source of a created survey is "api" or "schedule".
+var (
+	SurveysCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "surveys_created_total",
+		Help:      "Surveys created by source.",
+	}, []string{"source"})
+
+	SurveyResponsesSubmitted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "survey_responses_submitted_total",
+		Help:      "Survey answers stored.",
+	})
+
+	activeSurveys = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "surveys_active",
+		Help:      "Open surveys that are neither archived nor deleted.",
+	})
+
+	surveyCompletionRatio = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "survey_completion_ratio",
+		Help:      "Share of team members who answered every question of an active survey, over all active surveys.",
+	})
+)
+
+// SetSurveyActivity updates the gauges computed from the database.
+func SetSurveyActivity(activity model.SurveyActivity) {
+	activeSurveys.Set(float64(activity.ActiveSurveys))
+	surveyCompletionRatio.Set(activity.CompletionRate())
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func RegisterDB(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,82 @@
+package migrate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/migrate"
+	"github.com/teamdetected/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_add_teams.up.sql":      {Data: []byte("CREATE TABLE teams ();")},
+		"000002_add_teams.down.sql":    {Data: []byte("DROP TABLE teams;")},
+		"000001_create_users.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"000001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations.go":                {Data: []byte("package migrations")},
+	}
+
+	loaded, err := migrate.Load(fsys)
+	require.NoError(t, err)
+
+	assert.Equal(t, []migrate.Migration{
+		{Version: 1, Name: "create_users", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+		{Version: 2, Name: "add_teams", Up: "CREATE TABLE teams ();", Down: "DROP TABLE teams;"},
+	}, loaded)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	testTable := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "Conflicting Names",
+			fsys: fstest.MapFS{
+				"001_create_users_table.up.sql":   {Data: []byte("SELECT 1;")},
+				"001_create_users_table.down.sql": {Data: []byte("SELECT 1;")},
+				"001_init_users.up.sql":           {Data: []byte("SELECT 1;")},
+				"001_init_users.down.sql":         {Data: []byte("SELECT 1;")},
+			},
+		},
+		{
+			name: "Missing Down",
+			fsys: fstest.MapFS{
+				"000001_create_users.up.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+		{
+			name: "Unversioned",
+			fsys: fstest.MapFS{
+				"create_users.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+		{
+			name: "Zero Version",
+			fsys: fstest.MapFS{
+				"000000_init.up.sql":   {Data: []byte("SELECT 1;")},
+				"000000_init.down.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := migrate.Load(testCase.fsys)
+			assert.ErrorIs(t, err, migrate.ErrInvalidMigrations)
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	loaded, err := migrate.Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	for i, migration := range loaded {
+		assert.Equal(t, int64(i+1), migration.Version, migration.Name)
+	}
+}
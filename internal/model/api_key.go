@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// APIKeyPrefix starts every personal API key so it can be told apart from a
+// JWT in the Authorization header.
+const APIKeyPrefix = "tdk_"
+
+// API key scopes grant read or write access to one route group. Write access
+// implies read access.
+const (
+	ScopeCompaniesRead  = "companies:read"
+	ScopeCompaniesWrite = "companies:write"
+	ScopeTeamsRead      = "teams:read"
+	ScopeTeamsWrite     = "teams:write"
+	ScopeSurveysRead    = "surveys:read"
+	ScopeSurveysWrite   = "surveys:write"
+)
+
+var APIKeyScopes = []string{
+	ScopeCompaniesRead, ScopeCompaniesWrite,
+	ScopeTeamsRead, ScopeTeamsWrite,
+	ScopeSurveysRead, ScopeSurveysWrite,
+}
+
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKey is returned once on creation; Key is never shown again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyPrincipal is the caller identified by a valid API key.
+type APIKeyPrincipal struct {
+	KeyID    int
+	UserID   int
+	UserRole string
+	Scopes   []string
+}
+
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -3,31 +3,130 @@ package model
 import "time"
 
 type Company struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name" binding:"required"`
-	Description string    `json:"description"`
-	CreatedBy   int       `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
+	CreatedBy   int        `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+
+	Stats *CompanyStats `json:"stats,omitempty"`
 }
 
 type Team struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name" binding:"required"`
-	Description string    `json:"description"`
-	CompanyID   int       `json:"company_id" binding:"required"`
-	CreatedBy   int       `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
+	CompanyID   int        `json:"company_id" binding:"required"`
+	ParentID    *int       `json:"parent_id,omitempty"`
+	CreatedBy   int        `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
 }
 
 type CreateCompanyInput struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	// FirstTeam, when set, is created together with the company.
+	FirstTeam *CreateFirstTeamInput `json:"first_team"`
+}
+
+type CreateFirstTeamInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
 }
 
 type CreateTeamInput struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
 	CompanyID   int    `json:"company_id" binding:"required"`
+	ParentID    *int   `json:"parent_id"`
+}
+
+// SetTeamParentInput moves a team under another team of the same company; a
+// null parent_id makes it a top-level team.
+type SetTeamParentInput struct {
+	ParentID *int `json:"parent_id"`
+}
+
+// UpdateCompanyInput is a partial update; nil fields are left unchanged.
+type UpdateCompanyInput struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+type UpdateTeamInput struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// ReplaceCompanyInput is the full representation accepted by PUT.
+type ReplaceCompanyInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type ReplaceTeamInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// CompanyRole is a user's role within one company. Every company has exactly
+// one owner; owners and admins manage members, managers manage teams and
+// surveys, viewers only read.
+type CompanyRole string
+
+const (
+	CompanyRoleOwner   CompanyRole = "owner"
+	CompanyRoleAdmin   CompanyRole = "admin"
+	CompanyRoleManager CompanyRole = "manager"
+	CompanyRoleViewer  CompanyRole = "viewer"
+)
+
+type CompanyMember struct {
+	UserID    int         `json:"user_id"`
+	Email     string      `json:"email"`
+	Name      string      `json:"name"`
+	Role      CompanyRole `json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type AddCompanyMemberInput struct {
+	Email string      `json:"email" binding:"required,email"`
+	Role  CompanyRole `json:"role" binding:"required"`
+}
+
+type UpdateCompanyMemberInput struct {
+	Role CompanyRole `json:"role" binding:"required"`
+}
+
+type TransferOwnershipInput struct {
+	UserID int `json:"user_id" binding:"required"`
+}
+
+// CompanyInvitation invites an email address to join a company, and
+// optionally one of its teams, with Role. Only the token's hash is stored.
+type CompanyInvitation struct {
+	CompanyID int
+	Email     string
+	Role      CompanyRole
+	TeamID    *int
+	TokenHash string
+	InvitedBy int
+	ExpiresAt time.Time
+}
+
+type AcceptInvitationInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// CompanyStats are summary counters returned with company details and
+// listings.
+type CompanyStats struct {
+	TeamsCount                 int      `json:"teams_count"`
+	EmployeesCount             int      `json:"employees_count"`
+	ActiveSurveysCount         int      `json:"active_surveys_count"`
+	LatestSurveyCompletionRate *float64 `json:"latest_survey_completion_rate"`
 }
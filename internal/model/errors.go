@@ -6,4 +6,60 @@ var (
 	ErrInvalidInput = errors.New("invalid input")
 	ErrNotFound     = errors.New("not found")
 	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+
+	// ErrConflict means the write collides with existing data, e.g. a unique
+	// constraint.
+	ErrConflict = errors.New("resource already exists")
+
+	// ErrUnprocessable means the request is well-formed but references data
+	// that does not exist or breaks a constraint.
+	ErrUnprocessable = errors.New("request violates a data constraint")
+
+	// ErrPreconditionFailed means the resource changed since the version the
+	// client sent in If-Match.
+	ErrPreconditionFailed = errors.New("resource was modified by another request")
+
+	// ErrRestoreWindowExpired means a soft-deleted resource is past the
+	// retention window and waits to be purged.
+	ErrRestoreWindowExpired = errors.New("restore window has expired")
+
+	// ErrTimeout means a query ran past its deadline or the database statement
+	// timeout.
+	ErrTimeout = errors.New("request timed out")
+
+	ErrInvalidTwoFactorCode   = errors.New("invalid two-factor code")
+	ErrTwoFactorNotEnrolled   = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorAlreadyActive = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorPolicy        = errors.New("two-factor authentication is required for this role")
+
+	// ErrTwoFactorLocked rejects second-factor codes after too many wrong
+	// ones until the lockout expires.
+	ErrTwoFactorLocked = errors.New("too many invalid two-factor codes; try again later")
+
+	ErrSSONotConfigured  = errors.New("single sign-on is not configured for this company")
+	ErrSSOLoginExpired   = errors.New("single sign-on login expired or was already used")
+	ErrSSOUserNotAllowed = errors.New("identity provider user is not allowed to sign in")
+
+	// ErrSSODomainNotVerified means the domain verification TXT record was
+	// not found in DNS.
+	ErrSSODomainNotVerified = errors.New("email domain verification record not found")
+
+	ErrInvalidAPIKey = errors.New("invalid api key")
+
+	// ErrSurveyClosed rejects answers to a survey that is completed or
+	// archived.
+	ErrSurveyClosed = errors.New("survey is not accepting responses")
+
+	// ErrNotTeamMember rejects answers from users outside the survey's team.
+	ErrNotTeamMember = errors.New("only members of the survey's team can respond")
+
+	// ErrOwnsCompanies refuses to delete a user who still owns companies
+	// unless they are handed over to someone else.
+	ErrOwnsCompanies = errors.New("user owns companies; pass transfer_to to hand them over")
+
+	ErrInvalidPassword   = errors.New("invalid password")
+	ErrEmailTaken        = errors.New("email is already in use")
+	ErrInvalidEmailToken = errors.New("email verification token is invalid or expired")
+	ErrInvalidInvitation = errors.New("invitation is invalid or expired")
 )
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types.
+const (
+	EventSurveyOpened = "survey.opened"
+	EventSurveyClosed = "survey.closed"
+	// EventSurveyAnswered fires when the last team member answers the last
+	// question of a survey.
+	EventSurveyAnswered = "survey.answered"
+	EventTeamCreated    = "team.created"
+
+	EventCompanyCreated    = "company.created"
+	EventCompanyDeleted    = "company.deleted"
+	EventCompanyRestored   = "company.restored"
+	EventCompanyArchived   = "company.archived"
+	EventCompanyUnarchived = "company.unarchived"
+)
+
+// Event is a domain event as it is relayed from the outbox to the event
+// sinks and sent to webhook subscribers. ID is unique per event and lets
+// consumers drop duplicates.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	CompanyID  int             `json:"company_id"`
+	TeamID     int             `json:"team_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// OutboxEvent is an event claimed from the outbox for relaying.
+type OutboxEvent struct {
+	Event
+	Attempts int `json:"-"`
+}
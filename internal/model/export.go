@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// CompanyExport is a full dump of a company: its members and every team that
+// is not deleted, with the team's surveys and their responses. Archived
+// teams and surveys are included.
+type CompanyExport struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Company    Company         `json:"company"`
+	Members    []CompanyMember `json:"members"`
+	Teams      []TeamExport    `json:"teams"`
+}
+
+type TeamExport struct {
+	Team
+	Surveys []SurveyExport `json:"surveys"`
+}
+
+type SurveyExport struct {
+	Survey
+	Responses []SurveyResponse `json:"responses"`
+}
@@ -0,0 +1,41 @@
+package model
+
+// ImportRow is one validated employee row of a company import.
+// InvitationTokenHash is stored with the invitation when the employee is
+// not a member of the company yet.
+type ImportRow struct {
+	Line                int
+	Email               string
+	Name                string
+	Team                string
+	Role                CompanyRole
+	InvitationTokenHash string
+}
+
+// ImportTeam is a team named in an import. Teams are ordered so that a
+// parent named in the same file comes before its sub-teams.
+type ImportTeam struct {
+	Line       int
+	Name       string
+	ParentTeam string
+}
+
+type ImportError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport describes what an import did or, for a dry run, would do.
+// Nothing is written when Errors is not empty. Invited lists the emails that
+// were sent an invitation instead of being added directly.
+type ImportReport struct {
+	DryRun             bool          `json:"dry_run"`
+	Applied            bool          `json:"applied"`
+	Rows               int           `json:"rows"`
+	TeamsCreated       int           `json:"teams_created"`
+	InvitationsCreated int           `json:"invitations_created"`
+	TeamMembersAdded   int           `json:"team_members_added"`
+	Invited            []string      `json:"invited"`
+	Errors             []ImportError `json:"errors"`
+}
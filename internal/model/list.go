@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListQuery describes one page of a list endpoint. Pages are keyset based:
+// Cursor is the opaque NextCursor of the previous page and is only valid with
+// the same Sort and Desc. Filters that do not apply to a resource are ignored.
+type ListQuery struct {
+	Limit  int
+	Cursor string
+	Sort   string
+	Desc   bool
+
+	IncludeArchived bool
+	Status          string
+	CreatedAfter    *time.Time
+	CreatedBefore   *time.Time
+}
+
+// Page is a single page of a list endpoint. NextCursor is empty on the last
+// page.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
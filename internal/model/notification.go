@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+const NotificationSurveyReminder = "survey_reminder"
+
+// Notification is a message to one user. Every channel of a Notifier renders
+// it its own way: email uses Subject and Body, webhooks post it as JSON.
+type Notification struct {
+	Kind     string `json:"kind"`
+	UserID   int    `json:"user_id"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+	SurveyID int    `json:"survey_id,omitempty"`
+}
+
+// NotificationSettings are the user's notification preferences.
+type NotificationSettings struct {
+	SurveyReminders bool `json:"survey_reminders"`
+}
+
+type UpdateNotificationSettingsInput struct {
+	SurveyReminders *bool `json:"survey_reminders" binding:"required"`
+}
+
+// SurveyReminder is a reminder due for a team member who has not answered
+// every question of an active survey.
+type SurveyReminder struct {
+	SurveyID      int
+	SurveyTitle   string
+	ClosesAt      time.Time
+	UserID        int
+	Email         string
+	Name          string
+	OffsetMinutes int
+	Answered      int
+	Questions     int
+}
@@ -0,0 +1,23 @@
+package model
+
+// QuestionResult is the average answer to one survey question. Scores are
+// survey option values from 1 to 5.
+type QuestionResult struct {
+	QuestionID   int     `json:"question_id"`
+	Text         string  `json:"text"`
+	Category     string  `json:"category"`
+	AverageScore float64 `json:"average_score"`
+	Responses    int     `json:"responses"`
+}
+
+// TeamResults aggregates the survey responses of a team. With roll-up it
+// covers the team and all of its sub-teams.
+type TeamResults struct {
+	TeamID       int              `json:"team_id"`
+	RollUp       bool             `json:"roll_up"`
+	Teams        int              `json:"teams"`
+	Surveys      int              `json:"surveys"`
+	Respondents  int              `json:"respondents"`
+	AverageScore float64          `json:"average_score"`
+	Questions    []QuestionResult `json:"questions"`
+}
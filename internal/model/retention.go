@@ -0,0 +1,8 @@
+package model
+
+// PurgeResult counts the soft-deleted rows removed by one purge run.
+type PurgeResult struct {
+	Surveys   int64 `json:"surveys"`
+	Teams     int64 `json:"teams"`
+	Companies int64 `json:"companies"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// DefaultSurveyTitleTemplate names scheduled surveys when no template is
+// given. "{date}" is replaced with the run date in the schedule's timezone.
+const DefaultSurveyTitleTemplate = "Pulse survey {date}"
+
+// SurveySchedule opens a new survey for its team whenever Rule fires. Rule is
+// a cron expression, a shortcut such as @monthly, or "@every 336h".
+type SurveySchedule struct {
+	ID            int        `json:"id"`
+	TeamID        int        `json:"team_id"`
+	Rule          string     `json:"rule"`
+	Timezone      string     `json:"timezone"`
+	TitleTemplate string     `json:"title_template"`
+	DurationHours int        `json:"duration_hours"`
+	Enabled       bool       `json:"enabled"`
+	NextRunAt     time.Time  `json:"next_run_at"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	CreatedBy     int        `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type CreateSurveyScheduleInput struct {
+	Rule          string `json:"rule" binding:"required"`
+	Timezone      string `json:"timezone"`
+	TitleTemplate string `json:"title_template"`
+	DurationHours int    `json:"duration_hours" binding:"required,min=1"`
+}
+
+// UpdateSurveyScheduleInput is a partial update; nil fields are left
+// unchanged.
+type UpdateSurveyScheduleInput struct {
+	Rule          *string `json:"rule"`
+	Timezone      *string `json:"timezone"`
+	TitleTemplate *string `json:"title_template"`
+	DurationHours *int    `json:"duration_hours" binding:"omitempty,min=1"`
+	Enabled       *bool   `json:"enabled"`
+}
+
+// SurveyWave is what one run of a schedule opens: the survey's title and
+// close time, and when the schedule fires next.
+type SurveyWave struct {
+	Title     string    `json:"title"`
+	ClosesAt  time.Time `json:"closes_at"`
+	NextRunAt time.Time `json:"next_run_at"`
+}
+
+// SchedulerRun reports one scheduler tick: the surveys it opened and the ones
+// it completed. Leader is false when another instance held the scheduler
+// lock and nothing was done.
+type SchedulerRun struct {
+	Leader bool
+	Opened []Survey
+	Closed []Survey
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// SSOConfig is a company's OpenID Connect identity provider registration.
+// AutoProvision only takes effect once DomainVerifiedAt is set, i.e. the
+// company published DomainVerificationRecord in DNS.
+type SSOConfig struct {
+	CompanyID                int         `json:"company_id"`
+	Issuer                   string      `json:"issuer"`
+	ClientID                 string      `json:"client_id"`
+	ClientSecret             string      `json:"-"`
+	RedirectURL              string      `json:"redirect_url"`
+	EmailDomain              string      `json:"email_domain"`
+	AutoProvision            bool        `json:"auto_provision"`
+	DefaultRole              CompanyRole `json:"default_role"`
+	Enabled                  bool        `json:"enabled"`
+	DomainVerificationToken  string      `json:"-"`
+	DomainVerificationRecord *TXTRecord  `json:"domain_verification_record,omitempty"`
+	DomainVerifiedAt         *time.Time  `json:"domain_verified_at"`
+	CreatedAt                time.Time   `json:"created_at"`
+	UpdatedAt                time.Time   `json:"updated_at"`
+}
+
+// TXTRecord is a DNS record a company publishes to prove domain ownership.
+type TXTRecord struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type SSOConfigInput struct {
+	Issuer        string      `json:"issuer" binding:"required,url"`
+	ClientID      string      `json:"client_id" binding:"required"`
+	ClientSecret  string      `json:"client_secret"`
+	RedirectURL   string      `json:"redirect_url" binding:"required,url"`
+	EmailDomain   string      `json:"email_domain"`
+	AutoProvision bool        `json:"auto_provision"`
+	DefaultRole   CompanyRole `json:"default_role"`
+	Enabled       *bool       `json:"enabled"`
+}
+
+// OIDCLoginState is an authorization request waiting for the provider callback.
+// LinkUserID is set when a signed-in user started the request to link the
+// provider identity to their account.
+type OIDCLoginState struct {
+	State        string
+	CompanyID    int
+	LinkUserID   int
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
+
+// ExternalIdentity is the identity asserted by an SSO provider.
+type ExternalIdentity struct {
+	Issuer  string
+	Subject string
+	Email   string
+	Name    string
+}
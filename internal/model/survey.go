@@ -3,12 +3,33 @@ package model
 import "time"
 
 type Survey struct {
-	ID        int       `json:"id"`
-	TeamID    int       `json:"team_id" binding:"required"`
-	Status    string    `json:"status"` // active, completed
-	CreatedBy int       `json:"created_by"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int        `json:"id"`
+	TeamID     int        `json:"team_id" binding:"required"`
+	Title      string     `json:"title,omitempty"`
+	Status     string     `json:"status"` // active, completed
+	ClosesAt   *time.Time `json:"closes_at,omitempty"`
+	ScheduleID *int       `json:"schedule_id,omitempty"`
+	CreatedBy  int        `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// SurveyActivity summarises the active surveys: how many there are, how many
+// team members they were sent to and how many of those answered every
+// question.
+type SurveyActivity struct {
+	ActiveSurveys int
+	Members       int
+	Completed     int
+}
+
+// CompletionRate is Completed over Members, or 0 without members.
+func (a SurveyActivity) CompletionRate() float64 {
+	if a.Members == 0 {
+		return 0
+	}
+	return float64(a.Completed) / float64(a.Members)
 }
 
 type SurveyQuestion struct {
@@ -33,7 +54,23 @@ type SurveyOption struct {
 }
 
 type CreateSurveyInput struct {
-	TeamID int `json:"team_id" binding:"required"`
+	TeamID int    `json:"team_id" binding:"required"`
+	Title  string `json:"title" binding:"max=255"`
+}
+
+const (
+	SurveyStatusActive    = "active"
+	SurveyStatusCompleted = "completed"
+)
+
+// UpdateSurveyInput is a partial update; nil fields are left unchanged.
+type UpdateSurveyInput struct {
+	Status *string `json:"status"`
+}
+
+// ReplaceSurveyInput is the full representation accepted by PUT.
+type ReplaceSurveyInput struct {
+	Status string `json:"status" binding:"required"`
 }
 
 type CreateSurveyResponseInput struct {
@@ -41,3 +78,15 @@ type CreateSurveyResponseInput struct {
 	QuestionID int `json:"question_id" binding:"required"`
 	OptionID   int `json:"option_id" binding:"required"`
 }
+
+// SurveyAnswer is one answer of a SubmitSurveyResponsesInput.
+type SurveyAnswer struct {
+	QuestionID int `json:"question_id" binding:"required"`
+	OptionID   int `json:"option_id" binding:"required"`
+}
+
+// SubmitSurveyResponsesInput submits several answers to a survey at once;
+// they are stored all together or not at all.
+type SubmitSurveyResponsesInput struct {
+	Answers []SurveyAnswer `json:"answers" binding:"required,min=1,dive"`
+}
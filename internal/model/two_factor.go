@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// TwoFactorSecret is the TOTP state stored for a user. ChallengeVersion is
+// the version a login challenge token must carry to be accepted.
+type TwoFactorSecret struct {
+	UserID           int
+	Secret           string
+	Enabled          bool
+	LastStep         int64
+	LockedUntil      *time.Time
+	ChallengeVersion int
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorPolicy struct {
+	Role      string    `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorVerifyInput struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorPolicyInput struct {
+	Role     string `json:"role" binding:"required"`
+	Required *bool  `json:"required" binding:"required"`
+}
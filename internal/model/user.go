@@ -1,21 +1,33 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type User struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"`
-	Name      string    `json:"name"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               int       `json:"id"`
+	Email            string    `json:"email"`
+	Password         string    `json:"-"`
+	Name             string    `json:"name"`
+	Role             string    `json:"role"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// NormalizeEmail is the form emails are stored and looked up in. Emails are
+// case-insensitive: users.email is unique on LOWER(email).
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
+// SignUpInput is a self-registration. It carries no role: every registered
+// user gets UserRoleTeam, and admins are created with the create-admin
+// command.
 type SignUpInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
 	Name     string `json:"name" binding:"required"`
-	Role     string `json:"role" binding:"required"`
 }
 
 type SignInInput struct {
@@ -24,6 +36,76 @@ type SignInInput struct {
 	Role     string `json:"role"`
 }
 
+// Requester identifies the authenticated user performing a request, for
+// permission checks in the service layer.
+type Requester struct {
+	UserID int
+	Role   string
+}
+
+type UpdateProfileInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailChangeRequest is a pending email change confirmed by a token sent to
+// the new address.
+type EmailChangeRequest struct {
+	UserID    int
+	NewEmail  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type CompanyMembership struct {
+	CompanyID int    `json:"company_id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+}
+
+type TeamMembership struct {
+	TeamID    int    `json:"team_id"`
+	CompanyID int    `json:"company_id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+}
+
+type UserMemberships struct {
+	Companies []CompanyMembership `json:"companies"`
+	Teams     []TeamMembership    `json:"teams"`
+}
+
+// SignInResult is returned by a password login. When the user has two-factor
+// authentication enabled only ChallengeToken is set and must be exchanged for
+// an access token via the second step.
+type SignInResult struct {
+	Token                  string `json:"token,omitempty"`
+	ChallengeToken         string `json:"challenge_token,omitempty"`
+	TwoFactorRequired      bool   `json:"two_factor_required,omitempty"`
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"`
+}
+
+// AccessClaims identify the user an access token was issued to.
+type AccessClaims struct {
+	UserID int
+	Role   string
+	// TwoFactorSetupRequired limits the token to the enrollment endpoints.
+	TwoFactorSetupRequired bool
+}
+
 type UserRole string
 
 const (
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEventTypes are the event types a subscription may filter on. There is
+// no event for generated recommendations because the service has none yet,
+// and company.created happens before any subscription can exist.
+var WebhookEventTypes = []string{
+	EventSurveyOpened, EventSurveyClosed, EventSurveyAnswered, EventTeamCreated,
+	EventCompanyDeleted, EventCompanyRestored, EventCompanyArchived, EventCompanyUnarchived,
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription sends the company's events of the listed types to URL.
+// Secret is only returned when the subscription is created.
+type WebhookSubscription struct {
+	ID        int       `json:"id"`
+	CompanyID int       `json:"company_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedBy int       `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateWebhookInput struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events" binding:"required,min=1"`
+}
+
+// UpdateWebhookInput is a partial update; nil fields are left unchanged.
+type UpdateWebhookInput struct {
+	URL    *string  `json:"url" binding:"omitempty,url"`
+	Events []string `json:"events" binding:"omitempty,min=1"`
+	Active *bool    `json:"active"`
+}
+
+// WebhookDelivery is one attempt series of sending an event to a
+// subscription.
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	SubscriptionID int             `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+
+	// URL and Secret come from the subscription when a delivery is claimed
+	// for sending.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookAttempt is the outcome of sending a claimed delivery.
+type WebhookAttempt struct {
+	DeliveryID    int
+	Status        string
+	StatusCode    *int
+	Error         *string
+	NextAttemptAt time.Time
+}
@@ -0,0 +1,94 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/oidc"
+	"github.com/teamdetected/internal/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:8080/api/v1/auth/oidc/callback"
+
+// authorize follows the login redirect against the mock provider and returns
+// the authorization code and state it sends back to the redirect URL.
+func authorize(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestClient_AuthorizationCodeFlow(t *testing.T) {
+	server := oidctest.NewServer("teamdetector", "secret")
+	defer server.Close()
+
+	server.SetUser(oidctest.User{Subject: "42", Email: "Jane@Example.com", EmailVerified: true, Name: "Jane"})
+
+	ctx := context.Background()
+	provider, err := oidc.Discover(ctx, nil, server.Issuer())
+	require.NoError(t, err)
+
+	client := oidc.NewClient(provider, oidc.Config{
+		ClientID:     "teamdetector",
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+	})
+
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+
+	code, state := authorize(t, client.AuthCodeURL("state-1", "nonce-1", oidc.CodeChallengeS256(verifier)))
+	assert.Equal(t, "state-1", state)
+
+	token, err := client.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, oidc.Claims{
+		Issuer:        server.Issuer(),
+		Subject:       "42",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane",
+	}, claims)
+}
+
+func TestClient_RejectsWrongVerifierAndNonce(t *testing.T) {
+	server := oidctest.NewServer("teamdetector", "")
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := oidc.Discover(ctx, nil, server.Issuer())
+	require.NoError(t, err)
+
+	client := oidc.NewClient(provider, oidc.Config{ClientID: "teamdetector", RedirectURL: redirectURL})
+
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+
+	code, _ := authorize(t, client.AuthCodeURL("state", "nonce", oidc.CodeChallengeS256(verifier)))
+	_, err = client.Exchange(ctx, code, "wrong-verifier")
+	assert.Error(t, err)
+
+	code, _ = authorize(t, client.AuthCodeURL("state", "nonce", oidc.CodeChallengeS256(verifier)))
+	token, err := client.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	_, err = client.VerifyIDToken(ctx, token.IDToken, "other-nonce")
+	assert.ErrorIs(t, err, oidc.ErrNonceMismatch)
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/teamdetected/internal/model"
+)
+
+type APIKeyPostgres struct {
+	db pgDB
+}
+
+func NewAPIKeyPostgres(db *sql.DB) *APIKeyPostgres {
+	return &APIKeyPostgres{db: pgDB{db: db}}
+}
+
+func (r *APIKeyPostgres) CreateAPIKey(ctx context.Context, key model.APIKey) (int, error) {
+	var id int
+	query := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	err := r.db.QueryRow(ctx, query, key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *APIKeyPostgres) GetAPIKeysByUserID(ctx context.Context, userID int) ([]model.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, key_hash, scopes, last_used_at, expires_at, revoked_at, created_at
+              FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *APIKeyPostgres) GetAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	query := `SELECT k.id, k.user_id, k.name, k.prefix, k.key_hash, k.scopes, k.last_used_at, k.expires_at, k.revoked_at, k.created_at
+              FROM api_keys k JOIN users u ON u.id = k.user_id AND u.deleted_at IS NULL
+              WHERE k.prefix = $1`
+
+	return scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+}
+
+func (r *APIKeyPostgres) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errNoRows
+	}
+
+	return nil
+}
+
+// RevokeAPIKeyByID revokes a key whoever owns it.
+func (r *APIKeyPostgres) RevokeAPIKeyByID(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errNoRows
+	}
+
+	return nil
+}
+
+// ReplaceAPIKey revokes an active key and, in the same statement, issues a
+// key with the new prefix and hash for the same user, name, scopes and
+// expiry. It returns the new key.
+func (r *APIKeyPostgres) ReplaceAPIKey(ctx context.Context, id int, prefix, keyHash string) (model.APIKey, error) {
+	query := `WITH revoked AS (
+                  UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+                  RETURNING user_id, name, scopes, expires_at
+              )
+              INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+              SELECT user_id, name, $2, $3, scopes, expires_at FROM revoked
+              RETURNING id, user_id, name, prefix, key_hash, scopes, last_used_at, expires_at, revoked_at, created_at`
+
+	return scanAPIKey(r.db.QueryRow(ctx, query, id, prefix, keyHash))
+}
+
+// TouchAPIKey records that a key was used. The timestamp is only written once
+// a minute so busy scripts do not turn every request into a write.
+func (r *APIKeyPostgres) TouchAPIKey(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET last_used_at = NOW()
+              WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (model.APIKey, error) {
+	var key model.APIKey
+	err := row.Scan(
+		&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes),
+		&key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt, &key.CreatedAt,
+	)
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	return key, nil
+}
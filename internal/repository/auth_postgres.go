@@ -1,21 +1,23 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 
+	"github.com/lib/pq"
 	"github.com/teamdetected/internal/model"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthPostgres struct {
-	db *sql.DB
+	db pgDB
 }
 
 func NewAuthPostgres(db *sql.DB) *AuthPostgres {
-	return &AuthPostgres{db: db}
+	return &AuthPostgres{db: pgDB{db: db}}
 }
 
-func (r *AuthPostgres) CreateUser(user model.User) (int, error) {
+func (r *AuthPostgres) CreateUser(ctx context.Context, user model.User) (int, error) {
 	var id int
 	query := `INSERT INTO users (email, password_hash, name, role) VALUES ($1, $2, $3, $4) RETURNING id`
 
@@ -24,7 +26,7 @@ func (r *AuthPostgres) CreateUser(user model.User) (int, error) {
 		return 0, err
 	}
 
-	err = r.db.QueryRow(query, user.Email, string(hashedPassword), user.Name, user.Role).Scan(&id)
+	err = r.db.QueryRow(ctx, query, model.NormalizeEmail(user.Email), string(hashedPassword), user.Name, user.Role).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
@@ -32,11 +34,14 @@ func (r *AuthPostgres) CreateUser(user model.User) (int, error) {
 	return id, nil
 }
 
-func (r *AuthPostgres) GetUser(email, password string) (model.User, error) {
+func (r *AuthPostgres) GetUser(ctx context.Context, email, password string) (model.User, error) {
 	var user model.User
-	query := `SELECT id, email, password_hash, name, created_at FROM users WHERE email = $1`
+	query := `SELECT id, email, password_hash, name, role, totp_enabled, created_at
+              FROM users WHERE LOWER(email) = $1 AND deleted_at IS NULL`
 
-	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Password, &user.Name, &user.CreatedAt)
+	err := r.db.QueryRow(ctx, query, model.NormalizeEmail(email)).Scan(
+		&user.ID, &user.Email, &user.Password, &user.Name, &user.Role, &user.TwoFactorEnabled, &user.CreatedAt,
+	)
 	if err != nil {
 		return model.User{}, err
 	}
@@ -49,8 +54,299 @@ func (r *AuthPostgres) GetUser(email, password string) (model.User, error) {
 	return user, nil
 }
 
-func (r *AuthPostgres) DeleteUser(id int) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.Exec(query, id)
+func (r *AuthPostgres) GetUserByID(ctx context.Context, id int) (model.User, error) {
+	var user model.User
+	query := `SELECT id, email, name, role, totp_enabled, created_at FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&user.ID, &user.Email, &user.Name, &user.Role, &user.TwoFactorEnabled, &user.CreatedAt,
+	)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+func (r *AuthPostgres) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	var user model.User
+	query := `SELECT id, email, name, role, totp_enabled, created_at
+              FROM users WHERE LOWER(email) = $1 AND deleted_at IS NULL`
+
+	err := r.db.QueryRow(ctx, query, model.NormalizeEmail(email)).Scan(
+		&user.ID, &user.Email, &user.Name, &user.Role, &user.TwoFactorEnabled, &user.CreatedAt,
+	)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+func (r *AuthPostgres) VerifyPassword(ctx context.Context, id int, password string) error {
+	var passwordHash string
+	query := `SELECT password_hash FROM users WHERE id = $1`
+
+	if err := r.db.QueryRow(ctx, query, id).Scan(&passwordHash); err != nil {
+		return err
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
+}
+
+func (r *AuthPostgres) UpdateUserName(ctx context.Context, id int, name string) error {
+	query := `UPDATE users SET name = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, name)
 	return err
 }
+
+func (r *AuthPostgres) GetNotificationSettings(ctx context.Context, id int) (model.NotificationSettings, error) {
+	var settings model.NotificationSettings
+	query := `SELECT survey_reminders FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+	err := r.db.QueryRow(ctx, query, id).Scan(&settings.SurveyReminders)
+	return settings, err
+}
+
+func (r *AuthPostgres) UpdateNotificationSettings(ctx context.Context, id int, settings model.NotificationSettings) error {
+	query := `UPDATE users SET survey_reminders = $2 WHERE id = $1 AND deleted_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id, settings.SurveyReminders)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+func (r *AuthPostgres) UpdatePassword(ctx context.Context, id int, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`
+	_, err = r.db.Exec(ctx, query, id, string(hashedPassword))
+	return err
+}
+
+// CreateEmailChangeRequest replaces any pending email change of the user.
+func (r *AuthPostgres) CreateEmailChangeRequest(ctx context.Context, request model.EmailChangeRequest) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM email_change_requests WHERE user_id = $1`, request.UserID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, request.UserID, model.NormalizeEmail(request.NewEmail), request.TokenHash, request.ExpiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConfirmEmailChange applies the pending change identified by tokenHash and
+// returns the user id. It returns sql.ErrNoRows for unknown or expired tokens.
+func (r *AuthPostgres) ConfirmEmailChange(ctx context.Context, tokenHash string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var newEmail string
+	query := `DELETE FROM email_change_requests WHERE token_hash = $1 AND expires_at > NOW()
+              RETURNING user_id, new_email`
+	if err := tx.QueryRow(ctx, query, tokenHash).Scan(&userID, &newEmail); err != nil {
+		return 0, err
+	}
+
+	query = `UPDATE users SET email = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, userID, newEmail); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM email_change_requests WHERE user_id = $1`, userID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// GetUserMemberships lists the companies the user is a member of and the
+// teams the user created or was added to.
+func (r *AuthPostgres) GetUserMemberships(ctx context.Context, userID int) (model.UserMemberships, error) {
+	memberships := model.UserMemberships{
+		Companies: []model.CompanyMembership{},
+		Teams:     []model.TeamMembership{},
+	}
+
+	query := `SELECT c.id, c.name, cm.role
+              FROM companies c
+              JOIN company_members cm ON cm.company_id = c.id AND cm.user_id = $1
+              WHERE c.deleted_at IS NULL
+              ORDER BY c.name`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return model.UserMemberships{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var membership model.CompanyMembership
+		if err := rows.Scan(&membership.CompanyID, &membership.Name, &membership.Role); err != nil {
+			return model.UserMemberships{}, err
+		}
+		memberships.Companies = append(memberships.Companies, membership)
+	}
+	if err := rows.Err(); err != nil {
+		return model.UserMemberships{}, err
+	}
+
+	query = `SELECT t.id, t.company_id, t.name, COALESCE(tm.role, 'owner')
+             FROM teams t
+             LEFT JOIN team_members tm ON tm.team_id = t.id AND tm.user_id = $1
+             WHERE t.created_by = $1 OR tm.user_id IS NOT NULL
+             ORDER BY t.name`
+	teamRows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return model.UserMemberships{}, err
+	}
+	defer teamRows.Close()
+
+	for teamRows.Next() {
+		var membership model.TeamMembership
+		if err := teamRows.Scan(&membership.TeamID, &membership.CompanyID, &membership.Name, &membership.Role); err != nil {
+			return model.UserMemberships{}, err
+		}
+		memberships.Teams = append(memberships.Teams, membership)
+	}
+
+	return memberships, teamRows.Err()
+}
+
+// DeleteUser anonymizes the user instead of removing the row, so their survey
+// responses keep counting towards team results and foreign keys stay valid.
+// Companies and teams they created are handed over to transferTo when it is
+// not zero and otherwise stay attached to the anonymized account. A user who
+// owns companies, deleted ones included, can only be deleted with a
+// transferTo, since a company without an owner cannot be managed.
+func (r *AuthPostgres) DeleteUser(ctx context.Context, id, transferTo int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if transferTo == 0 {
+		var ownsCompanies bool
+		query := `SELECT EXISTS (SELECT 1 FROM company_members WHERE user_id = $1 AND role = 'owner')`
+		if err := tx.QueryRow(ctx, query, id).Scan(&ownsCompanies); err != nil {
+			return err
+		}
+		if ownsCompanies {
+			return model.ErrOwnsCompanies
+		}
+	} else {
+		// transferTo takes over owned companies and the companies and teams
+		// the user created, so it has to be a member of each of them already.
+		var outsider bool
+		query := `SELECT EXISTS (
+                      SELECT 1 FROM (
+                          SELECT company_id FROM company_members WHERE user_id = $1 AND role = 'owner'
+                          UNION SELECT id FROM companies WHERE created_by = $1
+                          UNION SELECT company_id FROM teams WHERE created_by = $1
+                      ) affected
+                      WHERE NOT EXISTS (
+                          SELECT 1 FROM company_members cm
+                          JOIN users u ON u.id = cm.user_id AND u.deleted_at IS NULL
+                          WHERE cm.company_id = affected.company_id AND cm.user_id = $2))`
+		if err := tx.QueryRow(ctx, query, id, transferTo).Scan(&outsider); err != nil {
+			return err
+		}
+		if outsider {
+			return model.ErrInvalidInput
+		}
+	}
+
+	query := `UPDATE users SET
+                  email = 'deleted-user-' || id || '@deleted.invalid',
+                  name = 'Deleted user',
+                  password_hash = '',
+                  totp_secret = NULL,
+                  totp_enabled = FALSE,
+                  deleted_at = NOW(),
+                  updated_at = NOW()
+              WHERE id = $1 AND deleted_at IS NULL`
+	result, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errNoRows
+	}
+
+	if transferTo != 0 {
+		// Owned companies go to transferTo. The old owner rows are removed
+		// first because a company can only have one owner.
+		query = `DELETE FROM company_members WHERE user_id = $1 AND role = 'owner' RETURNING company_id`
+		rows, err := tx.Query(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		var owned []int
+		for rows.Next() {
+			var companyID int
+			if err := rows.Scan(&companyID); err != nil {
+				rows.Close()
+				return err
+			}
+			owned = append(owned, companyID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		query = `INSERT INTO company_members (company_id, user_id, role) SELECT unnest($1::int[]), $2, 'owner'
+                 ON CONFLICT (company_id, user_id) DO UPDATE SET role = 'owner'`
+		if _, err := tx.Exec(ctx, query, pq.Array(owned), transferTo); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE companies SET created_by = $2, updated_at = NOW() WHERE created_by = $1`, id, transferTo); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE teams SET created_by = $2, updated_at = NOW() WHERE created_by = $1`, id, transferTo); err != nil {
+			return err
+		}
+	}
+
+	cleanup := []string{
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM user_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM email_change_requests WHERE user_id = $1`,
+		`DELETE FROM company_members WHERE user_id = $1`,
+		`DELETE FROM team_members WHERE user_id = $1`,
+		`UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`,
+	}
+	for _, query := range cleanup {
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/model"
+)
+
+func TestAuthPostgres_CaseInsensitiveEmail(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	r := NewAuthPostgres(db)
+
+	email := fmt.Sprintf("Case-%d@Example.com", time.Now().UnixNano())
+	id, err := r.CreateUser(ctx, model.User{Email: email, Password: "secret-password", Name: "Case", Role: "team"})
+	require.NoError(t, err)
+
+	user, err := r.GetUserByEmail(ctx, model.NormalizeEmail(email))
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, model.NormalizeEmail(email), user.Email)
+
+	_, err = r.GetUser(ctx, email, "secret-password")
+	assert.NoError(t, err)
+
+	_, err = r.CreateUser(ctx, model.User{Email: model.NormalizeEmail(email), Password: "secret-password", Name: "Case", Role: "team"})
+	assert.ErrorIs(t, err, model.ErrConflict)
+}
+
+func TestAuthPostgres_DeleteUser_TransferTo(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	r := NewAuthPostgres(db)
+	companies := NewCompanyPostgres(db)
+
+	newUser := func(name string) int {
+		email := fmt.Sprintf("%s-%d@example.com", name, time.Now().UnixNano())
+		id, err := r.CreateUser(ctx, model.User{Email: email, Password: "secret-password", Name: name, Role: "team"})
+		require.NoError(t, err)
+		return id
+	}
+	owner, member, outsider := newUser("owner"), newUser("member"), newUser("outsider")
+
+	companyID, err := companies.CreateCompany(ctx, model.Company{Name: "Transfer", CreatedBy: owner})
+	require.NoError(t, err)
+	require.NoError(t, companies.SetCompanyMember(ctx, companyID, member, model.CompanyRoleViewer))
+
+	assert.ErrorIs(t, r.DeleteUser(ctx, owner, outsider), model.ErrInvalidInput)
+
+	require.NoError(t, r.DeleteUser(ctx, owner, member))
+	role, err := companies.GetCompanyMemberRole(ctx, companyID, member)
+	require.NoError(t, err)
+	assert.Equal(t, model.CompanyRoleOwner, role)
+}
@@ -1,43 +1,178 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/teamdetected/internal/model"
 )
 
 type CompanyPostgres struct {
-	db *sql.DB
+	db pgDB
 }
 
 func NewCompanyPostgres(db *sql.DB) *CompanyPostgres {
-	return &CompanyPostgres{db: db}
+	return &CompanyPostgres{db: pgDB{db: db}}
 }
 
-func (r *CompanyPostgres) CreateCompany(company model.Company) (int, error) {
-	var id int
-	query := `INSERT INTO companies (name, description, created_by) VALUES ($1, $2, $3) RETURNING id`
+// CreateCompany inserts the company, makes its creator the owner and records
+// a company.created event.
+func (r *CompanyPostgres) CreateCompany(ctx context.Context, company model.Company) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
-	err := r.db.QueryRow(query, company.Name, company.Description, company.CreatedBy).Scan(&id)
+	query := `INSERT INTO companies (name, description, created_by) VALUES ($1, $2, $3)
+              RETURNING id, name, description, created_by, created_at, updated_at, archived_at`
+	created, err := scanCompany(tx.QueryRow(ctx, query, company.Name, company.Description, company.CreatedBy))
 	if err != nil {
 		return 0, err
 	}
 
-	return id, nil
+	query = `INSERT INTO company_members (company_id, user_id, role) VALUES ($1, $2, 'owner')`
+	if _, err := tx.Exec(ctx, query, created.ID, company.CreatedBy); err != nil {
+		return 0, err
+	}
+
+	if err := insertCompanyEvent(ctx, tx, model.EventCompanyCreated, created.ID, created); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return created.ID, nil
 }
 
-func (r *CompanyPostgres) GetCompanyByID(id int) (model.Company, error) {
-	var company model.Company
-	query := `SELECT id, name, description, created_by, created_at, updated_at FROM companies WHERE id = $1`
+// companyWithStats selects a company with its summary counters. Each counter
+// is a LATERAL subquery evaluated once per returned company, so a page of
+// companies costs a single round trip. Callers append a WHERE clause on the
+// companies table, aliased c.
+const companyWithStats = `SELECT c.id, c.name, c.description, c.created_by, c.created_at, c.updated_at, c.archived_at,
+           team_stats.teams_count, member_stats.employees_count, survey_stats.active_surveys_count,
+           latest_survey.completion_rate
+    FROM companies c
+    CROSS JOIN LATERAL (
+        SELECT COUNT(*) AS teams_count FROM teams t
+        WHERE t.company_id = c.id AND t.deleted_at IS NULL AND t.archived_at IS NULL
+    ) team_stats
+    CROSS JOIN LATERAL (
+        SELECT COUNT(DISTINCT tm.user_id) AS employees_count FROM team_members tm
+        JOIN teams t ON t.id = tm.team_id
+        JOIN users u ON u.id = tm.user_id
+        WHERE t.company_id = c.id AND t.deleted_at IS NULL AND t.archived_at IS NULL
+          AND u.deleted_at IS NULL
+    ) member_stats
+    CROSS JOIN LATERAL (
+        SELECT COUNT(*) AS active_surveys_count FROM surveys s
+        JOIN teams t ON t.id = s.team_id
+        WHERE t.company_id = c.id AND t.deleted_at IS NULL
+          AND s.deleted_at IS NULL AND s.status = 'active'
+    ) survey_stats
+    LEFT JOIN LATERAL (
+        SELECT LEAST(completed.count, members.count)::float8 / NULLIF(members.count, 0) AS completion_rate
+        FROM (
+            SELECT s.id, s.team_id FROM surveys s
+            JOIN teams t ON t.id = s.team_id
+            WHERE t.company_id = c.id AND t.deleted_at IS NULL AND s.deleted_at IS NULL
+            ORDER BY s.created_at DESC, s.id DESC
+            LIMIT 1
+        ) s
+        CROSS JOIN LATERAL (` + surveyMembers + `) members
+        CROSS JOIN LATERAL (` + surveyCompleted + `) completed
+    ) latest_survey ON TRUE
+    `
 
-	err := r.db.QueryRow(query, id).Scan(
-		&company.ID,
-		&company.Name,
-		&company.Description,
-		&company.CreatedBy,
-		&company.CreatedAt,
-		&company.UpdatedAt,
-	)
+func (r *CompanyPostgres) GetCompanyByID(ctx context.Context, id int) (model.Company, error) {
+	query := companyWithStats + `WHERE c.id = $1 AND c.deleted_at IS NULL`
+
+	return scanCompanyWithStats(r.db.QueryRow(ctx, query, id))
+}
+
+var companyListSpec = listSpec{
+	sorts: map[string]listColumn{
+		"id":         sortID,
+		"name":       sortName,
+		"created_at": sortCreatedAt,
+		"updated_at": sortUpdatedAt,
+	},
+	defaultSort:     "id",
+	archivable:      true,
+	createdAtColumn: "created_at",
+}
+
+// GetCompaniesByUserID lists the companies the user is a member of.
+func (r *CompanyPostgres) GetCompaniesByUserID(ctx context.Context, userID int, q model.ListQuery) (model.Page[model.Company], error) {
+	base := companyWithStats +
+		`WHERE c.id IN (SELECT company_id FROM company_members WHERE user_id = $1) AND c.deleted_at IS NULL`
+	return r.listCompanies(ctx, base, []interface{}{userID}, q)
+}
+
+// GetCompanies lists every company that is not deleted.
+func (r *CompanyPostgres) GetCompanies(ctx context.Context, q model.ListQuery) (model.Page[model.Company], error) {
+	return r.listCompanies(ctx, companyWithStats+`WHERE c.deleted_at IS NULL`, nil, q)
+}
+
+func (r *CompanyPostgres) listCompanies(ctx context.Context, base string, args []interface{}, q model.ListQuery) (model.Page[model.Company], error) {
+	list, err := buildListQuery(base, args, q, companyListSpec)
+	if err != nil {
+		return model.Page[model.Company]{}, err
+	}
+
+	rows, err := r.db.Query(ctx, list.query, list.args...)
+	if err != nil {
+		return model.Page[model.Company]{}, err
+	}
+	defer rows.Close()
+
+	var companies []model.Company
+	for rows.Next() {
+		company, err := scanCompanyWithStats(rows)
+		if err != nil {
+			return model.Page[model.Company]{}, err
+		}
+		companies = append(companies, company)
+	}
+	if err := rows.Err(); err != nil {
+		return model.Page[model.Company]{}, err
+	}
+
+	return page(companies, list, q, companySortKey), nil
+}
+
+func companySortKey(company model.Company, sort string) (string, int) {
+	switch sort {
+	case "name":
+		return company.Name, company.ID
+	case "created_at":
+		return cursorTime(company.CreatedAt), company.ID
+	case "updated_at":
+		return cursorTime(company.UpdatedAt), company.ID
+	default:
+		return strconv.Itoa(company.ID), company.ID
+	}
+}
+
+// UpdateCompany applies a partial update. When expectedUpdatedAt is set the
+// update only succeeds if the company was not modified since that version.
+func (r *CompanyPostgres) UpdateCompany(ctx context.Context, id int, input model.UpdateCompanyInput, expectedUpdatedAt *time.Time) (model.Company, error) {
+	query := `UPDATE companies SET
+                  name = COALESCE($2, name),
+                  description = COALESCE($3, description),
+                  updated_at = NOW()
+              WHERE id = $1 AND deleted_at IS NULL AND ($4::timestamptz IS NULL OR updated_at = $4)
+              RETURNING id, name, description, created_by, created_at, updated_at, archived_at`
+
+	company, err := scanCompany(r.db.QueryRow(ctx, query, id, input.Name, input.Description, expectedUpdatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Company{}, updateMissError(ctx, r.db, "companies", id)
+	}
 	if err != nil {
 		return model.Company{}, err
 	}
@@ -45,36 +180,323 @@ func (r *CompanyPostgres) GetCompanyByID(id int) (model.Company, error) {
 	return company, nil
 }
 
-func (r *CompanyPostgres) GetCompaniesByUserID(userID int) ([]model.Company, error) {
-	query := `SELECT id, name, description, created_by, created_at, updated_at FROM companies WHERE created_by = $1`
-	rows, err := r.db.Query(query, userID)
+// DeleteCompany soft-deletes the company together with its teams and their
+// surveys, stamping them all with the same deletion time so RestoreCompany
+// brings back exactly what was deleted with it. It records a company.deleted
+// event.
+func (r *CompanyPostgres) DeleteCompany(ctx context.Context, id int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		company   model.Company
+		deletedAt time.Time
+	)
+	query := `UPDATE companies SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL
+              RETURNING id, name, description, created_by, created_at, updated_at, archived_at, deleted_at`
+	err = tx.QueryRow(ctx, query, id).Scan(
+		&company.ID, &company.Name, &company.Description, &company.CreatedBy,
+		&company.CreatedAt, &company.UpdatedAt, &company.ArchivedAt, &deletedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	query = `UPDATE surveys SET deleted_at = $2
+             WHERE deleted_at IS NULL AND team_id IN (SELECT id FROM teams WHERE company_id = $1 AND deleted_at IS NULL)`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return err
+	}
+
+	query = `UPDATE teams SET deleted_at = $2 WHERE company_id = $1 AND deleted_at IS NULL`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return err
+	}
+
+	if err := insertCompanyEvent(ctx, tx, model.EventCompanyDeleted, id, company); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreCompany undoes DeleteCompany and records a company.restored event.
+func (r *CompanyPostgres) RestoreCompany(ctx context.Context, id int, deletedAfter time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deletedAt, err := lockDeleted(ctx, tx, "companies", id, deletedAfter)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE surveys SET deleted_at = NULL
+              WHERE deleted_at = $2 AND team_id IN (SELECT id FROM teams WHERE company_id = $1)`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return err
+	}
+
+	query = `UPDATE teams SET deleted_at = NULL WHERE company_id = $1 AND deleted_at = $2`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return err
+	}
+
+	query = `UPDATE companies SET deleted_at = NULL WHERE id = $1
+             RETURNING id, name, description, created_by, created_at, updated_at, archived_at`
+	company, err := scanCompany(tx.QueryRow(ctx, query, id))
+	if err != nil {
+		return err
+	}
+
+	if err := insertCompanyEvent(ctx, tx, model.EventCompanyRestored, id, company); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CompanyPostgres) ArchiveCompany(ctx context.Context, id int) error {
+	return r.setArchived(ctx, id, true, model.EventCompanyArchived)
+}
+
+func (r *CompanyPostgres) UnarchiveCompany(ctx context.Context, id int) error {
+	return r.setArchived(ctx, id, false, model.EventCompanyUnarchived)
+}
+
+// setArchived archives or unarchives the company like setArchived in
+// lifecycle.go does for teams and surveys, and records eventType.
+func (r *CompanyPostgres) setArchived(ctx context.Context, id int, archived bool, eventType string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE companies SET archived_at = CASE WHEN $2 THEN COALESCE(archived_at, NOW()) END
+              WHERE id = $1 AND deleted_at IS NULL
+              RETURNING id, name, description, created_by, created_at, updated_at, archived_at`
+	company, err := scanCompany(tx.QueryRow(ctx, query, id, archived))
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNoRows
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertCompanyEvent(ctx, tx, eventType, id, company); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeDeletedCompanies hard-deletes companies soft-deleted before the given
+// time. Their teams are purged first by PurgeDeletedTeams.
+func (r *CompanyPostgres) PurgeDeletedCompanies(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, r.db, "companies", before)
+}
+
+func (r *CompanyPostgres) GetCompanyMembers(ctx context.Context, companyID int) ([]model.CompanyMember, error) {
+	query := `SELECT u.id, u.email, u.name, cm.role, cm.created_at
+              FROM company_members cm
+              JOIN users u ON u.id = cm.user_id
+              WHERE cm.company_id = $1
+              ORDER BY cm.created_at, u.id`
+	rows, err := r.db.Query(ctx, query, companyID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var companies []model.Company
+	members := []model.CompanyMember{}
 	for rows.Next() {
-		var company model.Company
-		err := rows.Scan(
-			&company.ID,
-			&company.Name,
-			&company.Description,
-			&company.CreatedBy,
-			&company.CreatedAt,
-			&company.UpdatedAt,
-		)
-		if err != nil {
+		var member model.CompanyMember
+		if err := rows.Scan(&member.UserID, &member.Email, &member.Name, &member.Role, &member.CreatedAt); err != nil {
 			return nil, err
 		}
-		companies = append(companies, company)
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// GetCompanyMemberRole returns sql.ErrNoRows when the user is not a member of
+// the company or the company is deleted.
+func (r *CompanyPostgres) GetCompanyMemberRole(ctx context.Context, companyID, userID int) (model.CompanyRole, error) {
+	var role model.CompanyRole
+	query := `SELECT cm.role FROM company_members cm
+              JOIN companies c ON c.id = cm.company_id
+              WHERE cm.company_id = $1 AND cm.user_id = $2 AND c.deleted_at IS NULL`
+	err := r.db.QueryRow(ctx, query, companyID, userID).Scan(&role)
+	return role, err
+}
+
+// GetCompanyMemberRoleIncludingDeleted also finds roles in soft-deleted
+// companies, so their owners can restore them.
+func (r *CompanyPostgres) GetCompanyMemberRoleIncludingDeleted(ctx context.Context, companyID, userID int) (model.CompanyRole, error) {
+	var role model.CompanyRole
+	query := `SELECT role FROM company_members WHERE company_id = $1 AND user_id = $2`
+	err := r.db.QueryRow(ctx, query, companyID, userID).Scan(&role)
+	return role, err
+}
+
+// SetCompanyMember adds the user to the company or changes their role. It
+// never grants ownership; use TransferCompanyOwnership for that.
+func (r *CompanyPostgres) SetCompanyMember(ctx context.Context, companyID, userID int, role model.CompanyRole) error {
+	query := `INSERT INTO company_members (company_id, user_id, role) VALUES ($1, $2, $3)
+              ON CONFLICT (company_id, user_id) DO UPDATE SET role = EXCLUDED.role
+              WHERE company_members.role <> 'owner'`
+	_, err := r.db.Exec(ctx, query, companyID, userID, role)
+	return err
+}
+
+func (r *CompanyPostgres) RemoveCompanyMember(ctx context.Context, companyID, userID int) error {
+	query := `DELETE FROM company_members WHERE company_id = $1 AND user_id = $2 AND role <> 'owner'`
+	result, err := r.db.Exec(ctx, query, companyID, userID)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+// TransferCompanyOwnership makes newOwnerID the owner and demotes the
+// current owner to admin. newOwnerID must already be a member.
+func (r *CompanyPostgres) TransferCompanyOwnership(ctx context.Context, companyID, newOwnerID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT 1 FROM company_members WHERE company_id = $1 AND user_id = $2 FOR UPDATE`
+	var exists int
+	if err := tx.QueryRow(ctx, query, companyID, newOwnerID).Scan(&exists); err != nil {
+		return err
+	}
+
+	query = `UPDATE company_members SET role = 'admin' WHERE company_id = $1 AND role = 'owner'`
+	if _, err := tx.Exec(ctx, query, companyID); err != nil {
+		return err
 	}
 
-	return companies, nil
+	query = `UPDATE company_members SET role = 'owner' WHERE company_id = $1 AND user_id = $2`
+	if _, err := tx.Exec(ctx, query, companyID, newOwnerID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r *CompanyPostgres) DeleteCompany(id int) error {
-	query := `DELETE FROM companies WHERE id = $1`
-	_, err := r.db.Exec(query, id)
+// upsertCompanyInvitation stores an invitation; inviting an email again
+// replaces its pending invitation to the company.
+const upsertCompanyInvitation = `INSERT INTO company_invitations
+        (company_id, email, role, team_id, token_hash, invited_by, expires_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+    ON CONFLICT (company_id, email) DO UPDATE SET
+        role = EXCLUDED.role, team_id = EXCLUDED.team_id, token_hash = EXCLUDED.token_hash,
+        invited_by = EXCLUDED.invited_by, expires_at = EXCLUDED.expires_at, created_at = NOW()`
+
+func (r *CompanyPostgres) CreateCompanyInvitation(ctx context.Context, invitation model.CompanyInvitation) error {
+	_, err := r.db.Exec(ctx, upsertCompanyInvitation, invitation.CompanyID, model.NormalizeEmail(invitation.Email),
+		invitation.Role, invitation.TeamID, invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt)
 	return err
 }
+
+// AcceptCompanyInvitation adds the user to the company, and the team if any,
+// of the invitation with tokenHash and deletes the invitation. It returns
+// sql.ErrNoRows when the invitation is unknown or expired, its company is
+// deleted or it was sent to an address other than the user's.
+func (r *CompanyPostgres) AcceptCompanyInvitation(ctx context.Context, userID int, tokenHash string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var (
+		companyID int
+		role      model.CompanyRole
+		teamID    sql.NullInt64
+	)
+	query := `DELETE FROM company_invitations i
+              USING users u, companies c
+              WHERE i.token_hash = $1 AND i.expires_at > NOW()
+                AND u.id = $2 AND u.deleted_at IS NULL AND LOWER(u.email) = i.email
+                AND c.id = i.company_id AND c.deleted_at IS NULL
+              RETURNING i.company_id, i.role, i.team_id`
+	if err := tx.QueryRow(ctx, query, tokenHash, userID).Scan(&companyID, &role, &teamID); err != nil {
+		return 0, err
+	}
+
+	query = `INSERT INTO company_members (company_id, user_id, role) VALUES ($1, $2, $3)
+             ON CONFLICT (company_id, user_id) DO NOTHING`
+	if _, err := tx.Exec(ctx, query, companyID, userID, role); err != nil {
+		return 0, err
+	}
+
+	if teamID.Valid {
+		query = `INSERT INTO team_members (team_id, user_id)
+                 SELECT id, $2 FROM teams WHERE id = $1 AND deleted_at IS NULL
+                 ON CONFLICT (team_id, user_id) DO NOTHING`
+		if _, err := tx.Exec(ctx, query, teamID.Int64, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return companyID, nil
+}
+
+func scanCompany(row rowScanner) (model.Company, error) {
+	var company model.Company
+	err := row.Scan(
+		&company.ID,
+		&company.Name,
+		&company.Description,
+		&company.CreatedBy,
+		&company.CreatedAt,
+		&company.UpdatedAt,
+		&company.ArchivedAt,
+	)
+	if err != nil {
+		return model.Company{}, err
+	}
+
+	return company, nil
+}
+
+func scanCompanyWithStats(row rowScanner) (model.Company, error) {
+	var (
+		company model.Company
+		stats   model.CompanyStats
+	)
+	err := row.Scan(
+		&company.ID,
+		&company.Name,
+		&company.Description,
+		&company.CreatedBy,
+		&company.CreatedAt,
+		&company.UpdatedAt,
+		&company.ArchivedAt,
+		&stats.TeamsCount,
+		&stats.EmployeesCount,
+		&stats.ActiveSurveysCount,
+		&stats.LatestSurveyCompletionRate,
+	)
+	if err != nil {
+		return model.Company{}, err
+	}
+	company.Stats = &stats
+
+	return company, nil
+}
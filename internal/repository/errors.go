@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/teamdetected/internal/model"
+)
+
+// dbError is a driver error translated into a model sentinel. It unwraps to
+// both, so callers may keep checking sql.ErrNoRows while the HTTP layer only
+// needs to know about model errors. Error() never exposes driver text.
+type dbError struct {
+	sentinel error
+	cause    error
+}
+
+func (e *dbError) Error() string   { return e.sentinel.Error() }
+func (e *dbError) Unwrap() []error { return []error{e.sentinel, e.cause} }
+
+// errNoRows is returned where the repository itself decides a row is missing.
+var errNoRows error = &dbError{sentinel: model.ErrNotFound, cause: sql.ErrNoRows}
+
+// translateError maps sql.ErrNoRows, Postgres constraint violations and
+// timeouts to model sentinels and leaves any other error untouched.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNoRows
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &dbError{sentinel: model.ErrTimeout, cause: err}
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	var sentinel error
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		sentinel = model.ErrConflict
+	case "23503", "23502", "23514": // foreign_key, not_null, check
+		sentinel = model.ErrUnprocessable
+	case "22P02", "22001", "22003", "22007", "22008": // malformed or out of range values
+		sentinel = model.ErrInvalidInput
+	case "57014": // query_canceled, e.g. by statement_timeout
+		sentinel = model.ErrTimeout
+	default:
+		return err
+	}
+
+	return &dbError{sentinel: sentinel, cause: err}
+}
+
+// pgDB, pgTx and pgRow wrap database/sql so that every error leaving a query
+// goes through translateError and every statement is measured.
+
+// dbtx is what a query runs on: the pool, or the transaction of a unit of
+// work.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// pgDB runs queries on the pool unless it belongs to a unit of work started
+// by TxManager, in which case every query runs in that transaction.
+type pgDB struct {
+	db   *sql.DB
+	unit *unitOfWork
+}
+
+func (d pgDB) conn() dbtx {
+	if d.unit != nil {
+		return d.unit.tx
+	}
+	return d.db
+}
+
+func (d pgDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return runExec(ctx, d.conn(), query, args...)
+}
+
+func (d pgDB) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return runQuery(ctx, d.conn(), query, args...)
+}
+
+func (d pgDB) QueryRow(ctx context.Context, query string, args ...interface{}) pgRow {
+	return runQueryRow(ctx, d.conn(), query, args...)
+}
+
+// Begin starts a transaction, or a savepoint inside the unit of work, so
+// repository methods stay atomic on their own and compose into larger units.
+// Cancelling ctx rolls the transaction back.
+func (d pgDB) Begin(ctx context.Context) (pgTx, error) {
+	if d.unit != nil {
+		return d.unit.savepoint(ctx)
+	}
+	tx, err := d.db.BeginTx(ctx, nil)
+	return pgTx{tx: tx}, translateError(err)
+}
+
+// pgTx is a transaction or, inside a unit of work, a savepoint of its
+// transaction.
+type pgTx struct {
+	tx        *sql.Tx
+	savepoint *savepoint
+}
+
+type savepoint struct {
+	name string
+	done bool
+}
+
+func (t pgTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return runExec(ctx, t.tx, query, args...)
+}
+
+func (t pgTx) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return runQuery(ctx, t.tx, query, args...)
+}
+
+func (t pgTx) QueryRow(ctx context.Context, query string, args ...interface{}) pgRow {
+	return runQueryRow(ctx, t.tx, query, args...)
+}
+
+func (t pgTx) Commit() error {
+	if t.savepoint == nil {
+		return translateError(t.tx.Commit())
+	}
+	if t.savepoint.done {
+		return sql.ErrTxDone
+	}
+	t.savepoint.done = true
+	_, err := t.tx.Exec(`RELEASE SAVEPOINT ` + t.savepoint.name)
+	return translateError(err)
+}
+
+func (t pgTx) Rollback() error {
+	if t.savepoint == nil {
+		return t.tx.Rollback()
+	}
+	if t.savepoint.done {
+		return sql.ErrTxDone
+	}
+	t.savepoint.done = true
+	_, err := t.tx.Exec(`ROLLBACK TO SAVEPOINT ` + t.savepoint.name)
+	return err
+}
+
+type pgRow struct {
+	row *sql.Row
+}
+
+func (r pgRow) Scan(dest ...interface{}) error {
+	return translateError(r.row.Scan(dest...))
+}
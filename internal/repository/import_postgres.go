@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
+
+type ImportPostgres struct {
+	db pgDB
+}
+
+func NewImportPostgres(db *sql.DB) *ImportPostgres {
+	return &ImportPostgres{db: pgDB{db: db}}
+}
+
+// ImportCompanyStructure creates the missing teams of an import, adds
+// existing company members to their teams and invites everyone else, all in
+// one transaction. Existing teams are matched by name and keep their parent;
+// existing members keep their role. No user accounts are created or attached
+// here: invitees join when they accept. The transaction is rolled back for a
+// dry run or when a row fails, so the report of a dry run is exactly what
+// applying would do.
+func (r *ImportPostgres) ImportCompanyStructure(ctx context.Context, companyID, createdBy int, teams []model.ImportTeam, rows []model.ImportRow, invitationExpiresAt time.Time, dryRun bool) (model.ImportReport, error) {
+	report := model.ImportReport{DryRun: dryRun, Rows: len(rows), Invited: []string{}, Errors: []model.ImportError{}}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	var locked int
+	query := `SELECT id FROM companies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, companyID).Scan(&locked); err != nil {
+		return report, err
+	}
+
+	teamIDs := make(map[string]int, len(teams))
+	for _, team := range teams {
+		key := strings.ToLower(team.Name)
+
+		id, err := findTeam(ctx, tx, companyID, team.Name)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return report, err
+		}
+		if err == nil {
+			teamIDs[key] = id
+			continue
+		}
+
+		var parentID *int
+		if team.ParentTeam != "" {
+			id, ok := teamIDs[strings.ToLower(team.ParentTeam)]
+			if !ok {
+				id, err = findTeam(ctx, tx, companyID, team.ParentTeam)
+				if errors.Is(err, sql.ErrNoRows) {
+					report.Errors = append(report.Errors, model.ImportError{
+						Line: team.Line, Field: "parent_team", Message: "unknown parent team " + team.ParentTeam,
+					})
+					continue
+				}
+				if err != nil {
+					return report, err
+				}
+			}
+			parentID = &id
+		}
+
+		query := `INSERT INTO teams (name, company_id, parent_id, created_by) VALUES ($1, $2, $3, $4) RETURNING id`
+		if err := tx.QueryRow(ctx, query, team.Name, companyID, parentID, createdBy).Scan(&id); err != nil {
+			return report, err
+		}
+		teamIDs[key] = id
+		report.TeamsCreated++
+	}
+
+	for _, row := range rows {
+		teamID, hasTeam := 0, false
+		if row.Team != "" {
+			// A missing team failed to import and already has an error.
+			teamID, hasTeam = teamIDs[strings.ToLower(row.Team)]
+		}
+
+		var userID int
+		query := `SELECT u.id FROM users u
+                  JOIN company_members cm ON cm.user_id = u.id AND cm.company_id = $1
+                  WHERE LOWER(u.email) = $2 AND u.deleted_at IS NULL`
+		err := tx.QueryRow(ctx, query, companyID, row.Email).Scan(&userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			var invitedTeam *int
+			if hasTeam {
+				invitedTeam = &teamID
+			}
+			if _, err := tx.Exec(ctx, upsertCompanyInvitation, companyID, row.Email, row.Role, invitedTeam,
+				row.InvitationTokenHash, createdBy, invitationExpiresAt); err != nil {
+				return report, err
+			}
+			report.InvitationsCreated++
+			report.Invited = append(report.Invited, row.Email)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		if !hasTeam {
+			continue
+		}
+		query = `INSERT INTO team_members (team_id, user_id) VALUES ($1, $2)
+                 ON CONFLICT (team_id, user_id) DO NOTHING`
+		added, err := execAffected(ctx, tx, query, teamID, userID)
+		if err != nil {
+			return report, err
+		}
+		report.TeamMembersAdded += added
+	}
+
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return report, err
+	}
+	report.Applied = true
+
+	return report, nil
+}
+
+func findTeam(ctx context.Context, tx pgTx, companyID int, name string) (int, error) {
+	var id int
+	query := `SELECT id FROM teams WHERE company_id = $1 AND LOWER(name) = LOWER($2) AND deleted_at IS NULL
+              ORDER BY id LIMIT 1`
+	err := tx.QueryRow(ctx, query, companyID, name).Scan(&id)
+	return id, err
+}
+
+func execAffected(ctx context.Context, tx pgTx, query string, args ...interface{}) (int, error) {
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/teamdetected/internal/metrics"
+	"github.com/teamdetected/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// runExec, runQuery and runQueryRow run a statement for pgDB and pgTx: they
+// translate its error, record its latency and failure in the database
+// metrics and trace it as a child span of ctx. The span of a query covers
+// its execution, not reading the rows.
+func runExec(ctx context.Context, conn dbtx, query string, args ...interface{}) (sql.Result, error) {
+	ctx, finish := instrument(ctx, query)
+	result, err := conn.ExecContext(ctx, query, args...)
+	finish(err)
+	return result, translateError(err)
+}
+
+func runQuery(ctx context.Context, conn dbtx, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, finish := instrument(ctx, query)
+	rows, err := conn.QueryContext(ctx, query, args...)
+	finish(err)
+	return rows, translateError(err)
+}
+
+func runQueryRow(ctx context.Context, conn dbtx, query string, args ...interface{}) pgRow {
+	ctx, finish := instrument(ctx, query)
+	row := conn.QueryRowContext(ctx, query, args...)
+	// Err reports whether the statement failed; a missing row only shows up
+	// in Scan and is not a failure anyway.
+	finish(row.Err())
+	return pgRow{row: row}
+}
+
+// instrument starts measuring query; finish ends the measurement with the
+// statement's error.
+func instrument(ctx context.Context, query string) (context.Context, func(err error)) {
+	op := operation(query)
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation(op),
+	))
+	if span.IsRecording() {
+		// Arguments are never recorded, only the statement with its
+		// placeholders, its whitespace collapsed.
+		span.SetAttributes(semconv.DBStatement(strings.Join(strings.Fields(query), " ")))
+	}
+
+	return ctx, func(err error) {
+		failed := err != nil && !errors.Is(err, sql.ErrNoRows)
+		metrics.DBQueryDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+		if failed {
+			metrics.DBQueryErrors.WithLabelValues(op).Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "query failed")
+		}
+		span.End()
+	}
+}
+
+// operation is the SQL verb of query. Statements starting with WITH are
+// labelled by the verb of their main statement where it is easy to find.
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "OTHER"
+	}
+
+	verb := strings.ToUpper(fields[0])
+	if verb == "WITH" {
+		verb = "SELECT"
+		for _, field := range fields {
+			switch word := strings.ToUpper(field); word {
+			case "INSERT", "UPDATE", "DELETE":
+				verb = word
+			}
+		}
+	}
+
+	switch verb {
+	case "SELECT", "INSERT", "UPDATE", "DELETE":
+		return verb
+	}
+	return "OTHER"
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
+
+// Companies, teams and surveys share the same lifecycle columns: archived_at
+// hides a row from default listings and deleted_at soft-deletes it until the
+// retention window has passed. table is always a constant from this package.
+
+func setArchived(ctx context.Context, db pgDB, table string, id int, archived bool) error {
+	query := `UPDATE ` + table + ` SET archived_at = CASE WHEN $2 THEN COALESCE(archived_at, NOW()) END
+              WHERE id = $1 AND deleted_at IS NULL`
+	result, err := db.Exec(ctx, query, id, archived)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+// lockDeleted locks a soft-deleted row for restoring and returns its
+// deletion time. It fails with model.ErrRestoreWindowExpired when the row was
+// deleted before deletedAfter and with sql.ErrNoRows when it is not deleted.
+func lockDeleted(ctx context.Context, tx pgTx, table string, id int, deletedAfter time.Time) (time.Time, error) {
+	var deletedAt sql.NullTime
+	query := `SELECT deleted_at FROM ` + table + ` WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, id).Scan(&deletedAt); err != nil {
+		return time.Time{}, err
+	}
+	if !deletedAt.Valid {
+		return time.Time{}, errNoRows
+	}
+	if deletedAt.Time.Before(deletedAfter) {
+		return time.Time{}, model.ErrRestoreWindowExpired
+	}
+
+	return deletedAt.Time, nil
+}
+
+func purgeDeleted(ctx context.Context, db pgDB, table string, before time.Time) (int64, error) {
+	result, err := db.Exec(ctx, `DELETE FROM `+table+` WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errNoRows
+	}
+
+	return nil
+}
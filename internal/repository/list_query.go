@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
+
+// listColumn is a column a list can be sorted by. cast is the Postgres type
+// the cursor value is converted to when comparing.
+type listColumn struct {
+	expr string
+	cast string
+}
+
+var (
+	sortID        = listColumn{expr: "id", cast: "integer"}
+	sortName      = listColumn{expr: "name", cast: "text"}
+	sortStatus    = listColumn{expr: "status", cast: "text"}
+	sortCreatedAt = listColumn{expr: "created_at", cast: "timestamptz"}
+	sortUpdatedAt = listColumn{expr: "updated_at", cast: "timestamptz"}
+)
+
+// listSpec lists what a resource can be sorted and filtered by.
+type listSpec struct {
+	sorts           map[string]listColumn
+	defaultSort     string
+	status          bool
+	archivable      bool
+	createdAtColumn string
+}
+
+// listCursor is the decoded form of model.Page.NextCursor: the sort key of
+// the last row of the previous page, with the row id as a tie breaker.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// listSQL builds the keyset-paginated query for a list. base is a SELECT with
+// a WHERE clause whose placeholders are args; the filters, cursor, ordering
+// and limit are appended as further placeholders. One row more than the page
+// size is requested so the caller can tell whether another page exists.
+type listSQL struct {
+	query  string
+	args   []interface{}
+	sort   string
+	limit  int
+	column listColumn
+}
+
+func buildListQuery(base string, args []interface{}, q model.ListQuery, spec listSpec) (listSQL, error) {
+	sort := q.Sort
+	if sort == "" {
+		sort = spec.defaultSort
+	}
+	column, ok := spec.sorts[sort]
+	if !ok {
+		return listSQL{}, model.ErrInvalidInput
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = model.DefaultListLimit
+	}
+	if limit > model.MaxListLimit {
+		limit = model.MaxListLimit
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base)
+
+	next := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if spec.archivable && !q.IncludeArchived {
+		sb.WriteString(" AND archived_at IS NULL")
+	}
+	if spec.status && q.Status != "" {
+		sb.WriteString(" AND status = " + next(q.Status))
+	}
+	if q.CreatedAfter != nil {
+		sb.WriteString(" AND " + spec.createdAtColumn + " >= " + next(*q.CreatedAfter))
+	}
+	if q.CreatedBefore != nil {
+		sb.WriteString(" AND " + spec.createdAtColumn + " < " + next(*q.CreatedBefore))
+	}
+
+	op, dir := ">", "ASC"
+	if q.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeListCursor(q.Cursor)
+		if err != nil || cursor.Sort != sort || cursor.Desc != q.Desc {
+			return listSQL{}, model.ErrInvalidInput
+		}
+		sb.WriteString(" AND (" + column.expr + ", id) " + op +
+			" (" + next(cursor.Value) + "::" + column.cast + ", " + next(cursor.ID) + ")")
+	}
+
+	sb.WriteString(" ORDER BY " + column.expr + " " + dir + ", id " + dir)
+	sb.WriteString(" LIMIT " + next(limit+1))
+
+	return listSQL{query: sb.String(), args: args, sort: sort, limit: limit, column: column}, nil
+}
+
+// page trims the extra row fetched by buildListQuery and derives the cursor
+// of the next page from the last row kept. key returns a row's value for the
+// given sort field and its id.
+func page[T any](items []T, l listSQL, q model.ListQuery, key func(item T, sort string) (string, int)) model.Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) <= l.limit {
+		return model.Page[T]{Data: items}
+	}
+
+	items = items[:l.limit]
+	value, id := key(items[len(items)-1], l.sort)
+
+	return model.Page[T]{
+		Data:       items,
+		NextCursor: encodeListCursor(listCursor{Sort: l.sort, Desc: q.Desc, Value: value, ID: id}),
+	}
+}
+
+func encodeListCursor(cursor listCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeListCursor(value string) (listCursor, error) {
+	var cursor listCursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(raw, &cursor)
+	return cursor, err
+}
+
+func cursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
+
+type OutboxPostgres struct {
+	db pgDB
+}
+
+func NewOutboxPostgres(db *sql.DB) *OutboxPostgres {
+	return &OutboxPostgres{db: pgDB{db: db}}
+}
+
+// insertEvent records a domain event of the team in the outbox as part of
+// tx, so the event is stored if and only if the change that caused it is
+// committed.
+func insertEvent(ctx context.Context, tx pgTx, eventType string, teamID int, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO outbox_events (event_type, company_id, team_id, data)
+              SELECT $1, company_id, id, $3 FROM teams WHERE id = $2`
+	_, err = tx.Exec(ctx, query, eventType, teamID, string(raw))
+	return err
+}
+
+// insertCompanyEvent records a domain event of the company as a whole, not
+// of one of its teams.
+func insertCompanyEvent(ctx context.Context, tx pgTx, eventType string, companyID int, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO outbox_events (event_type, company_id, data) VALUES ($1, $2, $3)`
+	_, err = tx.Exec(ctx, query, eventType, companyID, string(raw))
+	return err
+}
+
+// ClaimOutboxEvents picks up to limit unpublished events due at now, oldest
+// first, counts the attempt and moves next_attempt_at by lease so an
+// instance that dies mid-relay only delays them.
+func (r *OutboxPostgres) ClaimOutboxEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.OutboxEvent, error) {
+	query := `UPDATE outbox_events SET attempts = attempts + 1, next_attempt_at = $2
+              WHERE id IN (
+                  SELECT id FROM outbox_events
+                  WHERE published_at IS NULL AND next_attempt_at <= $1
+                  ORDER BY id
+                  LIMIT $3
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING event_id, event_type, company_id, COALESCE(team_id, 0), data, occurred_at, attempts`
+
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.OutboxEvent
+	for rows.Next() {
+		var event model.OutboxEvent
+		err := rows.Scan(
+			&event.ID, &event.Type, &event.CompanyID, &event.TeamID, &event.Data, &event.OccurredAt, &event.Attempts,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *OutboxPostgres) MarkOutboxEventPublished(ctx context.Context, eventID string) error {
+	_, err := r.db.Exec(ctx, `UPDATE outbox_events SET published_at = NOW(), last_error = NULL WHERE event_id = $1`, eventID)
+	return err
+}
+
+// RecordOutboxFailure keeps the event queued until next.
+func (r *OutboxPostgres) RecordOutboxFailure(ctx context.Context, eventID, message string, next time.Time) error {
+	query := `UPDATE outbox_events SET last_error = $2, next_attempt_at = $3 WHERE event_id = $1`
+	_, err := r.db.Exec(ctx, query, eventID, message, next)
+	return err
+}
+
+// PurgePublishedOutboxEvents deletes events published before the given time.
+func (r *OutboxPostgres) PurgePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM outbox_events WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -1,29 +1,71 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
+	"net/url"
+	"strconv"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
-func NewPostgresDB() (*sql.DB, error) {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
+// Config describes the Postgres connection and its pool.
+type Config struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
 
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnectTimeout  time.Duration
+	// QueryTimeout makes Postgres abort any statement running longer; zero
+	// disables the limit. Queries are also cancelled with the context they
+	// were given.
+	QueryTimeout time.Duration
+}
+
+// NewPostgresDB opens the connection pool and checks the database is
+// reachable within cfg.ConnectTimeout.
+func NewPostgresDB(cfg Config) (*sql.DB, error) {
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	query.Set("statement_timeout", strconv.FormatInt(cfg.QueryTimeout.Milliseconds(), 10))
+	if cfg.ConnectTimeout > 0 {
+		query.Set("connect_timeout", strconv.Itoa(int(cfg.ConnectTimeout.Seconds())))
+	}
+
+	connStr := (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Path:     cfg.Name,
+		RawQuery: query.Encode(),
+	}).String()
 
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = db.Ping(); err != nil {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	ctx := context.Background()
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+	}
+
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, err
 	}
 
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/teamdetected/internal/model"
+)
+
+type ReminderPostgres struct {
+	db pgDB
+}
+
+func NewReminderPostgres(db *sql.DB) *ReminderPostgres {
+	return &ReminderPostgres{db: pgDB{db: db}}
+}
+
+// GetDueSurveyReminders returns, for every member of a team with an active
+// survey, the smallest reminder offset (in minutes before closes_at) that has
+// passed at now, provided the member has not answered every question, has
+// not opted out and was not reminded at that stage or a later one yet.
+func (r *ReminderPostgres) GetDueSurveyReminders(ctx context.Context, now time.Time, offsetMinutes []int) ([]model.SurveyReminder, error) {
+	query := `WITH questions AS (SELECT COUNT(*) AS total FROM survey_questions),
+              due AS (
+                  SELECT s.id AS survey_id, s.title, s.closes_at, u.id AS user_id, u.email, u.name,
+                         MIN(o.minutes) AS offset_minutes
+                  FROM surveys s
+                  JOIN team_members tm ON tm.team_id = s.team_id
+                  JOIN users u ON u.id = tm.user_id AND u.deleted_at IS NULL AND u.survey_reminders
+                  CROSS JOIN unnest($2::int[]) AS o(minutes)
+                  WHERE s.status = 'active' AND s.deleted_at IS NULL
+                    AND s.closes_at > $1 AND s.closes_at - make_interval(mins => o.minutes) <= $1
+                  GROUP BY s.id, s.title, s.closes_at, u.id, u.email, u.name
+              )
+              SELECT d.survey_id, d.title, d.closes_at, d.user_id, d.email, d.name, d.offset_minutes,
+                     answered.count, questions.total
+              FROM due d
+              CROSS JOIN questions
+              CROSS JOIN LATERAL (
+                  SELECT COUNT(DISTINCT r.question_id) AS count
+                  FROM survey_responses r
+                  WHERE r.survey_id = d.survey_id AND r.user_id = d.user_id
+              ) answered
+              WHERE answered.count < questions.total
+                AND NOT EXISTS (
+                    SELECT 1 FROM survey_reminders sr
+                    WHERE sr.survey_id = d.survey_id AND sr.user_id = d.user_id
+                      AND sr.offset_minutes <= d.offset_minutes
+                )
+              ORDER BY d.survey_id, d.user_id`
+
+	rows, err := r.db.Query(ctx, query, now, pq.Array(offsetMinutes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []model.SurveyReminder
+	for rows.Next() {
+		var reminder model.SurveyReminder
+		err := rows.Scan(
+			&reminder.SurveyID, &reminder.SurveyTitle, &reminder.ClosesAt, &reminder.UserID, &reminder.Email,
+			&reminder.Name, &reminder.OffsetMinutes, &reminder.Answered, &reminder.Questions,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// ClaimSurveyReminder records the reminder before it is sent. It returns false
+// when another instance already claimed it.
+func (r *ReminderPostgres) ClaimSurveyReminder(ctx context.Context, reminder model.SurveyReminder) (bool, error) {
+	query := `INSERT INTO survey_reminders (survey_id, user_id, offset_minutes) VALUES ($1, $2, $3)
+              ON CONFLICT DO NOTHING`
+	result, err := r.db.Exec(ctx, query, reminder.SurveyID, reminder.UserID, reminder.OffsetMinutes)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// ReleaseSurveyReminder forgets a claimed reminder that could not be sent so
+// the next run retries it.
+func (r *ReminderPostgres) ReleaseSurveyReminder(ctx context.Context, reminder model.SurveyReminder) error {
+	query := `DELETE FROM survey_reminders WHERE survey_id = $1 AND user_id = $2 AND offset_minutes = $3`
+	_, err := r.db.Exec(ctx, query, reminder.SurveyID, reminder.UserID, reminder.OffsetMinutes)
+	return err
+}
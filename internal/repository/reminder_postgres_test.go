@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/migrate"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/migrations"
+)
+
+// openTestDB connects to the empty database in TEST_DATABASE_URL and applies
+// the migrations. Tests that need Postgres are skipped without it.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrations.FS)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestReminderPostgres_GetDueSurveyReminders(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	insert := func(query string, args ...interface{}) int {
+		t.Helper()
+		var id int
+		require.NoError(t, db.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id))
+		return id
+	}
+	user := func(name string, reminders bool) int {
+		email := fmt.Sprintf("%s-%d@example.com", name, now.UnixNano())
+		return insert(`INSERT INTO users (email, password_hash, name, survey_reminders) VALUES ($1, '', $1, $2)`, email, reminders)
+	}
+
+	owner := user("owner", true)
+	subscribed := user("subscribed", true)
+	optedOut := user("opted-out", false)
+	reminded := user("reminded", true)
+
+	company := insert(`INSERT INTO companies (name, created_by) VALUES ('Reminders', $1)`, owner)
+	team := insert(`INSERT INTO teams (name, company_id, created_by) VALUES ('Reminders', $1, $2)`, company, owner)
+	for _, member := range []int{subscribed, optedOut, reminded} {
+		_, err := db.ExecContext(ctx, `INSERT INTO team_members (team_id, user_id) VALUES ($1, $2)`, team, member)
+		require.NoError(t, err)
+	}
+	survey := insert(`INSERT INTO surveys (team_id, created_by, title, closes_at) VALUES ($1, $2, 'Weekly', $3)`,
+		team, owner, now.Add(3*time.Hour))
+
+	r := NewReminderPostgres(db)
+	claimed, err := r.ClaimSurveyReminder(ctx, model.SurveyReminder{SurveyID: survey, UserID: reminded, OffsetMinutes: 240})
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	claimed, err = r.ClaimSurveyReminder(ctx, model.SurveyReminder{SurveyID: survey, UserID: reminded, OffsetMinutes: 240})
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	reminders, err := r.GetDueSurveyReminders(ctx, now, []int{2880, 240})
+	require.NoError(t, err)
+
+	var users []int
+	for _, reminder := range reminders {
+		if reminder.SurveyID == survey {
+			users = append(users, reminder.UserID)
+			assert.Equal(t, 240, reminder.OffsetMinutes)
+		}
+	}
+	assert.Equal(t, []int{subscribed}, users)
+
+	require.NoError(t, r.ReleaseSurveyReminder(ctx, model.SurveyReminder{SurveyID: survey, UserID: reminded, OffsetMinutes: 240}))
+
+	reminders, err = r.GetDueSurveyReminders(ctx, now, []int{2880, 240})
+	require.NoError(t, err)
+
+	users = nil
+	for _, reminder := range reminders {
+		if reminder.SurveyID == survey {
+			users = append(users, reminder.UserID)
+		}
+	}
+	assert.Equal(t, []int{subscribed, reminded}, users)
+}
@@ -1,43 +1,201 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
 	"github.com/teamdetected/internal/model"
 )
 
 type Repository struct {
+	Transactor
 	Authorization
+	TwoFactor
+	SSO
+	APIKey
 	Company
 	Team
 	Survey
+	Import
+	Schedule
+	Reminder
+	Webhook
+	Outbox
 }
 
 type Authorization interface {
-	CreateUser(user model.User) (int, error)
-	GetUser(email, password string) (model.User, error)
-	DeleteUser(id int) error
+	CreateUser(ctx context.Context, user model.User) (int, error)
+	GetUser(ctx context.Context, email, password string) (model.User, error)
+	GetUserByID(ctx context.Context, id int) (model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (model.User, error)
+	VerifyPassword(ctx context.Context, id int, password string) error
+	UpdateUserName(ctx context.Context, id int, name string) error
+	UpdatePassword(ctx context.Context, id int, password string) error
+	GetNotificationSettings(ctx context.Context, id int) (model.NotificationSettings, error)
+	UpdateNotificationSettings(ctx context.Context, id int, settings model.NotificationSettings) error
+	CreateEmailChangeRequest(ctx context.Context, request model.EmailChangeRequest) error
+	ConfirmEmailChange(ctx context.Context, tokenHash string) (int, error)
+	GetUserMemberships(ctx context.Context, userID int) (model.UserMemberships, error)
+	DeleteUser(ctx context.Context, id, transferTo int) error
+}
+
+type TwoFactor interface {
+	GetTwoFactorSecret(ctx context.Context, userID int) (model.TwoFactorSecret, error)
+	SetTwoFactorSecret(ctx context.Context, userID int, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	DisableTwoFactor(ctx context.Context, userID int) error
+	UpdateTwoFactorLastStep(ctx context.Context, userID int, step int64) (bool, error)
+	RecordTwoFactorFailure(ctx context.Context, userID, maxAttempts int, lockout time.Duration) (bool, error)
+	ResetTwoFactorFailures(ctx context.Context, userID int) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	GetTwoFactorPolicies(ctx context.Context) ([]model.TwoFactorPolicy, error)
+	IsTwoFactorRequired(ctx context.Context, role string) (bool, error)
+	SetTwoFactorPolicy(ctx context.Context, role string, required bool) error
+}
+
+type SSO interface {
+	GetSSOConfig(ctx context.Context, companyID int) (model.SSOConfig, error)
+	SaveSSOConfig(ctx context.Context, config model.SSOConfig) error
+	MarkSSODomainVerified(ctx context.Context, companyID int, domain string) error
+	CreateLoginState(ctx context.Context, state model.OIDCLoginState) error
+	ConsumeLoginState(ctx context.Context, state string) (model.OIDCLoginState, error)
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (model.User, error)
+	LinkIdentity(ctx context.Context, userID int, identity model.ExternalIdentity) error
+	ProvisionUser(ctx context.Context, user model.User, identity model.ExternalIdentity, companyID int, role model.CompanyRole) (int, error)
+	AddCompanyMember(ctx context.Context, companyID, userID int) error
+}
+
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, key model.APIKey) (int, error)
+	GetAPIKeysByUserID(ctx context.Context, userID int) ([]model.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id int) error
+	RevokeAPIKeyByID(ctx context.Context, id int) error
+	ReplaceAPIKey(ctx context.Context, id int, prefix, keyHash string) (model.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int) error
 }
 
 type Company interface {
-	CreateCompany(company model.Company) (int, error)
-	GetCompanyByID(id int) (model.Company, error)
-	GetCompaniesByUserID(userID int) ([]model.Company, error)
-	DeleteCompany(id int) error
+	CreateCompany(ctx context.Context, company model.Company) (int, error)
+	GetCompanyByID(ctx context.Context, id int) (model.Company, error)
+	GetCompaniesByUserID(ctx context.Context, userID int, query model.ListQuery) (model.Page[model.Company], error)
+	GetCompanies(ctx context.Context, query model.ListQuery) (model.Page[model.Company], error)
+	UpdateCompany(ctx context.Context, id int, input model.UpdateCompanyInput, expectedUpdatedAt *time.Time) (model.Company, error)
+	DeleteCompany(ctx context.Context, id int) error
+	RestoreCompany(ctx context.Context, id int, deletedAfter time.Time) error
+	ArchiveCompany(ctx context.Context, id int) error
+	UnarchiveCompany(ctx context.Context, id int) error
+	PurgeDeletedCompanies(ctx context.Context, before time.Time) (int64, error)
+	GetCompanyMembers(ctx context.Context, companyID int) ([]model.CompanyMember, error)
+	GetCompanyMemberRole(ctx context.Context, companyID, userID int) (model.CompanyRole, error)
+	GetCompanyMemberRoleIncludingDeleted(ctx context.Context, companyID, userID int) (model.CompanyRole, error)
+	SetCompanyMember(ctx context.Context, companyID, userID int, role model.CompanyRole) error
+	RemoveCompanyMember(ctx context.Context, companyID, userID int) error
+	TransferCompanyOwnership(ctx context.Context, companyID, newOwnerID int) error
+	CreateCompanyInvitation(ctx context.Context, invitation model.CompanyInvitation) error
+	AcceptCompanyInvitation(ctx context.Context, userID int, tokenHash string) (int, error)
 }
 
 type Team interface {
-	CreateTeam(team model.Team) (int, error)
-	GetTeamByID(id int) (model.Team, error)
-	GetTeamsByCompanyID(companyID int) ([]model.Team, error)
-	DeleteTeam(id int) error
+	CreateTeam(ctx context.Context, team model.Team) (int, error)
+	GetTeamByID(ctx context.Context, id int) (model.Team, error)
+	GetTeamCompanyID(ctx context.Context, id int) (int, error)
+	GetTeamsByCompanyID(ctx context.Context, companyID int, query model.ListQuery) (model.Page[model.Team], error)
+	UpdateTeam(ctx context.Context, id int, input model.UpdateTeamInput, expectedUpdatedAt *time.Time) (model.Team, error)
+	DeleteTeam(ctx context.Context, id int) error
+	RestoreTeam(ctx context.Context, id int, deletedAfter time.Time) error
+	ArchiveTeam(ctx context.Context, id int) error
+	UnarchiveTeam(ctx context.Context, id int) error
+	PurgeDeletedTeams(ctx context.Context, before time.Time) (int64, error)
+	GetTeamDescendants(ctx context.Context, id int) ([]model.Team, error)
+	SetTeamParent(ctx context.Context, id int, parentID *int, expectedUpdatedAt *time.Time) (model.Team, error)
+	GetTeamResults(ctx context.Context, id int, rollUp bool) (model.TeamResults, error)
+}
+
+type Schedule interface {
+	CreateSurveySchedule(ctx context.Context, schedule model.SurveySchedule) (model.SurveySchedule, error)
+	GetSurveyScheduleByID(ctx context.Context, id int) (model.SurveySchedule, error)
+	GetSurveySchedulesByTeamID(ctx context.Context, teamID int) ([]model.SurveySchedule, error)
+	UpdateSurveySchedule(ctx context.Context, schedule model.SurveySchedule) (model.SurveySchedule, error)
+	DeleteSurveySchedule(ctx context.Context, id int) error
+	RunDueSurveySchedules(ctx context.Context, now time.Time, plan func(model.SurveySchedule) (model.SurveyWave, error)) (model.SchedulerRun, error)
+}
+
+type Reminder interface {
+	GetDueSurveyReminders(ctx context.Context, now time.Time, offsetMinutes []int) ([]model.SurveyReminder, error)
+	ClaimSurveyReminder(ctx context.Context, reminder model.SurveyReminder) (bool, error)
+	ReleaseSurveyReminder(ctx context.Context, reminder model.SurveyReminder) error
+}
+
+type Webhook interface {
+	CreateWebhook(ctx context.Context, subscription model.WebhookSubscription) (model.WebhookSubscription, error)
+	GetWebhookByID(ctx context.Context, id int) (model.WebhookSubscription, error)
+	GetWebhooksByCompanyID(ctx context.Context, companyID int) ([]model.WebhookSubscription, error)
+	UpdateWebhook(ctx context.Context, subscription model.WebhookSubscription) (model.WebhookSubscription, error)
+	SetWebhookSecret(ctx context.Context, id int, secret string) (model.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	EnqueueWebhookEvent(ctx context.Context, event model.Event) (int, error)
+	GetWebhookDeliveries(ctx context.Context, subscriptionID int, query model.ListQuery) (model.Page[model.WebhookDelivery], error)
+	GetWebhookDeliveryByID(ctx context.Context, id int) (model.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, id int) (model.WebhookDelivery, error)
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, attempt model.WebhookAttempt) error
+}
+
+type Outbox interface {
+	ClaimOutboxEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, eventID string) error
+	RecordOutboxFailure(ctx context.Context, eventID, message string, next time.Time) error
+	PurgePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Import interface {
+	ImportCompanyStructure(ctx context.Context, companyID, createdBy int, teams []model.ImportTeam, rows []model.ImportRow, invitationExpiresAt time.Time, dryRun bool) (model.ImportReport, error)
 }
 
 func NewRepository(db *sql.DB) *Repository {
-	return &Repository{
-		Authorization: NewAuthPostgres(db),
-		Company:       NewCompanyPostgres(db),
-		Team:          NewTeamPostgres(db),
-		Survey:        NewSurveyPostgres(db),
+	return newRepository(pgDB{db: db})
+}
+
+// newRepository builds the repositories on db, which is either the pool or a
+// unit of work.
+func newRepository(db pgDB) *Repository {
+	repos := &Repository{
+		Authorization: &AuthPostgres{db: db},
+		TwoFactor:     &TwoFactorPostgres{db: db},
+		SSO:           &SSOPostgres{db: db},
+		APIKey:        &APIKeyPostgres{db: db},
+		Company:       &CompanyPostgres{db: db},
+		Team:          &TeamPostgres{db: db},
+		Survey:        &SurveyPostgres{db: db},
+		Import:        &ImportPostgres{db: db},
+		Schedule:      &SchedulePostgres{db: db},
+		Reminder:      &ReminderPostgres{db: db},
+		Webhook:       &WebhookPostgres{db: db},
+		Outbox:        &OutboxPostgres{db: db},
+	}
+
+	if db.unit != nil {
+		repos.Transactor = boundTransactor{repos: repos}
+	} else {
+		repos.Transactor = NewTxManager(db.db)
+	}
+	return repos
+}
+
+// updateMissError explains why a conditional UPDATE of id in table matched no
+// rows: the row is gone (sql.ErrNoRows) or its version no longer matches.
+func updateMissError(ctx context.Context, db pgDB, table string, id int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE id = $1 AND deleted_at IS NULL)`
+	if err := db.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return errNoRows
 	}
+
+	return model.ErrPreconditionFailed
 }
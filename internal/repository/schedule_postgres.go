@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
+
+// schedulerLockKey is the advisory lock that elects the scheduler leader.
+// Every instance ticks, but only the one holding the lock does the work.
+// Advisory lock keys only need to be unique within this application.
+const schedulerLockKey int64 = 1
+
+const surveyScheduleColumns = `id, team_id, rule, timezone, title_template, duration_hours, enabled,
+              next_run_at, last_run_at, created_by, created_at, updated_at`
+
+type SchedulePostgres struct {
+	db pgDB
+}
+
+func NewSchedulePostgres(db *sql.DB) *SchedulePostgres {
+	return &SchedulePostgres{db: pgDB{db: db}}
+}
+
+// CreateSurveySchedule inserts the schedule unless its team is deleted, in
+// which case sql.ErrNoRows is returned.
+func (r *SchedulePostgres) CreateSurveySchedule(ctx context.Context, schedule model.SurveySchedule) (model.SurveySchedule, error) {
+	query := `INSERT INTO survey_schedules (team_id, rule, timezone, title_template, duration_hours, next_run_at, created_by)
+              SELECT $1, $2, $3, $4, $5, $6, $7
+              WHERE EXISTS (SELECT 1 FROM teams WHERE id = $1 AND deleted_at IS NULL)
+              RETURNING ` + surveyScheduleColumns
+
+	return scanSurveySchedule(r.db.QueryRow(ctx, query, schedule.TeamID, schedule.Rule, schedule.Timezone,
+		schedule.TitleTemplate, schedule.DurationHours, schedule.NextRunAt, schedule.CreatedBy))
+}
+
+func (r *SchedulePostgres) GetSurveyScheduleByID(ctx context.Context, id int) (model.SurveySchedule, error) {
+	query := `SELECT ` + surveyScheduleColumns + ` FROM survey_schedules WHERE id = $1`
+
+	return scanSurveySchedule(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *SchedulePostgres) GetSurveySchedulesByTeamID(ctx context.Context, teamID int) ([]model.SurveySchedule, error) {
+	query := `SELECT ` + surveyScheduleColumns + ` FROM survey_schedules WHERE team_id = $1 ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []model.SurveySchedule{}
+	for rows.Next() {
+		schedule, err := scanSurveySchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// UpdateSurveySchedule stores every editable field of schedule, including the
+// recomputed next run.
+func (r *SchedulePostgres) UpdateSurveySchedule(ctx context.Context, schedule model.SurveySchedule) (model.SurveySchedule, error) {
+	query := `UPDATE survey_schedules
+              SET rule = $2, timezone = $3, title_template = $4, duration_hours = $5, enabled = $6, next_run_at = $7
+              WHERE id = $1
+              RETURNING ` + surveyScheduleColumns
+
+	return scanSurveySchedule(r.db.QueryRow(ctx, query, schedule.ID, schedule.Rule, schedule.Timezone,
+		schedule.TitleTemplate, schedule.DurationHours, schedule.Enabled, schedule.NextRunAt))
+}
+
+// DeleteSurveySchedule removes the schedule; surveys it opened stay and lose
+// their schedule_id.
+func (r *SchedulePostgres) DeleteSurveySchedule(ctx context.Context, id int) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM survey_schedules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+// RunDueSurveySchedules performs one scheduler tick in a single transaction
+// guarded by a transaction-level advisory lock, so concurrent instances
+// never open the same wave twice. It completes surveys past their closes_at,
+// then for every enabled schedule of a live team due at now it completes the
+// schedule's open wave, opens the wave described by plan and moves the
+// schedule to plan's NextRunAt. Every survey it completes or opens records
+// its survey.closed or survey.opened event in the same transaction.
+func (r *SchedulePostgres) RunDueSurveySchedules(ctx context.Context, now time.Time, plan func(model.SurveySchedule) (model.SurveyWave, error)) (model.SchedulerRun, error) {
+	var run model.SchedulerRun
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return run, err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, schedulerLockKey).Scan(&run.Leader); err != nil {
+		return run, err
+	}
+	if !run.Leader {
+		return run, nil
+	}
+
+	run.Closed, err = completeSurveys(ctx, tx, `status = 'active' AND closes_at <= $1 AND deleted_at IS NULL`, now)
+	if err != nil {
+		return run, err
+	}
+
+	query := `SELECT s.id, s.team_id, s.rule, s.timezone, s.title_template, s.duration_hours, s.enabled,
+                     s.next_run_at, s.last_run_at, s.created_by, s.created_at, s.updated_at
+              FROM survey_schedules s
+              JOIN teams t ON t.id = s.team_id AND t.deleted_at IS NULL AND t.archived_at IS NULL
+              WHERE s.enabled AND s.next_run_at <= $1
+              ORDER BY s.next_run_at
+              FOR UPDATE OF s`
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return run, err
+	}
+
+	var due []model.SurveySchedule
+	for rows.Next() {
+		schedule, err := scanSurveySchedule(rows)
+		if err != nil {
+			rows.Close()
+			return run, err
+		}
+		due = append(due, schedule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return run, err
+	}
+
+	for _, schedule := range due {
+		wave, err := plan(schedule)
+		if err != nil {
+			return run, err
+		}
+
+		closed, err := completeSurveys(ctx, tx, `schedule_id = $1 AND status = 'active' AND deleted_at IS NULL`, schedule.ID)
+		if err != nil {
+			return run, err
+		}
+		run.Closed = append(run.Closed, closed...)
+
+		query := `INSERT INTO surveys (team_id, title, status, closes_at, schedule_id, created_by)
+                  VALUES ($1, $2, $3, $4, $5, $6)
+                  RETURNING ` + surveyColumns
+		opened, err := scanSurvey(tx.QueryRow(ctx, query, schedule.TeamID, wave.Title, model.SurveyStatusActive,
+			wave.ClosesAt, schedule.ID, schedule.CreatedBy))
+		if err != nil {
+			return run, err
+		}
+
+		query = `UPDATE survey_schedules SET next_run_at = $2, last_run_at = $3 WHERE id = $1`
+		if _, err := tx.Exec(ctx, query, schedule.ID, wave.NextRunAt, now); err != nil {
+			return run, err
+		}
+
+		run.Opened = append(run.Opened, opened)
+	}
+
+	for _, survey := range run.Closed {
+		if err := insertEvent(ctx, tx, model.EventSurveyClosed, survey.TeamID, survey); err != nil {
+			return run, err
+		}
+	}
+	for _, survey := range run.Opened {
+		if err := insertEvent(ctx, tx, model.EventSurveyOpened, survey.TeamID, survey); err != nil {
+			return run, err
+		}
+	}
+
+	return run, tx.Commit()
+}
+
+// completeSurveys completes the surveys matching where and returns them.
+func completeSurveys(ctx context.Context, tx pgTx, where string, args ...interface{}) ([]model.Survey, error) {
+	rows, err := tx.Query(ctx, `UPDATE surveys SET status = 'completed' WHERE `+where+` RETURNING `+surveyColumns, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var surveys []model.Survey
+	for rows.Next() {
+		survey, err := scanSurvey(rows)
+		if err != nil {
+			return nil, err
+		}
+		surveys = append(surveys, survey)
+	}
+
+	return surveys, rows.Err()
+}
+
+func scanSurveySchedule(row rowScanner) (model.SurveySchedule, error) {
+	var schedule model.SurveySchedule
+	err := row.Scan(
+		&schedule.ID, &schedule.TeamID, &schedule.Rule, &schedule.Timezone, &schedule.TitleTemplate,
+		&schedule.DurationHours, &schedule.Enabled, &schedule.NextRunAt, &schedule.LastRunAt,
+		&schedule.CreatedBy, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return model.SurveySchedule{}, err
+	}
+
+	return schedule, nil
+}
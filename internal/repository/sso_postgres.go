@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/teamdetected/internal/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SSOPostgres struct {
+	db pgDB
+}
+
+func NewSSOPostgres(db *sql.DB) *SSOPostgres {
+	return &SSOPostgres{db: pgDB{db: db}}
+}
+
+func (r *SSOPostgres) GetSSOConfig(ctx context.Context, companyID int) (model.SSOConfig, error) {
+	var config model.SSOConfig
+	query := `SELECT company_id, issuer, client_id, client_secret, redirect_url, email_domain,
+                     auto_provision, default_role, enabled, domain_verification_token, domain_verified_at,
+                     created_at, updated_at
+              FROM company_sso_configs WHERE company_id = $1`
+
+	err := r.db.QueryRow(ctx, query, companyID).Scan(
+		&config.CompanyID, &config.Issuer, &config.ClientID, &config.ClientSecret, &config.RedirectURL,
+		&config.EmailDomain, &config.AutoProvision, &config.DefaultRole, &config.Enabled,
+		&config.DomainVerificationToken, &config.DomainVerifiedAt, &config.CreatedAt, &config.UpdatedAt,
+	)
+	if err != nil {
+		return model.SSOConfig{}, err
+	}
+
+	return config, nil
+}
+
+// SaveSSOConfig creates or replaces a company's provider settings. An empty
+// client secret keeps the stored one so it does not have to be resent. The
+// domain verification survives only while the email domain stays the same;
+// a new domain gets config.DomainVerificationToken and must be verified again.
+func (r *SSOPostgres) SaveSSOConfig(ctx context.Context, config model.SSOConfig) error {
+	query := `INSERT INTO company_sso_configs
+                  (company_id, issuer, client_id, client_secret, redirect_url, email_domain,
+                   auto_provision, default_role, enabled, domain_verification_token)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+              ON CONFLICT (company_id) DO UPDATE SET
+                  domain_verification_token = CASE WHEN company_sso_configs.email_domain = EXCLUDED.email_domain
+                      THEN company_sso_configs.domain_verification_token ELSE EXCLUDED.domain_verification_token END,
+                  domain_verified_at = CASE WHEN company_sso_configs.email_domain = EXCLUDED.email_domain
+                      THEN company_sso_configs.domain_verified_at END,
+                  issuer = EXCLUDED.issuer,
+                  client_id = EXCLUDED.client_id,
+                  client_secret = CASE WHEN EXCLUDED.client_secret = ''
+                      THEN company_sso_configs.client_secret ELSE EXCLUDED.client_secret END,
+                  redirect_url = EXCLUDED.redirect_url,
+                  email_domain = EXCLUDED.email_domain,
+                  auto_provision = EXCLUDED.auto_provision,
+                  default_role = EXCLUDED.default_role,
+                  enabled = EXCLUDED.enabled,
+                  updated_at = NOW()`
+
+	_, err := r.db.Exec(ctx, query, config.CompanyID, config.Issuer, config.ClientID, config.ClientSecret,
+		config.RedirectURL, config.EmailDomain, config.AutoProvision, config.DefaultRole, config.Enabled,
+		config.DomainVerificationToken)
+	return err
+}
+
+// MarkSSODomainVerified records that the company proved it owns domain. It
+// does nothing if the configured domain changed in the meantime.
+func (r *SSOPostgres) MarkSSODomainVerified(ctx context.Context, companyID int, domain string) error {
+	query := `UPDATE company_sso_configs SET domain_verified_at = NOW(), updated_at = NOW()
+              WHERE company_id = $1 AND email_domain = $2 AND domain_verified_at IS NULL`
+	_, err := r.db.Exec(ctx, query, companyID, domain)
+	return err
+}
+
+func (r *SSOPostgres) CreateLoginState(ctx context.Context, state model.OIDCLoginState) error {
+	// Expired states of abandoned logins are cleaned up opportunistically.
+	if _, err := r.db.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO oidc_login_states (state, company_id, link_user_id, code_verifier, nonce, expires_at)
+              VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6)`
+	_, err := r.db.Exec(ctx, query, state.State, state.CompanyID, state.LinkUserID, state.CodeVerifier,
+		state.Nonce, state.ExpiresAt)
+	return err
+}
+
+// ConsumeLoginState removes and returns a pending login so each state value
+// can complete at most one login.
+func (r *SSOPostgres) ConsumeLoginState(ctx context.Context, state string) (model.OIDCLoginState, error) {
+	var result model.OIDCLoginState
+	query := `DELETE FROM oidc_login_states WHERE state = $1 AND expires_at > NOW()
+              RETURNING state, company_id, COALESCE(link_user_id, 0), code_verifier, nonce, expires_at`
+
+	err := r.db.QueryRow(ctx, query, state).Scan(
+		&result.State, &result.CompanyID, &result.LinkUserID, &result.CodeVerifier, &result.Nonce, &result.ExpiresAt,
+	)
+	if err != nil {
+		return model.OIDCLoginState{}, err
+	}
+
+	return result, nil
+}
+
+func (r *SSOPostgres) GetUserByIdentity(ctx context.Context, issuer, subject string) (model.User, error) {
+	var user model.User
+	query := `SELECT u.id, u.email, u.name, u.role, u.totp_enabled, u.created_at
+              FROM users u
+              JOIN user_identities ui ON ui.user_id = u.id
+              WHERE ui.issuer = $1 AND ui.subject = $2`
+
+	err := r.db.QueryRow(ctx, query, issuer, subject).Scan(
+		&user.ID, &user.Email, &user.Name, &user.Role, &user.TwoFactorEnabled, &user.CreatedAt,
+	)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+func (r *SSOPostgres) LinkIdentity(ctx context.Context, userID int, identity model.ExternalIdentity) error {
+	query := `INSERT INTO user_identities (user_id, issuer, subject, email) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(ctx, query, userID, identity.Issuer, identity.Subject, identity.Email)
+	return err
+}
+
+// ProvisionUser creates a user for an SSO identity, links the identity and
+// adds the user to the company with role in a single transaction.
+func (r *SSOPostgres) ProvisionUser(ctx context.Context, user model.User, identity model.ExternalIdentity, companyID int, role model.CompanyRole) (int, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	query := `INSERT INTO users (email, password_hash, name, role) VALUES ($1, $2, $3, $4) RETURNING id`
+	err = tx.QueryRow(ctx, query, model.NormalizeEmail(user.Email), string(hashedPassword), user.Name, user.Role).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `INSERT INTO user_identities (user_id, issuer, subject, email) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, id, identity.Issuer, identity.Subject, identity.Email); err != nil {
+		return 0, err
+	}
+
+	query = `INSERT INTO company_members (company_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(ctx, query, companyID, id, role); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *SSOPostgres) AddCompanyMember(ctx context.Context, companyID, userID int) error {
+	query := `INSERT INTO company_members (company_id, user_id) VALUES ($1, $2)
+              ON CONFLICT (company_id, user_id) DO NOTHING`
+	_, err := r.db.Exec(ctx, query, companyID, userID)
+	return err
+}
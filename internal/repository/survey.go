@@ -1,14 +1,26 @@
 package repository
 
-import "github.com/teamdetected/internal/model"
+import (
+	"context"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
 
 type Survey interface {
-	CreateSurvey(survey model.Survey) (int, error)
-	GetSurveyByID(id int) (model.Survey, error)
-	GetSurveysByTeamID(teamID int) ([]model.Survey, error)
-	DeleteSurvey(id int) error
-	CreateSurveyResponse(response model.SurveyResponse) (int, error)
-	GetSurveyResponses(surveyID int) ([]model.SurveyResponse, error)
-	GetSurveyOptions() ([]model.SurveyOption, error)
-	GetSurveyQuestions() ([]model.SurveyQuestion, error)
+	CreateSurvey(ctx context.Context, survey model.Survey) (int, error)
+	GetSurveyByID(ctx context.Context, id int) (model.Survey, error)
+	GetSurveyCompanyID(ctx context.Context, id int) (int, error)
+	GetSurveysByTeamID(ctx context.Context, teamID int, query model.ListQuery) (model.Page[model.Survey], error)
+	UpdateSurvey(ctx context.Context, id int, input model.UpdateSurveyInput, expectedUpdatedAt *time.Time) (model.Survey, error)
+	DeleteSurvey(ctx context.Context, id int) error
+	RestoreSurvey(ctx context.Context, id int, deletedAfter time.Time) error
+	ArchiveSurvey(ctx context.Context, id int) error
+	UnarchiveSurvey(ctx context.Context, id int) error
+	PurgeDeletedSurveys(ctx context.Context, before time.Time) (int64, error)
+	CreateSurveyResponse(ctx context.Context, response model.SurveyResponse) (int, error)
+	GetSurveyResponses(ctx context.Context, surveyID int, query model.ListQuery) (model.Page[model.SurveyResponse], error)
+	GetSurveyOptions(ctx context.Context) ([]model.SurveyOption, error)
+	GetSurveyQuestions(ctx context.Context) ([]model.SurveyQuestion, error)
+	GetSurveyActivity(ctx context.Context) (model.SurveyActivity, error)
 }
@@ -1,100 +1,309 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/teamdetected/internal/model"
 )
 
+const surveyColumns = `id, team_id, title, status, closes_at, schedule_id, created_by, created_at, updated_at, archived_at`
+
 type SurveyPostgres struct {
-	db *sql.DB
+	db pgDB
 }
 
 func NewSurveyPostgres(db *sql.DB) *SurveyPostgres {
-	return &SurveyPostgres{db: db}
+	return &SurveyPostgres{db: pgDB{db: db}}
 }
 
-func (r *SurveyPostgres) CreateSurvey(survey model.Survey) (int, error) {
-	var id int
-	query := `INSERT INTO surveys (team_id, status, created_by) 
-              VALUES ($1, $2, $3) RETURNING id`
+// CreateSurvey inserts the survey and its survey.opened event unless its
+// team is deleted, in which case sql.ErrNoRows is returned.
+func (r *SurveyPostgres) CreateSurvey(ctx context.Context, survey model.Survey) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO surveys (team_id, title, status, created_by)
+              SELECT $1, $2, $3, $4
+              WHERE EXISTS (SELECT 1 FROM teams WHERE id = $1 AND deleted_at IS NULL)
+              RETURNING ` + surveyColumns
 
-	err := r.db.QueryRow(query, survey.TeamID, survey.Status, survey.CreatedBy).Scan(&id)
+	created, err := scanSurvey(tx.QueryRow(ctx, query, survey.TeamID, survey.Title, survey.Status, survey.CreatedBy))
 	if err != nil {
 		return 0, err
 	}
+	if err := insertEvent(ctx, tx, model.EventSurveyOpened, created.TeamID, created); err != nil {
+		return 0, err
+	}
 
-	return id, nil
+	return created.ID, tx.Commit()
 }
 
-func (r *SurveyPostgres) GetSurveyByID(id int) (model.Survey, error) {
-	var survey model.Survey
-	query := `SELECT id, team_id, status, created_by, created_at, updated_at 
-              FROM surveys WHERE id = $1`
+func (r *SurveyPostgres) GetSurveyByID(ctx context.Context, id int) (model.Survey, error) {
+	query := `SELECT ` + surveyColumns + ` FROM surveys WHERE id = $1 AND deleted_at IS NULL`
 
-	err := r.db.QueryRow(query, id).Scan(
-		&survey.ID, &survey.TeamID, &survey.Status, &survey.CreatedBy,
-		&survey.CreatedAt, &survey.UpdatedAt,
-	)
-	if err != nil {
-		return model.Survey{}, err
-	}
+	return scanSurvey(r.db.QueryRow(ctx, query, id))
+}
 
-	return survey, nil
+// GetSurveyCompanyID returns the company of a survey's team, including
+// deleted and archived surveys, for permission checks.
+func (r *SurveyPostgres) GetSurveyCompanyID(ctx context.Context, id int) (int, error) {
+	var companyID int
+	query := `SELECT t.company_id FROM surveys s JOIN teams t ON t.id = s.team_id WHERE s.id = $1`
+	err := r.db.QueryRow(ctx, query, id).Scan(&companyID)
+	return companyID, err
 }
 
-func (r *SurveyPostgres) GetSurveysByTeamID(teamID int) ([]model.Survey, error) {
-	query := `SELECT id, team_id, status, created_by, created_at, updated_at 
-              FROM surveys WHERE team_id = $1`
+var surveyListSpec = listSpec{
+	sorts: map[string]listColumn{
+		"id":         sortID,
+		"status":     sortStatus,
+		"created_at": sortCreatedAt,
+		"updated_at": sortUpdatedAt,
+	},
+	defaultSort:     "id",
+	status:          true,
+	archivable:      true,
+	createdAtColumn: "created_at",
+}
 
-	rows, err := r.db.Query(query, teamID)
+func (r *SurveyPostgres) GetSurveysByTeamID(ctx context.Context, teamID int, q model.ListQuery) (model.Page[model.Survey], error) {
+	base := `SELECT ` + surveyColumns + `
+             FROM surveys
+             WHERE team_id = $1 AND deleted_at IS NULL`
+	list, err := buildListQuery(base, []interface{}{teamID}, q, surveyListSpec)
 	if err != nil {
-		return nil, err
+		return model.Page[model.Survey]{}, err
+	}
+
+	rows, err := r.db.Query(ctx, list.query, list.args...)
+	if err != nil {
+		return model.Page[model.Survey]{}, err
 	}
 	defer rows.Close()
 
 	var surveys []model.Survey
 	for rows.Next() {
-		var survey model.Survey
-		err := rows.Scan(
-			&survey.ID, &survey.TeamID, &survey.Status, &survey.CreatedBy,
-			&survey.CreatedAt, &survey.UpdatedAt,
-		)
+		survey, err := scanSurvey(rows)
 		if err != nil {
-			return nil, err
+			return model.Page[model.Survey]{}, err
 		}
 		surveys = append(surveys, survey)
 	}
+	if err := rows.Err(); err != nil {
+		return model.Page[model.Survey]{}, err
+	}
+
+	return page(surveys, list, q, surveySortKey), nil
+}
+
+func surveySortKey(survey model.Survey, sort string) (string, int) {
+	switch sort {
+	case "status":
+		return survey.Status, survey.ID
+	case "created_at":
+		return cursorTime(survey.CreatedAt), survey.ID
+	case "updated_at":
+		return cursorTime(survey.UpdatedAt), survey.ID
+	default:
+		return strconv.Itoa(survey.ID), survey.ID
+	}
+}
+
+// UpdateSurvey applies a partial update. When expectedUpdatedAt is set the
+// update only succeeds if the survey was not modified since that version.
+// Completing an active survey records a survey.closed event.
+func (r *SurveyPostgres) UpdateSurvey(ctx context.Context, id int, input model.UpdateSurveyInput, expectedUpdatedAt *time.Time) (model.Survey, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Survey{}, err
+	}
+	defer tx.Rollback()
+
+	var previousStatus string
+	err = tx.QueryRow(ctx, `SELECT status FROM surveys WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&previousStatus)
+	if err != nil {
+		return model.Survey{}, err
+	}
+
+	query := `UPDATE surveys SET status = COALESCE($2, status), updated_at = NOW()
+              WHERE id = $1 AND deleted_at IS NULL AND ($3::timestamptz IS NULL OR updated_at = $3)
+              RETURNING ` + surveyColumns
+
+	survey, err := scanSurvey(tx.QueryRow(ctx, query, id, input.Status, expectedUpdatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Survey{}, updateMissError(ctx, r.db, "surveys", id)
+	}
+	if err != nil {
+		return model.Survey{}, err
+	}
+
+	if previousStatus == model.SurveyStatusActive && survey.Status == model.SurveyStatusCompleted {
+		if err := insertEvent(ctx, tx, model.EventSurveyClosed, survey.TeamID, survey); err != nil {
+			return model.Survey{}, err
+		}
+	}
 
-	return surveys, nil
+	return survey, tx.Commit()
 }
 
-func (r *SurveyPostgres) DeleteSurvey(id int) error {
-	query := `DELETE FROM surveys WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+func (r *SurveyPostgres) DeleteSurvey(ctx context.Context, id int) error {
+	query := `UPDATE surveys SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
 }
 
-func (r *SurveyPostgres) CreateSurveyResponse(response model.SurveyResponse) (int, error) {
+// RestoreSurvey undeletes the survey. A survey of a deleted team cannot be
+// restored on its own.
+func (r *SurveyPostgres) RestoreSurvey(ctx context.Context, id int, deletedAfter time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockDeleted(ctx, tx, "surveys", id, deletedAfter); err != nil {
+		return err
+	}
+
+	var teamDeleted bool
+	query := `SELECT t.deleted_at IS NOT NULL FROM surveys s JOIN teams t ON t.id = s.team_id WHERE s.id = $1`
+	if err := tx.QueryRow(ctx, query, id).Scan(&teamDeleted); err != nil {
+		return err
+	}
+	if teamDeleted {
+		return model.ErrInvalidInput
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE surveys SET deleted_at = NULL WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SurveyPostgres) ArchiveSurvey(ctx context.Context, id int) error {
+	return setArchived(ctx, r.db, "surveys", id, true)
+}
+
+func (r *SurveyPostgres) UnarchiveSurvey(ctx context.Context, id int) error {
+	return setArchived(ctx, r.db, "surveys", id, false)
+}
+
+// PurgeDeletedSurveys hard-deletes surveys soft-deleted before the given
+// time together with their responses.
+func (r *SurveyPostgres) PurgeDeletedSurveys(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, r.db, "surveys", before)
+}
+
+// CreateSurveyResponse stores an answer of a member of the survey's team to
+// an active survey. It returns model.ErrNotFound for a missing survey,
+// model.ErrSurveyClosed for one that is completed or archived and
+// model.ErrNotTeamMember for other users. The answer that completes the
+// survey, i.e. the last question answered by the last team member, also
+// records answered_at and a survey.answered event. The survey row is locked
+// first so that two concurrent final answers cannot both miss each other and
+// the survey cannot close in between.
+func (r *SurveyPostgres) CreateSurveyResponse(ctx context.Context, response model.SurveyResponse) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var (
+		teamID int
+		status string
+		open   bool
+	)
+	query := `SELECT team_id, status, archived_at IS NULL FROM surveys
+              WHERE id = $1 AND deleted_at IS NULL FOR NO KEY UPDATE`
+	err = tx.QueryRow(ctx, query, response.SurveyID).Scan(&teamID, &status, &open)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status != model.SurveyStatusActive || !open {
+		return 0, model.ErrSurveyClosed
+	}
+
+	var member bool
+	query = `SELECT EXISTS (SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2)`
+	if err := tx.QueryRow(ctx, query, teamID, response.UserID).Scan(&member); err != nil {
+		return 0, err
+	}
+	if !member {
+		return 0, model.ErrNotTeamMember
+	}
+
 	var id int
-	query := `INSERT INTO survey_responses (survey_id, user_id, question_id, option_id) 
+	query = `INSERT INTO survey_responses (survey_id, user_id, question_id, option_id) 
               VALUES ($1, $2, $3, $4) RETURNING id`
 
-	err := r.db.QueryRow(query, response.SurveyID, response.UserID, response.QuestionID, response.OptionID).Scan(&id)
+	err = tx.QueryRow(ctx, query, response.SurveyID, response.UserID, response.QuestionID, response.OptionID).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
 
-	return id, nil
+	query = `UPDATE surveys s SET answered_at = NOW()
+             WHERE s.id = $1 AND s.status = 'active' AND s.deleted_at IS NULL AND s.answered_at IS NULL
+               AND EXISTS (SELECT 1 FROM team_members tm WHERE tm.team_id = s.team_id)
+               AND NOT EXISTS (
+                   SELECT 1 FROM team_members tm
+                   JOIN users u ON u.id = tm.user_id AND u.deleted_at IS NULL
+                   WHERE tm.team_id = s.team_id
+                     AND (SELECT COUNT(DISTINCT r.question_id) FROM survey_responses r
+                          WHERE r.survey_id = s.id AND r.user_id = tm.user_id)
+                         < (SELECT COUNT(*) FROM survey_questions)
+               )
+             RETURNING ` + surveyColumns
+
+	answered, err := scanSurvey(tx.QueryRow(ctx, query, response.SurveyID))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return 0, err
+	default:
+		if err := insertEvent(ctx, tx, model.EventSurveyAnswered, answered.TeamID, answered); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
 }
 
-func (r *SurveyPostgres) GetSurveyResponses(surveyID int) ([]model.SurveyResponse, error) {
-	query := `SELECT id, survey_id, user_id, question_id, option_id, created_at 
-              FROM survey_responses WHERE survey_id = $1`
+var surveyResponseListSpec = listSpec{
+	sorts: map[string]listColumn{
+		"id":         sortID,
+		"created_at": sortCreatedAt,
+	},
+	defaultSort:     "id",
+	createdAtColumn: "created_at",
+}
 
-	rows, err := r.db.Query(query, surveyID)
+func (r *SurveyPostgres) GetSurveyResponses(ctx context.Context, surveyID int, q model.ListQuery) (model.Page[model.SurveyResponse], error) {
+	base := `SELECT id, survey_id, user_id, question_id, option_id, created_at
+             FROM survey_responses WHERE survey_id = $1`
+	list, err := buildListQuery(base, []interface{}{surveyID}, q, surveyResponseListSpec)
 	if err != nil {
-		return nil, err
+		return model.Page[model.SurveyResponse]{}, err
+	}
+
+	rows, err := r.db.Query(ctx, list.query, list.args...)
+	if err != nil {
+		return model.Page[model.SurveyResponse]{}, err
 	}
 	defer rows.Close()
 
@@ -106,18 +315,28 @@ func (r *SurveyPostgres) GetSurveyResponses(surveyID int) ([]model.SurveyRespons
 			&response.OptionID, &response.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return model.Page[model.SurveyResponse]{}, err
 		}
 		responses = append(responses, response)
 	}
+	if err := rows.Err(); err != nil {
+		return model.Page[model.SurveyResponse]{}, err
+	}
 
-	return responses, nil
+	return page(responses, list, q, surveyResponseSortKey), nil
 }
 
-func (r *SurveyPostgres) GetSurveyOptions() ([]model.SurveyOption, error) {
+func surveyResponseSortKey(response model.SurveyResponse, sort string) (string, int) {
+	if sort == "created_at" {
+		return cursorTime(response.CreatedAt), response.ID
+	}
+	return strconv.Itoa(response.ID), response.ID
+}
+
+func (r *SurveyPostgres) GetSurveyOptions(ctx context.Context) ([]model.SurveyOption, error) {
 	query := `SELECT id, text, value FROM survey_options`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -136,10 +355,10 @@ func (r *SurveyPostgres) GetSurveyOptions() ([]model.SurveyOption, error) {
 	return options, nil
 }
 
-func (r *SurveyPostgres) GetSurveyQuestions() ([]model.SurveyQuestion, error) {
+func (r *SurveyPostgres) GetSurveyQuestions(ctx context.Context) ([]model.SurveyQuestion, error) {
 	query := `SELECT id, text, category FROM survey_questions`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -157,3 +376,51 @@ func (r *SurveyPostgres) GetSurveyQuestions() ([]model.SurveyQuestion, error) {
 
 	return questions, nil
 }
+
+func scanSurvey(row rowScanner) (model.Survey, error) {
+	var survey model.Survey
+	err := row.Scan(
+		&survey.ID, &survey.TeamID, &survey.Title, &survey.Status, &survey.ClosesAt, &survey.ScheduleID,
+		&survey.CreatedBy, &survey.CreatedAt, &survey.UpdatedAt, &survey.ArchivedAt,
+	)
+	if err != nil {
+		return model.Survey{}, err
+	}
+
+	return survey, nil
+}
+
+// surveyMembers and surveyCompleted count, for the survey aliased s, the
+// current members of its team whose accounts are not deleted and those of
+// them who answered every question. Responses of people who left the team
+// do not count, so completed never exceeds members.
+const (
+	surveyMembers = `SELECT COUNT(*) AS count FROM team_members tm
+        JOIN users u ON u.id = tm.user_id AND u.deleted_at IS NULL
+        WHERE tm.team_id = s.team_id`
+	surveyCompleted = `SELECT COUNT(*) AS count FROM (
+            SELECT r.user_id FROM survey_responses r
+            JOIN team_members tm ON tm.team_id = s.team_id AND tm.user_id = r.user_id
+            JOIN users u ON u.id = r.user_id AND u.deleted_at IS NULL
+            WHERE r.survey_id = s.id
+            GROUP BY r.user_id
+            HAVING COUNT(DISTINCT r.question_id) >= (SELECT COUNT(*) FROM survey_questions)
+        ) answered`
+)
+
+// GetSurveyActivity counts the active surveys that are neither archived nor
+// deleted, the team members they address and the members who answered every
+// question.
+func (r *SurveyPostgres) GetSurveyActivity(ctx context.Context) (model.SurveyActivity, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(members.count), 0), COALESCE(SUM(completed.count), 0)
+              FROM surveys s
+              JOIN teams t ON t.id = s.team_id
+              CROSS JOIN LATERAL (` + surveyMembers + `) members
+              CROSS JOIN LATERAL (` + surveyCompleted + `) completed
+              WHERE s.status = 'active' AND s.deleted_at IS NULL AND s.archived_at IS NULL
+                AND t.deleted_at IS NULL`
+
+	var activity model.SurveyActivity
+	err := r.db.QueryRow(ctx, query).Scan(&activity.ActiveSurveys, &activity.Members, &activity.Completed)
+	return activity, err
+}
@@ -1,44 +1,146 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/teamdetected/internal/model"
 )
 
 type TeamPostgres struct {
-	db *sql.DB
+	db pgDB
 }
 
 func NewTeamPostgres(db *sql.DB) *TeamPostgres {
-	return &TeamPostgres{db: db}
+	return &TeamPostgres{db: pgDB{db: db}}
 }
 
-func (r *TeamPostgres) CreateTeam(team model.Team) (int, error) {
-	var id int
-	query := `INSERT INTO teams (name, description, company_id, created_by) VALUES ($1, $2, $3, $4) RETURNING id`
+// teamSubtree is a recursive CTE named subtree holding team $1 and its
+// descendants whose rows match the given condition on t. UNION keeps the
+// recursion finite even if a cycle slipped into the data.
+func teamSubtree(condition string) string {
+	return `WITH RECURSIVE subtree AS (
+                SELECT id FROM teams WHERE id = $1
+                UNION
+                SELECT t.id FROM teams t JOIN subtree s ON t.parent_id = s.id WHERE ` + condition + `
+            ) `
+}
+
+// CreateTeam inserts the team and its team.created event unless its company
+// is deleted or its parent is not a live team of the same company, in which
+// case sql.ErrNoRows is returned.
+func (r *TeamPostgres) CreateTeam(ctx context.Context, team model.Team) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO teams (name, description, company_id, parent_id, created_by)
+              SELECT $1, $2, $3, $4, $5
+              WHERE EXISTS (SELECT 1 FROM companies WHERE id = $3 AND deleted_at IS NULL)
+                AND ($4::integer IS NULL OR EXISTS (
+                    SELECT 1 FROM teams WHERE id = $4 AND company_id = $3 AND deleted_at IS NULL))
+              RETURNING id, name, description, company_id, parent_id, created_by, created_at, updated_at, archived_at`
 
-	err := r.db.QueryRow(query, team.Name, team.Description, team.CompanyID, team.CreatedBy).Scan(&id)
+	created, err := scanTeam(tx.QueryRow(ctx, query, team.Name, team.Description, team.CompanyID, team.ParentID, team.CreatedBy))
 	if err != nil {
 		return 0, err
 	}
+	if err := insertEvent(ctx, tx, model.EventTeamCreated, created.ID, created); err != nil {
+		return 0, err
+	}
 
-	return id, nil
+	return created.ID, tx.Commit()
 }
 
-func (r *TeamPostgres) GetTeamByID(id int) (model.Team, error) {
-	var team model.Team
-	query := `SELECT id, name, description, company_id, created_by, created_at, updated_at FROM teams WHERE id = $1`
+func (r *TeamPostgres) GetTeamByID(ctx context.Context, id int) (model.Team, error) {
+	query := `SELECT id, name, description, company_id, parent_id, created_by, created_at, updated_at, archived_at
+              FROM teams WHERE id = $1 AND deleted_at IS NULL`
 
-	err := r.db.QueryRow(query, id).Scan(
-		&team.ID,
-		&team.Name,
-		&team.Description,
-		&team.CompanyID,
-		&team.CreatedBy,
-		&team.CreatedAt,
-		&team.UpdatedAt,
-	)
+	return scanTeam(r.db.QueryRow(ctx, query, id))
+}
+
+// GetTeamCompanyID returns the company of a team, including deleted and
+// archived teams, for permission checks.
+func (r *TeamPostgres) GetTeamCompanyID(ctx context.Context, id int) (int, error) {
+	var companyID int
+	err := r.db.QueryRow(ctx, `SELECT company_id FROM teams WHERE id = $1`, id).Scan(&companyID)
+	return companyID, err
+}
+
+var teamListSpec = listSpec{
+	sorts: map[string]listColumn{
+		"id":         sortID,
+		"name":       sortName,
+		"created_at": sortCreatedAt,
+		"updated_at": sortUpdatedAt,
+	},
+	defaultSort:     "id",
+	archivable:      true,
+	createdAtColumn: "created_at",
+}
+
+func (r *TeamPostgres) GetTeamsByCompanyID(ctx context.Context, companyID int, q model.ListQuery) (model.Page[model.Team], error) {
+	base := `SELECT id, name, description, company_id, parent_id, created_by, created_at, updated_at, archived_at
+             FROM teams
+             WHERE company_id = $1 AND deleted_at IS NULL`
+	list, err := buildListQuery(base, []interface{}{companyID}, q, teamListSpec)
+	if err != nil {
+		return model.Page[model.Team]{}, err
+	}
+
+	rows, err := r.db.Query(ctx, list.query, list.args...)
+	if err != nil {
+		return model.Page[model.Team]{}, err
+	}
+	defer rows.Close()
+
+	var teams []model.Team
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return model.Page[model.Team]{}, err
+		}
+		teams = append(teams, team)
+	}
+	if err := rows.Err(); err != nil {
+		return model.Page[model.Team]{}, err
+	}
+
+	return page(teams, list, q, teamSortKey), nil
+}
+
+func teamSortKey(team model.Team, sort string) (string, int) {
+	switch sort {
+	case "name":
+		return team.Name, team.ID
+	case "created_at":
+		return cursorTime(team.CreatedAt), team.ID
+	case "updated_at":
+		return cursorTime(team.UpdatedAt), team.ID
+	default:
+		return strconv.Itoa(team.ID), team.ID
+	}
+}
+
+// UpdateTeam applies a partial update. When expectedUpdatedAt is set the
+// update only succeeds if the team was not modified since that version.
+func (r *TeamPostgres) UpdateTeam(ctx context.Context, id int, input model.UpdateTeamInput, expectedUpdatedAt *time.Time) (model.Team, error) {
+	query := `UPDATE teams SET
+                  name = COALESCE($2, name),
+                  description = COALESCE($3, description),
+                  updated_at = NOW()
+              WHERE id = $1 AND deleted_at IS NULL AND ($4::timestamptz IS NULL OR updated_at = $4)
+              RETURNING id, name, description, company_id, parent_id, created_by, created_at, updated_at, archived_at`
+
+	team, err := scanTeam(r.db.QueryRow(ctx, query, id, input.Name, input.Description, expectedUpdatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Team{}, updateMissError(ctx, r.db, "teams", id)
+	}
 	if err != nil {
 		return model.Team{}, err
 	}
@@ -46,37 +148,237 @@ func (r *TeamPostgres) GetTeamByID(id int) (model.Team, error) {
 	return team, nil
 }
 
-func (r *TeamPostgres) GetTeamsByCompanyID(companyID int) ([]model.Team, error) {
-	query := `SELECT id, name, description, company_id, created_by, created_at, updated_at FROM teams WHERE company_id = $1`
-	rows, err := r.db.Query(query, companyID)
+// DeleteTeam soft-deletes the team, its sub-teams and their surveys with the
+// same deletion time. Survey responses are kept until the teams are purged.
+func (r *TeamPostgres) DeleteTeam(ctx context.Context, id int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	query := `UPDATE teams SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING deleted_at`
+	if err := tx.QueryRow(ctx, query, id).Scan(&deletedAt); err != nil {
+		return err
+	}
+
+	query = teamSubtree("t.deleted_at IS NULL") +
+		`UPDATE teams SET deleted_at = $2 WHERE id IN (SELECT id FROM subtree) AND deleted_at IS NULL`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return err
+	}
+
+	query = teamSubtree("t.deleted_at = $2") +
+		`UPDATE surveys SET deleted_at = $2 WHERE team_id IN (SELECT id FROM subtree) AND deleted_at IS NULL`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreTeam undeletes the team together with the sub-teams and surveys
+// deleted along with it. A team whose company or parent team is deleted
+// cannot be restored on its own.
+func (r *TeamPostgres) RestoreTeam(ctx context.Context, id int, deletedAfter time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deletedAt, err := lockDeleted(ctx, tx, "teams", id, deletedAfter)
+	if err != nil {
+		return err
+	}
+
+	var parentDeleted bool
+	query := `SELECT c.deleted_at IS NOT NULL OR COALESCE(p.deleted_at IS NOT NULL, FALSE)
+              FROM teams t
+              JOIN companies c ON c.id = t.company_id
+              LEFT JOIN teams p ON p.id = t.parent_id
+              WHERE t.id = $1`
+	if err := tx.QueryRow(ctx, query, id).Scan(&parentDeleted); err != nil {
+		return err
+	}
+	if parentDeleted {
+		return model.ErrInvalidInput
+	}
+
+	query = teamSubtree("t.deleted_at = $2") +
+		`UPDATE surveys SET deleted_at = NULL WHERE team_id IN (SELECT id FROM subtree) AND deleted_at = $2`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return err
+	}
+
+	query = teamSubtree("t.deleted_at = $2") +
+		`UPDATE teams SET deleted_at = NULL WHERE id IN (SELECT id FROM subtree)`
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTeamDescendants lists the live sub-teams of a team at any depth,
+// parents before their children.
+func (r *TeamPostgres) GetTeamDescendants(ctx context.Context, id int) ([]model.Team, error) {
+	query := `WITH RECURSIVE tree AS (
+                  SELECT id, 0 AS depth FROM teams WHERE id = $1 AND deleted_at IS NULL
+                  UNION
+                  SELECT t.id, tree.depth + 1 FROM teams t JOIN tree ON t.parent_id = tree.id
+                  WHERE t.deleted_at IS NULL
+              )
+              SELECT t.id, t.name, t.description, t.company_id, t.parent_id, t.created_by,
+                     t.created_at, t.updated_at, t.archived_at
+              FROM teams t JOIN tree ON tree.id = t.id
+              WHERE tree.depth > 0
+              ORDER BY tree.depth, t.id`
+	rows, err := r.db.Query(ctx, query, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var teams []model.Team
+	teams := []model.Team{}
 	for rows.Next() {
-		var team model.Team
-		err := rows.Scan(
-			&team.ID,
-			&team.Name,
-			&team.Description,
-			&team.CompanyID,
-			&team.CreatedBy,
-			&team.CreatedAt,
-			&team.UpdatedAt,
-		)
+		team, err := scanTeam(rows)
 		if err != nil {
 			return nil, err
 		}
 		teams = append(teams, team)
 	}
 
-	return teams, nil
+	return teams, rows.Err()
+}
+
+// SetTeamParent re-parents a team. The parent must be a live team of the
+// same company outside the team's own subtree, otherwise
+// model.ErrInvalidInput is returned. Re-parenting is serialized per company
+// so two concurrent moves cannot build a cycle together. When
+// expectedUpdatedAt is set the team is only moved if it was not modified
+// since that version.
+func (r *TeamPostgres) SetTeamParent(ctx context.Context, id int, parentID *int, expectedUpdatedAt *time.Time) (model.Team, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Team{}, err
+	}
+	defer tx.Rollback()
+
+	var companyID int
+	query := `SELECT company_id FROM teams WHERE id = $1 AND deleted_at IS NULL`
+	if err := tx.QueryRow(ctx, query, id).Scan(&companyID); err != nil {
+		return model.Team{}, err
+	}
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM companies WHERE id = $1 FOR UPDATE`, companyID); err != nil {
+		return model.Team{}, err
+	}
+
+	if parentID != nil {
+		var valid bool
+		query = teamSubtree("TRUE") +
+			`SELECT EXISTS (
+                 SELECT 1 FROM teams
+                 WHERE id = $2 AND company_id = $3 AND deleted_at IS NULL
+                   AND id NOT IN (SELECT id FROM subtree))`
+		if err := tx.QueryRow(ctx, query, id, *parentID, companyID).Scan(&valid); err != nil {
+			return model.Team{}, err
+		}
+		if !valid {
+			return model.Team{}, model.ErrInvalidInput
+		}
+	}
+
+	query = `UPDATE teams SET parent_id = $2, updated_at = NOW()
+             WHERE id = $1 AND ($3::timestamptz IS NULL OR updated_at = $3)
+             RETURNING id, name, description, company_id, parent_id, created_by, created_at, updated_at, archived_at`
+	team, err := scanTeam(tx.QueryRow(ctx, query, id, parentID, expectedUpdatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Team{}, model.ErrPreconditionFailed
+	}
+	if err != nil {
+		return model.Team{}, err
+	}
+
+	return team, tx.Commit()
+}
+
+// GetTeamResults averages the survey answers of a team. With rollUp the
+// team's live sub-teams at any depth are included.
+func (r *TeamPostgres) GetTeamResults(ctx context.Context, id int, rollUp bool) (model.TeamResults, error) {
+	results := model.TeamResults{TeamID: id, RollUp: rollUp, Questions: []model.QuestionResult{}}
+	subtree := teamSubtree("$2 AND t.deleted_at IS NULL")
+
+	query := subtree + `SELECT (SELECT COUNT(*) FROM subtree), COUNT(DISTINCT s.id),
+                               COUNT(DISTINCT r.user_id), COALESCE(AVG(o.value), 0)
+                        FROM surveys s
+                        LEFT JOIN survey_responses r ON r.survey_id = s.id
+                        LEFT JOIN survey_options o ON o.id = r.option_id
+                        WHERE s.team_id IN (SELECT id FROM subtree) AND s.deleted_at IS NULL`
+	err := r.db.QueryRow(ctx, query, id, rollUp).Scan(
+		&results.Teams, &results.Surveys, &results.Respondents, &results.AverageScore,
+	)
+	if err != nil {
+		return model.TeamResults{}, err
+	}
+
+	query = subtree + `SELECT q.id, q.text, q.category, AVG(o.value), COUNT(*)
+                       FROM survey_responses r
+                       JOIN surveys s ON s.id = r.survey_id
+                       JOIN survey_questions q ON q.id = r.question_id
+                       JOIN survey_options o ON o.id = r.option_id
+                       WHERE s.team_id IN (SELECT id FROM subtree) AND s.deleted_at IS NULL
+                       GROUP BY q.id, q.text, q.category
+                       ORDER BY q.id`
+	rows, err := r.db.Query(ctx, query, id, rollUp)
+	if err != nil {
+		return model.TeamResults{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var question model.QuestionResult
+		err := rows.Scan(&question.QuestionID, &question.Text, &question.Category, &question.AverageScore, &question.Responses)
+		if err != nil {
+			return model.TeamResults{}, err
+		}
+		results.Questions = append(results.Questions, question)
+	}
+
+	return results, rows.Err()
+}
+
+func (r *TeamPostgres) ArchiveTeam(ctx context.Context, id int) error {
+	return setArchived(ctx, r.db, "teams", id, true)
+}
+
+func (r *TeamPostgres) UnarchiveTeam(ctx context.Context, id int) error {
+	return setArchived(ctx, r.db, "teams", id, false)
 }
 
-func (r *TeamPostgres) DeleteTeam(id int) error {
-	query := `DELETE FROM teams WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+// PurgeDeletedTeams hard-deletes teams soft-deleted before the given time.
+// Their surveys are purged first by PurgeDeletedSurveys.
+func (r *TeamPostgres) PurgeDeletedTeams(ctx context.Context, before time.Time) (int64, error) {
+	return purgeDeleted(ctx, r.db, "teams", before)
+}
+
+func scanTeam(row rowScanner) (model.Team, error) {
+	var team model.Team
+	err := row.Scan(
+		&team.ID,
+		&team.Name,
+		&team.Description,
+		&team.CompanyID,
+		&team.ParentID,
+		&team.CreatedBy,
+		&team.CreatedAt,
+		&team.UpdatedAt,
+		&team.ArchivedAt,
+	)
+	if err != nil {
+		return model.Team{}, err
+	}
+
+	return team, nil
 }
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
+
+type TwoFactorPostgres struct {
+	db pgDB
+}
+
+func NewTwoFactorPostgres(db *sql.DB) *TwoFactorPostgres {
+	return &TwoFactorPostgres{db: pgDB{db: db}}
+}
+
+func (r *TwoFactorPostgres) GetTwoFactorSecret(ctx context.Context, userID int) (model.TwoFactorSecret, error) {
+	var secret sql.NullString
+	result := model.TwoFactorSecret{UserID: userID}
+	query := `SELECT totp_secret, totp_enabled, totp_last_step, totp_locked_until, totp_challenge_version
+              FROM users WHERE id = $1`
+
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&secret, &result.Enabled, &result.LastStep, &result.LockedUntil, &result.ChallengeVersion,
+	)
+	if err != nil {
+		return model.TwoFactorSecret{}, err
+	}
+	result.Secret = secret.String
+
+	return result, nil
+}
+
+func (r *TwoFactorPostgres) SetTwoFactorSecret(ctx context.Context, userID int, secret string) error {
+	query := `UPDATE users SET totp_secret = $2, totp_enabled = FALSE, totp_last_step = 0, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, userID, secret)
+	return err
+}
+
+func (r *TwoFactorPostgres) EnableTwoFactor(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET totp_enabled = TRUE, updated_at = NOW() WHERE id = $1 AND totp_secret IS NOT NULL`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TwoFactorPostgres) DisableTwoFactor(ctx context.Context, userID int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0, updated_at = NOW() WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateTwoFactorLastStep records the time step of an accepted TOTP code. It
+// reports false when the step was already used, which prevents code replay.
+func (r *TwoFactorPostgres) UpdateTwoFactorLastStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2`
+	result, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// RecordTwoFactorFailure counts a wrong second-factor code. The maxAttempts-th
+// one in a row locks the second factor for lockout, starts the count over and
+// invalidates outstanding challenge tokens. It reports whether it locked.
+func (r *TwoFactorPostgres) RecordTwoFactorFailure(ctx context.Context, userID, maxAttempts int, lockout time.Duration) (bool, error) {
+	var locked bool
+	query := `UPDATE users SET
+                  totp_failed_attempts = CASE WHEN totp_failed_attempts + 1 >= $2
+                      THEN 0 ELSE totp_failed_attempts + 1 END,
+                  totp_locked_until = CASE WHEN totp_failed_attempts + 1 >= $2
+                      THEN NOW() + make_interval(secs => $3) ELSE totp_locked_until END,
+                  totp_challenge_version = CASE WHEN totp_failed_attempts + 1 >= $2
+                      THEN totp_challenge_version + 1 ELSE totp_challenge_version END
+              WHERE id = $1
+              RETURNING COALESCE(totp_locked_until > NOW(), FALSE)`
+
+	err := r.db.QueryRow(ctx, query, userID, maxAttempts, lockout.Seconds()).Scan(&locked)
+	if err != nil {
+		return false, err
+	}
+
+	return locked, nil
+}
+
+// ResetTwoFactorFailures clears the failure count after a correct code and
+// bumps the challenge version so the challenge token just used cannot be
+// used again.
+func (r *TwoFactorPostgres) ResetTwoFactorFailures(ctx context.Context, userID int) error {
+	query := `UPDATE users SET totp_failed_attempts = 0, totp_locked_until = NULL,
+                  totp_challenge_version = totp_challenge_version + 1
+              WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
+
+func (r *TwoFactorPostgres) ReplaceRecoveryCodes(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TwoFactorPostgres) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `UPDATE user_recovery_codes SET used_at = NOW()
+              WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *TwoFactorPostgres) GetTwoFactorPolicies(ctx context.Context) ([]model.TwoFactorPolicy, error) {
+	query := `SELECT role, required, updated_at FROM two_factor_policies ORDER BY role`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []model.TwoFactorPolicy
+	for rows.Next() {
+		var policy model.TwoFactorPolicy
+		if err := rows.Scan(&policy.Role, &policy.Required, &policy.UpdatedAt); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+func (r *TwoFactorPostgres) IsTwoFactorRequired(ctx context.Context, role string) (bool, error) {
+	var required bool
+	query := `SELECT required FROM two_factor_policies WHERE role = $1`
+
+	err := r.db.QueryRow(ctx, query, role).Scan(&required)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return required, nil
+}
+
+func (r *TwoFactorPostgres) SetTwoFactorPolicy(ctx context.Context, role string, required bool) error {
+	query := `INSERT INTO two_factor_policies (role, required, updated_at) VALUES ($1, $2, NOW())
+              ON CONFLICT (role) DO UPDATE SET required = EXCLUDED.required, updated_at = NOW()`
+	_, err := r.db.Exec(ctx, query, role, required)
+	return err
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgTx, userID int, recoveryCodeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.Exec(ctx, query, userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
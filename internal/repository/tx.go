@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// maxTxAttempts bounds how often a unit of work runs when Postgres keeps
+// aborting it with a serialization failure or a deadlock.
+const maxTxAttempts = 3
+
+// Transactor runs units of work: several repository calls that commit or
+// roll back together.
+type Transactor interface {
+	// WithinTx calls fn with repositories bound to one transaction. The
+	// transaction commits when fn returns nil and rolls back otherwise. When
+	// Postgres aborts it with a serialization failure or a deadlock, fn runs
+	// again in a new transaction, so fn must not have effects outside the
+	// repositories it is given. Calling WithinTx on repositories that are
+	// already bound to a transaction joins that transaction.
+	WithinTx(ctx context.Context, fn func(repos *Repository) error) error
+}
+
+// TxManager starts units of work on the pool. Units run at SERIALIZABLE
+// isolation, so a check made early in a unit still holds when it commits;
+// a concurrent unit that would invalidate it fails with a serialization
+// error and is retried.
+type TxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(repos *Repository) error) error {
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, fn)
+		if attempt == maxTxAttempts || !retryableTxError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * 20 * time.Millisecond):
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(repos *Repository) error) error {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(newRepository(pgDB{db: m.db, unit: &unitOfWork{tx: tx}})); err != nil {
+		return err
+	}
+
+	return translateError(tx.Commit())
+}
+
+// unitOfWork is the transaction shared by the repositories of one WithinTx
+// call.
+type unitOfWork struct {
+	tx         *sql.Tx
+	savepoints int
+}
+
+func (u *unitOfWork) savepoint(ctx context.Context) (pgTx, error) {
+	u.savepoints++
+	name := "sp_" + strconv.Itoa(u.savepoints)
+	if _, err := u.tx.ExecContext(ctx, `SAVEPOINT `+name); err != nil {
+		return pgTx{}, translateError(err)
+	}
+	return pgTx{tx: u.tx, savepoint: &savepoint{name: name}}, nil
+}
+
+// boundTransactor is the Transactor of repositories inside a unit of work.
+// Nested units of work join the outer one and commit with it.
+type boundTransactor struct {
+	repos *Repository
+}
+
+func (b boundTransactor) WithinTx(ctx context.Context, fn func(repos *Repository) error) error {
+	return fn(b.repos)
+}
+
+// retryableTxError reports whether err aborted the transaction in a way that
+// running it again may resolve.
+func retryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01" // serialization_failure, deadlock_detected
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/teamdetected/internal/model"
+)
+
+const webhookSubscriptionColumns = `id, company_id, url, events, active, created_by, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+              last_status_code, last_error, delivered_at, created_at`
+
+type WebhookPostgres struct {
+	db pgDB
+}
+
+func NewWebhookPostgres(db *sql.DB) *WebhookPostgres {
+	return &WebhookPostgres{db: pgDB{db: db}}
+}
+
+// CreateWebhook inserts the subscription unless its company is deleted, in
+// which case sql.ErrNoRows is returned. The secret is stored but, like on
+// every other read, not returned.
+func (r *WebhookPostgres) CreateWebhook(ctx context.Context, subscription model.WebhookSubscription) (model.WebhookSubscription, error) {
+	query := `INSERT INTO webhook_subscriptions (company_id, url, secret, events, created_by)
+              SELECT $1, $2, $3, $4, $5
+              WHERE EXISTS (SELECT 1 FROM companies WHERE id = $1 AND deleted_at IS NULL)
+              RETURNING ` + webhookSubscriptionColumns
+
+	return scanWebhook(r.db.QueryRow(ctx, query, subscription.CompanyID, subscription.URL, subscription.Secret,
+		pq.Array(subscription.Events), subscription.CreatedBy))
+}
+
+func (r *WebhookPostgres) GetWebhookByID(ctx context.Context, id int) (model.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	return scanWebhook(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *WebhookPostgres) GetWebhooksByCompanyID(ctx context.Context, companyID int) ([]model.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE company_id = $1 ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []model.WebhookSubscription{}
+	for rows.Next() {
+		subscription, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// UpdateWebhook stores the subscription's url, events and active flag.
+func (r *WebhookPostgres) UpdateWebhook(ctx context.Context, subscription model.WebhookSubscription) (model.WebhookSubscription, error) {
+	query := `UPDATE webhook_subscriptions SET url = $2, events = $3, active = $4
+              WHERE id = $1
+              RETURNING ` + webhookSubscriptionColumns
+
+	return scanWebhook(r.db.QueryRow(ctx, query, subscription.ID, subscription.URL,
+		pq.Array(subscription.Events), subscription.Active))
+}
+
+// SetWebhookSecret replaces the signing secret. Deliveries still pending are
+// signed with the new secret.
+func (r *WebhookPostgres) SetWebhookSecret(ctx context.Context, id int, secret string) (model.WebhookSubscription, error) {
+	query := `UPDATE webhook_subscriptions SET secret = $2
+              WHERE id = $1
+              RETURNING ` + webhookSubscriptionColumns
+
+	return scanWebhook(r.db.QueryRow(ctx, query, id, secret))
+}
+
+// DeleteWebhook removes the subscription together with its delivery log.
+func (r *WebhookPostgres) DeleteWebhook(ctx context.Context, id int) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+// EnqueueWebhookEvent queues a delivery of event for every active
+// subscription of its company that receives its type, and returns how many
+// were queued. An event that was already queued for a subscription is
+// skipped, so relaying an event twice does not send it twice.
+func (r *WebhookPostgres) EnqueueWebhookEvent(ctx context.Context, event model.Event) (int, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+              SELECT id, $2, $3, $4 FROM webhook_subscriptions
+              WHERE company_id = $1 AND active AND $3 = ANY(events)
+              ON CONFLICT (subscription_id, event_id) WHERE redelivery_of IS NULL DO NOTHING`
+
+	result, err := r.db.Exec(ctx, query, event.CompanyID, event.ID, event.Type, string(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+var webhookDeliveryListSpec = listSpec{
+	sorts: map[string]listColumn{
+		"id":         sortID,
+		"status":     sortStatus,
+		"created_at": sortCreatedAt,
+	},
+	defaultSort:     "id",
+	status:          true,
+	createdAtColumn: "created_at",
+}
+
+func (r *WebhookPostgres) GetWebhookDeliveries(ctx context.Context, subscriptionID int, q model.ListQuery) (model.Page[model.WebhookDelivery], error) {
+	base := `SELECT ` + webhookDeliveryColumns + `
+             FROM webhook_deliveries WHERE subscription_id = $1`
+	list, err := buildListQuery(base, []interface{}{subscriptionID}, q, webhookDeliveryListSpec)
+	if err != nil {
+		return model.Page[model.WebhookDelivery]{}, err
+	}
+
+	rows, err := r.db.Query(ctx, list.query, list.args...)
+	if err != nil {
+		return model.Page[model.WebhookDelivery]{}, err
+	}
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return model.Page[model.WebhookDelivery]{}, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return model.Page[model.WebhookDelivery]{}, err
+	}
+
+	return page(deliveries, list, q, webhookDeliverySortKey), nil
+}
+
+func webhookDeliverySortKey(delivery model.WebhookDelivery, sort string) (string, int) {
+	switch sort {
+	case "status":
+		return delivery.Status, delivery.ID
+	case "created_at":
+		return cursorTime(delivery.CreatedAt), delivery.ID
+	default:
+		return strconv.Itoa(delivery.ID), delivery.ID
+	}
+}
+
+func (r *WebhookPostgres) GetWebhookDeliveryByID(ctx context.Context, id int) (model.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	return scanWebhookDelivery(r.db.QueryRow(ctx, query, id))
+}
+
+// RedeliverWebhook queues a copy of the delivery with the same event id and
+// payload. The original stays in the log untouched.
+func (r *WebhookPostgres) RedeliverWebhook(ctx context.Context, id int) (model.WebhookDelivery, error) {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, redelivery_of)
+              SELECT subscription_id, event_id, event_type, payload, id FROM webhook_deliveries WHERE id = $1
+              RETURNING ` + webhookDeliveryColumns
+
+	return scanWebhookDelivery(r.db.QueryRow(ctx, query, id))
+}
+
+// ClaimWebhookDeliveries picks up to limit pending deliveries due at now,
+// counts the attempt and moves next_attempt_at by lease, so an instance that
+// dies mid-send only delays the delivery. SKIP LOCKED lets several instances
+// claim disjoint batches.
+func (r *WebhookPostgres) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries d
+              SET attempts = d.attempts + 1, next_attempt_at = $2
+              FROM webhook_subscriptions s
+              WHERE s.id = d.subscription_id AND d.id IN (
+                  SELECT dd.id FROM webhook_deliveries dd
+                  JOIN webhook_subscriptions ss ON ss.id = dd.subscription_id AND ss.active
+                  WHERE dd.status = 'pending' AND dd.next_attempt_at <= $1
+                  ORDER BY dd.next_attempt_at
+                  LIMIT $3
+                  FOR UPDATE OF dd SKIP LOCKED
+              )
+              RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+                        d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at,
+                        s.url, s.secret`
+
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		err := rows.Scan(
+			&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+			&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode,
+			&delivery.LastError, &delivery.DeliveredAt, &delivery.CreatedAt, &delivery.URL, &delivery.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordWebhookAttempt stores the outcome of sending a claimed delivery.
+func (r *WebhookPostgres) RecordWebhookAttempt(ctx context.Context, attempt model.WebhookAttempt) error {
+	query := `UPDATE webhook_deliveries
+              SET status = $2, last_status_code = $3, last_error = $4, next_attempt_at = $5,
+                  delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() END
+              WHERE id = $1`
+
+	_, err := r.db.Exec(ctx, query, attempt.DeliveryID, attempt.Status, attempt.StatusCode, attempt.Error, attempt.NextAttemptAt)
+	return err
+}
+
+func scanWebhook(row rowScanner) (model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	err := row.Scan(
+		&subscription.ID, &subscription.CompanyID, &subscription.URL, pq.Array(&subscription.Events),
+		&subscription.Active, &subscription.CreatedBy, &subscription.CreatedAt, &subscription.UpdatedAt,
+	)
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := row.Scan(
+		&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode,
+		&delivery.LastError, &delivery.DeliveredAt, &delivery.CreatedAt,
+	)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
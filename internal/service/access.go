@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// access is what a request does with a company's data. Every member may
+// read, managers may also change teams and surveys, and owners and admins may
+// also change the company itself.
+type access int
+
+const (
+	accessRead access = iota
+	accessManage
+	accessAdmin
+)
+
+func (a access) allowedFor(role model.CompanyRole) bool {
+	switch role {
+	case model.CompanyRoleOwner, model.CompanyRoleAdmin:
+		return true
+	case model.CompanyRoleManager:
+		return a <= accessManage
+	case model.CompanyRoleViewer:
+		return a == accessRead
+	default:
+		return false
+	}
+}
+
+// requireCompanyAccess fails with model.ErrForbidden unless the user is a
+// member of the company whose role allows a.
+func requireCompanyAccess(ctx context.Context, companyRepo repository.Company, userID, companyID int, a access) error {
+	role, err := companyRepo.GetCompanyMemberRole(ctx, companyID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+	if !a.allowedFor(role) {
+		return model.ErrForbidden
+	}
+	return nil
+}
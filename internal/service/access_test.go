@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+)
+
+func TestAccess_allowedFor(t *testing.T) {
+	testTable := []struct {
+		role     model.CompanyRole
+		expected map[access]bool
+	}{
+		{role: model.CompanyRoleOwner, expected: map[access]bool{accessRead: true, accessManage: true, accessAdmin: true}},
+		{role: model.CompanyRoleAdmin, expected: map[access]bool{accessRead: true, accessManage: true, accessAdmin: true}},
+		{role: model.CompanyRoleManager, expected: map[access]bool{accessRead: true, accessManage: true, accessAdmin: false}},
+		{role: model.CompanyRoleViewer, expected: map[access]bool{accessRead: true, accessManage: false, accessAdmin: false}},
+		{role: "", expected: map[access]bool{accessRead: false, accessManage: false, accessAdmin: false}},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(string(testCase.role), func(t *testing.T) {
+			for a, expected := range testCase.expected {
+				assert.Equal(t, expected, a.allowedFor(testCase.role), "access %d", a)
+			}
+		})
+	}
+}
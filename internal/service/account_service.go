@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+type AccountService struct {
+	repo   repository.Authorization
+	mailer Mailer
+}
+
+func NewAccountService(repo repository.Authorization, mailer Mailer) *AccountService {
+	return &AccountService{repo: repo, mailer: mailer}
+}
+
+func (s *AccountService) GetProfile(ctx context.Context, userID int) (model.User, error) {
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+func (s *AccountService) UpdateProfile(ctx context.Context, userID int, input model.UpdateProfileInput) (model.User, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return model.User{}, model.ErrInvalidInput
+	}
+
+	if err := s.repo.UpdateUserName(ctx, userID, name); err != nil {
+		return model.User{}, err
+	}
+
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+func (s *AccountService) GetNotificationSettings(ctx context.Context, userID int) (model.NotificationSettings, error) {
+	return s.repo.GetNotificationSettings(ctx, userID)
+}
+
+func (s *AccountService) UpdateNotificationSettings(ctx context.Context, userID int, input model.UpdateNotificationSettingsInput) (model.NotificationSettings, error) {
+	settings := model.NotificationSettings{SurveyReminders: *input.SurveyReminders}
+	if err := s.repo.UpdateNotificationSettings(ctx, userID, settings); err != nil {
+		return model.NotificationSettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (s *AccountService) ChangePassword(ctx context.Context, userID int, input model.ChangePasswordInput) error {
+	if err := s.checkPassword(ctx, userID, input.CurrentPassword); err != nil {
+		return err
+	}
+
+	return s.repo.UpdatePassword(ctx, userID, input.NewPassword)
+}
+
+// RequestEmailChange re-authenticates the user and mails a confirmation token
+// to the new address. The email only changes once the token is confirmed.
+func (s *AccountService) RequestEmailChange(ctx context.Context, userID int, input model.ChangeEmailInput) error {
+	if err := s.checkPassword(ctx, userID, input.Password); err != nil {
+		return err
+	}
+
+	newEmail := model.NormalizeEmail(input.NewEmail)
+	_, err := s.repo.GetUserByEmail(ctx, newEmail)
+	if err == nil {
+		return model.ErrEmailTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	token, err := newVerificationToken()
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.CreateEmailChangeRequest(ctx, model.EmailChangeRequest{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hashVerificationToken(token),
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Confirm your new email address by sending this token to "+
+		"POST /api/v1/users/email/verify:\n\n%s\n\nThe token expires in %s.", token, emailChangeTTL)
+
+	return s.mailer.Send(newEmail, "Confirm your new email address", body)
+}
+
+func (s *AccountService) ConfirmEmailChange(ctx context.Context, token string) error {
+	_, err := s.repo.ConfirmEmailChange(ctx, hashVerificationToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrInvalidEmailToken
+	}
+	return err
+}
+
+func (s *AccountService) GetMemberships(ctx context.Context, userID int) (model.UserMemberships, error) {
+	return s.repo.GetUserMemberships(ctx, userID)
+}
+
+func (s *AccountService) checkPassword(ctx context.Context, userID int, password string) error {
+	err := s.repo.VerifyPassword(ctx, userID, password)
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return model.ErrInvalidPassword
+	}
+	return err
+}
+
+func newVerificationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
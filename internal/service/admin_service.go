@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// AdminService holds operator tasks run from the command line. Its methods
+// act on any company without a requester, so it is never wired to an HTTP
+// route.
+type AdminService struct {
+	companies repository.Company
+	teams     repository.Team
+	surveys   repository.Survey
+	webhooks  repository.Webhook
+	apiKeys   repository.APIKey
+}
+
+func NewAdminService(companies repository.Company, teams repository.Team, surveys repository.Survey, webhooks repository.Webhook, apiKeys repository.APIKey) *AdminService {
+	return &AdminService{companies: companies, teams: teams, surveys: surveys, webhooks: webhooks, apiKeys: apiKeys}
+}
+
+func (s *AdminService) GetCompanies(ctx context.Context, query model.ListQuery) (model.Page[model.Company], error) {
+	return s.companies.GetCompanies(ctx, query)
+}
+
+func (s *AdminService) GetCompany(ctx context.Context, companyID int) (model.Company, error) {
+	return s.getCompany(ctx, companyID)
+}
+
+func (s *AdminService) GetTeams(ctx context.Context, companyID int, query model.ListQuery) (model.Page[model.Team], error) {
+	return s.teams.GetTeamsByCompanyID(ctx, companyID, query)
+}
+
+func (s *AdminService) GetTeam(ctx context.Context, teamID int) (model.Team, error) {
+	team, err := s.teams.GetTeamByID(ctx, teamID)
+	return team, lifecycleError(err)
+}
+
+func (s *AdminService) GetSurveys(ctx context.Context, teamID int, query model.ListQuery) (model.Page[model.Survey], error) {
+	return s.surveys.GetSurveysByTeamID(ctx, teamID, query)
+}
+
+// SetSurveyStatus completes or reopens a survey.
+func (s *AdminService) SetSurveyStatus(ctx context.Context, surveyID int, status string) (model.Survey, error) {
+	survey, err := s.surveys.UpdateSurvey(ctx, surveyID, model.UpdateSurveyInput{Status: &status}, nil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Survey{}, model.ErrNotFound
+	}
+	return survey, err
+}
+
+func (s *AdminService) GetTeamResults(ctx context.Context, teamID int, rollUp bool) (model.TeamResults, error) {
+	if _, err := s.teams.GetTeamByID(ctx, teamID); err != nil {
+		return model.TeamResults{}, lifecycleError(err)
+	}
+
+	return s.teams.GetTeamResults(ctx, teamID, rollUp)
+}
+
+func (s *AdminService) GetCompanyMembers(ctx context.Context, companyID int) ([]model.CompanyMember, error) {
+	if _, err := s.getCompany(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	return s.companies.GetCompanyMembers(ctx, companyID)
+}
+
+// ExportCompany collects the company's data for a backup or a data request.
+func (s *AdminService) ExportCompany(ctx context.Context, companyID int) (model.CompanyExport, error) {
+	company, err := s.getCompany(ctx, companyID)
+	if err != nil {
+		return model.CompanyExport{}, err
+	}
+
+	export := model.CompanyExport{ExportedAt: time.Now(), Company: company, Teams: []model.TeamExport{}}
+	if export.Members, err = s.companies.GetCompanyMembers(ctx, companyID); err != nil {
+		return model.CompanyExport{}, err
+	}
+
+	teams, err := allPages(func(query model.ListQuery) (model.Page[model.Team], error) {
+		return s.teams.GetTeamsByCompanyID(ctx, companyID, query)
+	})
+	if err != nil {
+		return model.CompanyExport{}, err
+	}
+
+	for _, team := range teams {
+		teamExport := model.TeamExport{Team: team, Surveys: []model.SurveyExport{}}
+
+		surveys, err := allPages(func(query model.ListQuery) (model.Page[model.Survey], error) {
+			return s.surveys.GetSurveysByTeamID(ctx, team.ID, query)
+		})
+		if err != nil {
+			return model.CompanyExport{}, err
+		}
+
+		for _, survey := range surveys {
+			responses, err := allPages(func(query model.ListQuery) (model.Page[model.SurveyResponse], error) {
+				return s.surveys.GetSurveyResponses(ctx, survey.ID, query)
+			})
+			if err != nil {
+				return model.CompanyExport{}, err
+			}
+			teamExport.Surveys = append(teamExport.Surveys, model.SurveyExport{Survey: survey, Responses: responses})
+		}
+
+		export.Teams = append(export.Teams, teamExport)
+	}
+
+	return export, nil
+}
+
+// RotateWebhookSecret gives the subscription a new signing secret and
+// returns it; the old secret stops working immediately.
+func (s *AdminService) RotateWebhookSecret(ctx context.Context, webhookID int) (model.WebhookSubscription, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	subscription, err := s.webhooks.SetWebhookSecret(ctx, webhookID, secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.WebhookSubscription{}, model.ErrNotFound
+	}
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	subscription.Secret = secret
+	return subscription, nil
+}
+
+// GetAPIKeys lists a user's API keys, revoked ones included.
+func (s *AdminService) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	return s.apiKeys.GetAPIKeysByUserID(ctx, userID)
+}
+
+// RevokeAPIKey revokes any user's key, e.g. one that leaked.
+func (s *AdminService) RevokeAPIKey(ctx context.Context, keyID int) error {
+	err := s.apiKeys.RevokeAPIKeyByID(ctx, keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	return err
+}
+
+// RotateAPIKey replaces an active key with a new one for the same user,
+// scopes and expiry. The old key stops working immediately; the new one is
+// only part of the returned value.
+func (s *AdminService) RotateAPIKey(ctx context.Context, keyID int) (model.CreatedAPIKey, error) {
+	prefix, rawKey, err := generateAPIKey()
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	key, err := s.apiKeys.ReplaceAPIKey(ctx, keyID, prefix, hashAPIKey(rawKey))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.CreatedAPIKey{}, model.ErrNotFound
+	}
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	return model.CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+func (s *AdminService) getCompany(ctx context.Context, companyID int) (model.Company, error) {
+	company, err := s.companies.GetCompanyByID(ctx, companyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Company{}, model.ErrNotFound
+	}
+	return company, err
+}
+
+// allPages walks a list through its cursors, archived rows included.
+func allPages[T any](list func(query model.ListQuery) (model.Page[T], error)) ([]T, error) {
+	items := []T{}
+	query := model.ListQuery{Limit: model.MaxListLimit, IncludeArchived: true}
+	for {
+		page, err := list(query)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Data...)
+		if page.NextCursor == "" {
+			return items, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
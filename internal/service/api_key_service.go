@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+const (
+	apiKeyPrefixBytes = 5
+	apiKeySecretBytes = 32
+)
+
+type APIKeyService struct {
+	repo      repository.APIKey
+	usersRepo repository.Authorization
+}
+
+func NewAPIKeyService(repo repository.APIKey, usersRepo repository.Authorization) *APIKeyService {
+	return &APIKeyService{repo: repo, usersRepo: usersRepo}
+}
+
+// CreateAPIKey issues a new key for userID. The plain key is only part of the
+// returned value; the database keeps its hash.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID int, input model.CreateAPIKeyInput) (model.CreatedAPIKey, error) {
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return model.CreatedAPIKey{}, model.ErrInvalidInput
+	}
+
+	prefix, rawKey, err := generateAPIKey()
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	key := model.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(input.Name),
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	key.ID, err = s.repo.CreateAPIKey(ctx, key)
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	return model.CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	return s.repo.GetAPIKeysByUserID(ctx, userID)
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	err := s.repo.RevokeAPIKey(ctx, userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	return err
+}
+
+// AuthenticateAPIKey resolves a raw key from the Authorization header to the
+// user it belongs to and the scopes it grants.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (model.APIKeyPrincipal, error) {
+	prefix, ok := parseAPIKeyPrefix(rawKey)
+	if !ok {
+		return model.APIKeyPrincipal{}, model.ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.APIKeyPrincipal{}, model.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return model.APIKeyPrincipal{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashAPIKey(rawKey))) != 1 {
+		return model.APIKeyPrincipal{}, model.ErrInvalidAPIKey
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now())) {
+		return model.APIKeyPrincipal{}, model.ErrInvalidAPIKey
+	}
+
+	// A deleted owner's keys stop working even if revoking them failed.
+	user, err := s.usersRepo.GetUserByID(ctx, key.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.APIKeyPrincipal{}, model.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return model.APIKeyPrincipal{}, err
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		return model.APIKeyPrincipal{}, err
+	}
+
+	return model.APIKeyPrincipal{
+		KeyID:    key.ID,
+		UserID:   user.ID,
+		UserRole: user.Role,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !isKnownScope(scope) {
+			return nil, model.ErrInvalidInput
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+
+	return result, nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range model.APIKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// generateAPIKey returns a key of the form tdk_<prefix>_<secret>. The prefix
+// is base32 so it never contains the separator.
+func generateAPIKey() (string, string, error) {
+	prefixBytes := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix := strings.ToLower(base32NoPadding.EncodeToString(prefixBytes))
+	return prefix, model.APIKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func parseAPIKeyPrefix(rawKey string) (string, bool) {
+	rest, ok := strings.CutPrefix(rawKey, model.APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", false
+	}
+
+	return prefix, true
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
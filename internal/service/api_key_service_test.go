@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// fakeAPIKeyRepo holds a single key, as if it were the only row in api_keys.
+type fakeAPIKeyRepo struct {
+	repository.APIKey
+	key model.APIKey
+}
+
+func (r *fakeAPIKeyRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	if prefix != r.key.Prefix {
+		return model.APIKey{}, sql.ErrNoRows
+	}
+	return r.key, nil
+}
+
+func (r *fakeAPIKeyRepo) TouchAPIKey(ctx context.Context, id int) error {
+	return nil
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	prefix, rawKey, err := generateAPIKey()
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name        string
+		userID      int
+		expectedErr error
+	}{
+		{name: "OK", userID: 2},
+		{name: "Deleted Owner", userID: 42, expectedErr: model.ErrInvalidAPIKey},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &fakeAPIKeyRepo{key: model.APIKey{
+				ID: 7, UserID: testCase.userID, Prefix: prefix, KeyHash: hashAPIKey(rawKey), Scopes: []string{model.ScopeSurveysRead},
+			}}
+			s := NewAPIKeyService(repo, fakeUsersRepo{store: newSSOStore()})
+
+			principal, err := s.AuthenticateAPIKey(context.Background(), rawKey)
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.userID, principal.UserID)
+			assert.Equal(t, 7, principal.KeyID)
+		})
+	}
+}
@@ -1,49 +1,132 @@
 package service
 
 import (
-	"time"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/teamdetected/internal/model"
 	"github.com/teamdetected/internal/repository"
-)
-
-const (
-	tokenTTL   = 12 * time.Hour
-	signingKey = "your-secret-key" // В продакшене нужно использовать переменную окружения
+	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	repo repository.Authorization
+	repo          repository.Authorization
+	twoFactorRepo repository.TwoFactor
+	tx            repository.Transactor
+	tokens        *tokens
 }
 
-func NewAuthService(repo repository.Authorization) *AuthService {
-	return &AuthService{repo: repo}
+func NewAuthService(repo repository.Authorization, twoFactorRepo repository.TwoFactor, tx repository.Transactor, tokens *tokens) *AuthService {
+	return &AuthService{repo: repo, twoFactorRepo: twoFactorRepo, tx: tx, tokens: tokens}
 }
 
-func (s *AuthService) CreateUser(user model.User) (int, error) {
-	return s.repo.CreateUser(user)
+func (s *AuthService) CreateUser(ctx context.Context, user model.User) (int, error) {
+	id, err := s.repo.CreateUser(ctx, user)
+	if errors.Is(err, model.ErrConflict) {
+		return 0, model.ErrEmailTaken
+	}
+	return id, err
 }
 
-func (s *AuthService) GetUser(email, password string) (model.User, error) {
-	return s.repo.GetUser(email, password)
+func (s *AuthService) GetUser(ctx context.Context, email, password string) (model.User, error) {
+	return s.repo.GetUser(ctx, email, password)
 }
 
-func (s *AuthService) GenerateToken(email, password string) (string, error) {
-	user, err := s.repo.GetUser(email, password)
+// GenerateToken checks the password and either issues an access token or, for
+// users with two-factor authentication enabled, a short-lived challenge token.
+func (s *AuthService) GenerateToken(ctx context.Context, email, password string) (model.SignInResult, error) {
+	user, err := s.repo.GetUser(ctx, email, password)
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return model.SignInResult{}, model.ErrUnauthorized
+	}
 	if err != nil {
-		return "", err
+		return model.SignInResult{}, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"exp":     time.Now().Add(tokenTTL).Unix(),
-	})
+	return s.signIn(ctx, user)
+}
+
+// signIn finishes a successful first-factor login for user, whether it came
+// from a password or an SSO provider.
+func (s *AuthService) signIn(ctx context.Context, user model.User) (model.SignInResult, error) {
+	if user.TwoFactorEnabled {
+		secret, err := s.twoFactorRepo.GetTwoFactorSecret(ctx, user.ID)
+		if err != nil {
+			return model.SignInResult{}, err
+		}
+		challenge, err := s.tokens.newChallengeToken(user.ID, secret.ChallengeVersion)
+		if err != nil {
+			return model.SignInResult{}, err
+		}
+		return model.SignInResult{ChallengeToken: challenge, TwoFactorRequired: true}, nil
+	}
+
+	setupRequired, err := s.twoFactorRepo.IsTwoFactorRequired(ctx, user.Role)
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	token, err := s.tokens.newAccessToken(user, setupRequired)
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	return model.SignInResult{Token: token, TwoFactorSetupRequired: setupRequired}, nil
+}
+
+// AuthenticateToken checks an access token issued by GenerateToken and that
+// its user still exists, so tokens of deleted users stop working before they
+// expire. The returned role is the one stored for the user now, not the one
+// the token was issued with.
+func (s *AuthService) AuthenticateToken(ctx context.Context, token string) (model.AccessClaims, error) {
+	claims, err := s.tokens.parseAccessToken(token)
+	if err != nil {
+		return model.AccessClaims{}, fmt.Errorf("%w: %v", model.ErrUnauthorized, err)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.AccessClaims{}, fmt.Errorf("%w: user no longer exists", model.ErrUnauthorized)
+	}
+	if err != nil {
+		return model.AccessClaims{}, err
+	}
 
-	return token.SignedString([]byte(signingKey))
+	claims.Role = user.Role
+	return claims, nil
 }
 
-func (s *AuthService) DeleteUser(id int) error {
-	return s.repo.DeleteUser(id)
+// DeleteUser anonymizes a user account. Users may delete themselves; admins
+// may delete anyone. transferTo optionally names the user who takes over the
+// companies and teams the deleted user created; it must be an active member of
+// each of those companies.
+func (s *AuthService) DeleteUser(ctx context.Context, requester model.Requester, id, transferTo int) error {
+	if requester.UserID != id && requester.Role != string(model.UserRoleAdmin) {
+		return model.ErrForbidden
+	}
+
+	if transferTo == id && transferTo != 0 {
+		return model.ErrInvalidInput
+	}
+
+	// The transfer target is checked in the same unit of work as the
+	// deletion, so it cannot be deleted in between.
+	err := s.tx.WithinTx(ctx, func(repos *repository.Repository) error {
+		if transferTo != 0 {
+			if _, err := repos.Authorization.GetUserByID(ctx, transferTo); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return model.ErrInvalidInput
+				}
+				return err
+			}
+		}
+
+		return repos.Authorization.DeleteUser(ctx, id, transferTo)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	return err
 }
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
+
+// memberRole returns the requester's role in the company, or
+// model.ErrForbidden when they are not a member.
+func (s *CompanyService) memberRole(ctx context.Context, companyID, userID int) (model.CompanyRole, error) {
+	role, err := s.repo.GetCompanyMemberRole(ctx, companyID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", model.ErrForbidden
+	}
+	return role, err
+}
+
+// canAssign reports whether a member with role actor may grant role or change
+// a member that currently has role. Only the owner manages admins.
+func canAssign(actor, role model.CompanyRole) bool {
+	switch actor {
+	case model.CompanyRoleOwner:
+		return role != model.CompanyRoleOwner
+	case model.CompanyRoleAdmin:
+		return role == model.CompanyRoleManager || role == model.CompanyRoleViewer
+	default:
+		return false
+	}
+}
+
+func validMemberRole(role model.CompanyRole) bool {
+	switch role {
+	case model.CompanyRoleAdmin, model.CompanyRoleManager, model.CompanyRoleViewer:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *CompanyService) GetCompanyMembers(ctx context.Context, userID, companyID int) ([]model.CompanyMember, error) {
+	if _, err := s.memberRole(ctx, companyID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetCompanyMembers(ctx, companyID)
+}
+
+// AddCompanyMember invites input.Email to the company with input.Role. The
+// person joins only by accepting the invitation, and whether the email has an
+// account is never revealed. Accepting does not change the role of someone
+// who is already a member; that is UpdateCompanyMember.
+func (s *CompanyService) AddCompanyMember(ctx context.Context, userID, companyID int, input model.AddCompanyMemberInput) error {
+	if !validMemberRole(input.Role) {
+		return model.ErrInvalidInput
+	}
+
+	actor, err := s.memberRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if !canAssign(actor, input.Role) {
+		return model.ErrForbidden
+	}
+
+	company, err := s.repo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return err
+	}
+
+	token, err := newVerificationToken()
+	if err != nil {
+		return err
+	}
+
+	email := model.NormalizeEmail(input.Email)
+	err = s.repo.CreateCompanyInvitation(ctx, model.CompanyInvitation{
+		CompanyID: companyID,
+		Email:     email,
+		Role:      input.Role,
+		TokenHash: hashVerificationToken(token),
+		InvitedBy: userID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	sendCompanyInvitation(s.mailer, company.Name, email, token)
+	return nil
+}
+
+func (s *CompanyService) UpdateCompanyMember(ctx context.Context, userID, companyID, memberID int, input model.UpdateCompanyMemberInput) error {
+	if !validMemberRole(input.Role) {
+		return model.ErrInvalidInput
+	}
+
+	actor, err := s.memberRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.repo.GetCompanyMemberRole(ctx, companyID, memberID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !canAssign(actor, current) || !canAssign(actor, input.Role) {
+		return model.ErrForbidden
+	}
+
+	return s.repo.SetCompanyMember(ctx, companyID, memberID, input.Role)
+}
+
+// RemoveCompanyMember removes a member. Members may always leave on their
+// own; the owner has to transfer ownership first.
+func (s *CompanyService) RemoveCompanyMember(ctx context.Context, userID, companyID, memberID int) error {
+	actor, err := s.memberRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.repo.GetCompanyMemberRole(ctx, companyID, memberID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current == model.CompanyRoleOwner {
+		return model.ErrInvalidInput
+	}
+	if memberID != userID && !canAssign(actor, current) {
+		return model.ErrForbidden
+	}
+
+	return lifecycleError(s.repo.RemoveCompanyMember(ctx, companyID, memberID))
+}
+
+// TransferCompanyOwnership hands the company to another member. The previous
+// owner stays on as admin.
+func (s *CompanyService) TransferCompanyOwnership(ctx context.Context, userID, companyID, newOwnerID int) error {
+	actor, err := s.memberRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if actor != model.CompanyRoleOwner {
+		return model.ErrForbidden
+	}
+	if newOwnerID == userID {
+		return model.ErrInvalidInput
+	}
+
+	err = s.repo.TransferCompanyOwnership(ctx, companyID, newOwnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrInvalidInput
+	}
+	return err
+}
+
+// AcceptCompanyInvitation lets a user join the company they were invited to
+// by a member or an import. The invitation only works for the invited email
+// address.
+func (s *CompanyService) AcceptCompanyInvitation(ctx context.Context, userID int, token string) (int, error) {
+	companyID, err := s.repo.AcceptCompanyInvitation(ctx, userID, hashVerificationToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrInvalidInvitation
+	}
+	return companyID, err
+}
+
+// sendCompanyInvitation mails an invitation token. A failure only leaves the
+// invitation unsent, so it is logged rather than failing the request.
+func sendCompanyInvitation(mailer Mailer, companyName, email, token string) {
+	body := fmt.Sprintf("You have been invited to join %s. Sign in or register with this email address "+
+		"and send this token to POST /api/v1/users/me/invitations/accept:\n\n%s\n\nThe invitation expires in %s.",
+		companyName, token, invitationTTL)
+
+	if err := mailer.Send(email, "Invitation to "+companyName, body); err != nil {
+		log.Printf("send invitation to %s: %v", email, err)
+	}
+}
@@ -1,30 +1,123 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/teamdetected/internal/model"
 	"github.com/teamdetected/internal/repository"
 )
 
 type CompanyService struct {
-	repo repository.Company
+	repo      repository.Company
+	usersRepo repository.Authorization
+	tx        repository.Transactor
+	retention time.Duration
+	mailer    Mailer
+}
+
+func NewCompanyService(repo repository.Company, usersRepo repository.Authorization, tx repository.Transactor, retention time.Duration, mailer Mailer) *CompanyService {
+	return &CompanyService{repo: repo, usersRepo: usersRepo, tx: tx, retention: retention, mailer: mailer}
+}
+
+func (s *CompanyService) CreateCompany(ctx context.Context, company model.Company) (int, error) {
+	return s.repo.CreateCompany(ctx, company)
+}
+
+// CreateCompanyWithTeam creates the company together with its first team;
+// either both exist afterwards or neither does.
+func (s *CompanyService) CreateCompanyWithTeam(ctx context.Context, company model.Company, team model.Team) (int, int, error) {
+	var companyID, teamID int
+	err := s.tx.WithinTx(ctx, func(repos *repository.Repository) error {
+		var err error
+		if companyID, err = repos.Company.CreateCompany(ctx, company); err != nil {
+			return err
+		}
+
+		team.CompanyID = companyID
+		team.CreatedBy = company.CreatedBy
+		teamID, err = repos.Team.CreateTeam(ctx, team)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return companyID, teamID, nil
+}
+
+func (s *CompanyService) GetCompanyByID(ctx context.Context, userID, id int) (model.Company, error) {
+	if err := requireCompanyAccess(ctx, s.repo, userID, id, accessRead); err != nil {
+		return model.Company{}, err
+	}
+
+	return s.repo.GetCompanyByID(ctx, id)
 }
 
-func NewCompanyService(repo repository.Company) *CompanyService {
-	return &CompanyService{repo: repo}
+func (s *CompanyService) GetCompaniesByUserID(ctx context.Context, userID int, query model.ListQuery) (model.Page[model.Company], error) {
+	return s.repo.GetCompaniesByUserID(ctx, userID, query)
+}
+
+func (s *CompanyService) UpdateCompany(ctx context.Context, userID, id int, input model.UpdateCompanyInput, expectedUpdatedAt *time.Time) (model.Company, error) {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return model.Company{}, model.ErrInvalidInput
+		}
+		input.Name = &name
+	}
+
+	if err := requireCompanyAccess(ctx, s.repo, userID, id, accessAdmin); err != nil {
+		return model.Company{}, err
+	}
+
+	company, err := s.repo.UpdateCompany(ctx, id, input, expectedUpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Company{}, model.ErrNotFound
+	}
+	return company, err
 }
 
-func (s *CompanyService) CreateCompany(company model.Company) (int, error) {
-	return s.repo.CreateCompany(company)
+func (s *CompanyService) DeleteCompany(ctx context.Context, userID, id int) error {
+	if err := requireCompanyAccess(ctx, s.repo, userID, id, accessAdmin); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.DeleteCompany(ctx, id))
 }
 
-func (s *CompanyService) GetCompanyByID(id int) (model.Company, error) {
-	return s.repo.GetCompanyByID(id)
+// RestoreCompany looks the requester's role up in the deleted company, which
+// GetCompanyMemberRole no longer sees.
+func (s *CompanyService) RestoreCompany(ctx context.Context, userID, id int) error {
+	role, err := s.repo.GetCompanyMemberRoleIncludingDeleted(ctx, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+	if !accessAdmin.allowedFor(role) {
+		return model.ErrForbidden
+	}
+
+	return lifecycleError(s.repo.RestoreCompany(ctx, id, time.Now().Add(-s.retention)))
 }
 
-func (s *CompanyService) GetCompaniesByUserID(userID int) ([]model.Company, error) {
-	return s.repo.GetCompaniesByUserID(userID)
+func (s *CompanyService) ArchiveCompany(ctx context.Context, userID, id int) error {
+	if err := requireCompanyAccess(ctx, s.repo, userID, id, accessAdmin); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.ArchiveCompany(ctx, id))
 }
 
-func (s *CompanyService) DeleteCompany(id int) error {
-	return s.repo.DeleteCompany(id)
+func (s *CompanyService) UnarchiveCompany(ctx context.Context, userID, id int) error {
+	if err := requireCompanyAccess(ctx, s.repo, userID, id, accessAdmin); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.UnarchiveCompany(ctx, id))
 }
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/teamdetected/internal/importer"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// Import columns. Only email is required; rows without a team only join the
+// company.
+const (
+	importColumnEmail      = "email"
+	importColumnName       = "name"
+	importColumnTeam       = "team"
+	importColumnParentTeam = "parent_team"
+	importColumnRole       = "role"
+)
+
+// invitationTTL is how long an invited person has to accept the invitation;
+// inviting or importing the email again sends a fresh one.
+const invitationTTL = 14 * 24 * time.Hour
+
+type ImportService struct {
+	repo        repository.Import
+	companyRepo repository.Company
+	mailer      Mailer
+}
+
+func NewImportService(repo repository.Import, companyRepo repository.Company, mailer Mailer) *ImportService {
+	return &ImportService{repo: repo, companyRepo: companyRepo, mailer: mailer}
+}
+
+// ImportCompanyStructure validates an employee sheet and, unless dryRun is
+// set, applies it. Validation problems are reported per row in the report and
+// prevent any change. Employees who are not company members yet are mailed an
+// invitation once the import is applied.
+func (s *ImportService) ImportCompanyStructure(ctx context.Context, userID, companyID int, fileName string, data []byte, dryRun bool) (model.ImportReport, error) {
+	actor, err := s.companyRepo.GetCompanyMemberRole(ctx, companyID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ImportReport{}, model.ErrForbidden
+	}
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	if actor != model.CompanyRoleOwner && actor != model.CompanyRoleAdmin {
+		return model.ImportReport{}, model.ErrForbidden
+	}
+
+	records, err := importer.Read(fileName, data)
+	if err != nil {
+		return model.ImportReport{}, fmt.Errorf("%w: %v", model.ErrInvalidInput, err)
+	}
+
+	v := newImportValidator(actor)
+	for _, record := range records {
+		v.add(record)
+	}
+	teams := v.orderedTeams()
+
+	if len(v.errors) > 0 {
+		return model.ImportReport{DryRun: dryRun, Rows: len(v.rows), Invited: []string{}, Errors: v.errors}, nil
+	}
+
+	tokens := make(map[string]string, len(v.rows))
+	for i := range v.rows {
+		token, err := newVerificationToken()
+		if err != nil {
+			return model.ImportReport{}, err
+		}
+		tokens[v.rows[i].Email] = token
+		v.rows[i].InvitationTokenHash = hashVerificationToken(token)
+	}
+
+	expiresAt := time.Now().Add(invitationTTL)
+	report, err := s.repo.ImportCompanyStructure(ctx, companyID, userID, teams, v.rows, expiresAt, dryRun)
+	if err != nil || !report.Applied {
+		return report, err
+	}
+
+	company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return report, err
+	}
+	for _, email := range report.Invited {
+		sendCompanyInvitation(s.mailer, company.Name, email, tokens[email])
+	}
+
+	return report, nil
+}
+
+type importValidator struct {
+	actor  model.CompanyRole
+	rows   []model.ImportRow
+	errors []model.ImportError
+	emails map[string]int
+
+	// teams maps a lower-cased team name to its first spelling, parent and
+	// the line that defined it. order keeps first appearance.
+	teams map[string]*model.ImportTeam
+	order []string
+}
+
+func newImportValidator(actor model.CompanyRole) *importValidator {
+	return &importValidator{
+		actor:  actor,
+		errors: []model.ImportError{},
+		emails: make(map[string]int),
+		teams:  make(map[string]*model.ImportTeam),
+	}
+}
+
+func (v *importValidator) fail(line int, field, message string) {
+	v.errors = append(v.errors, model.ImportError{Line: line, Field: field, Message: message})
+}
+
+func (v *importValidator) add(record importer.Record) {
+	line := record.Line
+	valid := true
+
+	email := model.NormalizeEmail(record.Get(importColumnEmail))
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		v.fail(line, importColumnEmail, "invalid email")
+		valid = false
+	} else if first, ok := v.emails[email]; ok {
+		v.fail(line, importColumnEmail, fmt.Sprintf("duplicate of line %d", first))
+		valid = false
+	} else {
+		v.emails[email] = line
+	}
+
+	role := model.CompanyRole(strings.ToLower(record.Get(importColumnRole)))
+	if role == "" {
+		role = model.CompanyRoleViewer
+	}
+	if !validMemberRole(role) {
+		v.fail(line, importColumnRole, "role must be admin, manager or viewer")
+		valid = false
+	} else if !canAssign(v.actor, role) {
+		v.fail(line, importColumnRole, "only the owner can import admins")
+		valid = false
+	}
+
+	team := record.Get(importColumnTeam)
+	parent := record.Get(importColumnParentTeam)
+	if parent != "" && team == "" {
+		v.fail(line, importColumnParentTeam, "parent_team requires team")
+		valid = false
+	}
+	if team != "" && !v.defineTeam(line, team, parent) {
+		valid = false
+	}
+
+	if !valid {
+		return
+	}
+
+	name := record.Get(importColumnName)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	v.rows = append(v.rows, model.ImportRow{Line: line, Email: email, Name: name, Team: team, Role: role})
+}
+
+// defineTeam records a team and its parent. A team may be listed on many
+// rows but must name the same parent on each row that gives one.
+func (v *importValidator) defineTeam(line int, name, parent string) bool {
+	key := strings.ToLower(name)
+	if strings.EqualFold(name, parent) {
+		v.fail(line, importColumnParentTeam, "team cannot be its own parent")
+		return false
+	}
+
+	team, ok := v.teams[key]
+	if !ok {
+		team = &model.ImportTeam{Line: line, Name: name}
+		v.teams[key] = team
+		v.order = append(v.order, key)
+	}
+	if parent == "" {
+		return true
+	}
+	if team.ParentTeam != "" && !strings.EqualFold(team.ParentTeam, parent) {
+		v.fail(line, importColumnParentTeam, fmt.Sprintf("team %s already has parent %s", team.Name, team.ParentTeam))
+		return false
+	}
+	team.ParentTeam = parent
+	team.Line = line
+
+	return true
+}
+
+// orderedTeams returns the teams with parents defined in the file before
+// their sub-teams and reports cycles. Parents not listed as a team in the
+// file must already exist in the company.
+func (v *importValidator) orderedTeams() []model.ImportTeam {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(v.teams))
+	ordered := make([]model.ImportTeam, 0, len(v.teams))
+
+	var visit func(key string) bool
+	visit = func(key string) bool {
+		team, ok := v.teams[key]
+		if !ok {
+			return true
+		}
+		switch state[key] {
+		case done:
+			return true
+		case visiting:
+			return false
+		}
+
+		state[key] = visiting
+		if team.ParentTeam != "" && !visit(strings.ToLower(team.ParentTeam)) {
+			if state[key] == visiting {
+				v.fail(team.Line, importColumnParentTeam, "team hierarchy contains a cycle")
+			}
+			state[key] = done
+			return false
+		}
+		state[key] = done
+		ordered = append(ordered, *team)
+		return true
+	}
+
+	for _, key := range v.order {
+		visit(key)
+	}
+
+	return ordered
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+)
+
+// Mailer delivers transactional email such as verification messages.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// LogMailer writes messages to the application log instead of sending them.
+// It is the default until an SMTP transport is configured.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(to, subject, body string) error {
+	log.Printf("mail to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
+
+// SMTPMailer sends plain-text email through an SMTP relay. Authentication is
+// only used when a username is set.
+type SMTPMailer struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+func NewSMTPMailer(addr, from, username, password string) *SMTPMailer {
+	return &SMTPMailer{addr: addr, from: from, username: username, password: password}
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, err := net.SplitHostPort(m.addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+
+	message := "From: " + m.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + body
+
+	return smtp.SendMail(m.addr, auth, m.from, []string{to}, []byte(message))
+}
@@ -0,0 +1,56 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/teamdetected/internal/model"
+)
+
+type Account struct {
+	mock.Mock
+}
+
+func NewAccount(t mock.TestingT) *Account {
+	return &Account{}
+}
+
+func (m *Account) GetProfile(ctx context.Context, userID int) (model.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *Account) UpdateProfile(ctx context.Context, userID int, input model.UpdateProfileInput) (model.User, error) {
+	args := m.Called(userID, input)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *Account) ChangePassword(ctx context.Context, userID int, input model.ChangePasswordInput) error {
+	args := m.Called(userID, input)
+	return args.Error(0)
+}
+
+func (m *Account) RequestEmailChange(ctx context.Context, userID int, input model.ChangeEmailInput) error {
+	args := m.Called(userID, input)
+	return args.Error(0)
+}
+
+func (m *Account) ConfirmEmailChange(ctx context.Context, token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *Account) GetMemberships(ctx context.Context, userID int) (model.UserMemberships, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.UserMemberships), args.Error(1)
+}
+
+func (m *Account) GetNotificationSettings(ctx context.Context, userID int) (model.NotificationSettings, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.NotificationSettings), args.Error(1)
+}
+
+func (m *Account) UpdateNotificationSettings(ctx context.Context, userID int, input model.UpdateNotificationSettingsInput) (model.NotificationSettings, error) {
+	args := m.Called(userID, input)
+	return args.Get(0).(model.NotificationSettings), args.Error(1)
+}
@@ -0,0 +1,81 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/teamdetected/internal/model"
+)
+
+type Admin struct {
+	mock.Mock
+}
+
+func NewAdmin(t mock.TestingT) *Admin {
+	return &Admin{}
+}
+
+func (m *Admin) GetCompanies(ctx context.Context, query model.ListQuery) (model.Page[model.Company], error) {
+	args := m.Called(query)
+	return args.Get(0).(model.Page[model.Company]), args.Error(1)
+}
+
+func (m *Admin) GetCompany(ctx context.Context, companyID int) (model.Company, error) {
+	args := m.Called(companyID)
+	return args.Get(0).(model.Company), args.Error(1)
+}
+
+func (m *Admin) GetTeams(ctx context.Context, companyID int, query model.ListQuery) (model.Page[model.Team], error) {
+	args := m.Called(companyID, query)
+	return args.Get(0).(model.Page[model.Team]), args.Error(1)
+}
+
+func (m *Admin) GetTeam(ctx context.Context, teamID int) (model.Team, error) {
+	args := m.Called(teamID)
+	return args.Get(0).(model.Team), args.Error(1)
+}
+
+func (m *Admin) GetSurveys(ctx context.Context, teamID int, query model.ListQuery) (model.Page[model.Survey], error) {
+	args := m.Called(teamID, query)
+	return args.Get(0).(model.Page[model.Survey]), args.Error(1)
+}
+
+func (m *Admin) SetSurveyStatus(ctx context.Context, surveyID int, status string) (model.Survey, error) {
+	args := m.Called(surveyID, status)
+	return args.Get(0).(model.Survey), args.Error(1)
+}
+
+func (m *Admin) GetTeamResults(ctx context.Context, teamID int, rollUp bool) (model.TeamResults, error) {
+	args := m.Called(teamID, rollUp)
+	return args.Get(0).(model.TeamResults), args.Error(1)
+}
+
+func (m *Admin) GetCompanyMembers(ctx context.Context, companyID int) ([]model.CompanyMember, error) {
+	args := m.Called(companyID)
+	return args.Get(0).([]model.CompanyMember), args.Error(1)
+}
+
+func (m *Admin) ExportCompany(ctx context.Context, companyID int) (model.CompanyExport, error) {
+	args := m.Called(companyID)
+	return args.Get(0).(model.CompanyExport), args.Error(1)
+}
+
+func (m *Admin) RotateWebhookSecret(ctx context.Context, webhookID int) (model.WebhookSubscription, error) {
+	args := m.Called(webhookID)
+	return args.Get(0).(model.WebhookSubscription), args.Error(1)
+}
+
+func (m *Admin) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *Admin) RevokeAPIKey(ctx context.Context, keyID int) error {
+	args := m.Called(keyID)
+	return args.Error(0)
+}
+
+func (m *Admin) RotateAPIKey(ctx context.Context, keyID int) (model.CreatedAPIKey, error) {
+	args := m.Called(keyID)
+	return args.Get(0).(model.CreatedAPIKey), args.Error(1)
+}
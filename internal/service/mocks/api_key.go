@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/teamdetected/internal/model"
+)
+
+type APIKey struct {
+	mock.Mock
+}
+
+func NewAPIKey(t mock.TestingT) *APIKey {
+	return &APIKey{}
+}
+
+func (m *APIKey) CreateAPIKey(ctx context.Context, userID int, input model.CreateAPIKeyInput) (model.CreatedAPIKey, error) {
+	args := m.Called(userID, input)
+	return args.Get(0).(model.CreatedAPIKey), args.Error(1)
+}
+
+func (m *APIKey) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *APIKey) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *APIKey) AuthenticateAPIKey(ctx context.Context, rawKey string) (model.APIKeyPrincipal, error) {
+	args := m.Called(rawKey)
+	return args.Get(0).(model.APIKeyPrincipal), args.Error(1)
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"context"
+
 	"github.com/stretchr/testify/mock"
 	"github.com/teamdetected/internal/model"
 )
@@ -13,22 +15,27 @@ func NewAuthorization(t mock.TestingT) *Authorization {
 	return &Authorization{}
 }
 
-func (m *Authorization) CreateUser(user model.User) (int, error) {
+func (m *Authorization) CreateUser(ctx context.Context, user model.User) (int, error) {
 	args := m.Called(user)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *Authorization) GetUser(email, password string) (model.User, error) {
+func (m *Authorization) GetUser(ctx context.Context, email, password string) (model.User, error) {
 	args := m.Called(email, password)
 	return args.Get(0).(model.User), args.Error(1)
 }
 
-func (m *Authorization) GenerateToken(email, password string) (string, error) {
+func (m *Authorization) GenerateToken(ctx context.Context, email, password string) (model.SignInResult, error) {
 	args := m.Called(email, password)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(model.SignInResult), args.Error(1)
+}
+
+func (m *Authorization) AuthenticateToken(ctx context.Context, token string) (model.AccessClaims, error) {
+	args := m.Called(token)
+	return args.Get(0).(model.AccessClaims), args.Error(1)
 }
 
-func (m *Authorization) DeleteUser(id int) error {
-	args := m.Called(id)
+func (m *Authorization) DeleteUser(ctx context.Context, requester model.Requester, id, transferTo int) error {
+	args := m.Called(requester, id, transferTo)
 	return args.Error(0)
 }
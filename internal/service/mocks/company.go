@@ -1,6 +1,10 @@
 package mocks
 
 import (
+	"context"
+
+	"time"
+
 	"github.com/stretchr/testify/mock"
 	"github.com/teamdetected/internal/model"
 )
@@ -13,22 +17,77 @@ func NewCompany(t mock.TestingT) *Company {
 	return &Company{}
 }
 
-func (m *Company) CreateCompany(company model.Company) (int, error) {
+func (m *Company) CreateCompany(ctx context.Context, company model.Company) (int, error) {
 	args := m.Called(company)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *Company) GetCompanyByID(id int) (model.Company, error) {
-	args := m.Called(id)
+func (m *Company) CreateCompanyWithTeam(ctx context.Context, company model.Company, team model.Team) (int, int, error) {
+	args := m.Called(company, team)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *Company) GetCompanyByID(ctx context.Context, userID, id int) (model.Company, error) {
+	args := m.Called(userID, id)
 	return args.Get(0).(model.Company), args.Error(1)
 }
 
-func (m *Company) GetCompaniesByUserID(userID int) ([]model.Company, error) {
-	args := m.Called(userID)
-	return args.Get(0).([]model.Company), args.Error(1)
+func (m *Company) GetCompaniesByUserID(ctx context.Context, userID int, query model.ListQuery) (model.Page[model.Company], error) {
+	args := m.Called(userID, query)
+	return args.Get(0).(model.Page[model.Company]), args.Error(1)
 }
 
-func (m *Company) DeleteCompany(id int) error {
-	args := m.Called(id)
+func (m *Company) UpdateCompany(ctx context.Context, userID, id int, input model.UpdateCompanyInput, expectedUpdatedAt *time.Time) (model.Company, error) {
+	args := m.Called(userID, id, input, expectedUpdatedAt)
+	return args.Get(0).(model.Company), args.Error(1)
+}
+
+func (m *Company) DeleteCompany(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
 	return args.Error(0)
 }
+
+func (m *Company) RestoreCompany(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *Company) ArchiveCompany(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *Company) UnarchiveCompany(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *Company) GetCompanyMembers(ctx context.Context, userID, companyID int) ([]model.CompanyMember, error) {
+	args := m.Called(userID, companyID)
+	return args.Get(0).([]model.CompanyMember), args.Error(1)
+}
+
+func (m *Company) AddCompanyMember(ctx context.Context, userID, companyID int, input model.AddCompanyMemberInput) error {
+	args := m.Called(userID, companyID, input)
+	return args.Error(0)
+}
+
+func (m *Company) UpdateCompanyMember(ctx context.Context, userID, companyID, memberID int, input model.UpdateCompanyMemberInput) error {
+	args := m.Called(userID, companyID, memberID, input)
+	return args.Error(0)
+}
+
+func (m *Company) RemoveCompanyMember(ctx context.Context, userID, companyID, memberID int) error {
+	args := m.Called(userID, companyID, memberID)
+	return args.Error(0)
+}
+
+func (m *Company) TransferCompanyOwnership(ctx context.Context, userID, companyID, newOwnerID int) error {
+	args := m.Called(userID, companyID, newOwnerID)
+	return args.Error(0)
+}
+
+func (m *Company) AcceptCompanyInvitation(ctx context.Context, userID int, token string) (int, error) {
+	args := m.Called(userID, token)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/teamdetected/internal/model"
+)
+
+type Import struct {
+	mock.Mock
+}
+
+func NewImport(t mock.TestingT) *Import {
+	return &Import{}
+}
+
+func (m *Import) ImportCompanyStructure(ctx context.Context, userID, companyID int, fileName string, data []byte, dryRun bool) (model.ImportReport, error) {
+	args := m.Called(userID, companyID, fileName, data, dryRun)
+	return args.Get(0).(model.ImportReport), args.Error(1)
+}
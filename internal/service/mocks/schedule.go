@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/teamdetected/internal/model"
+)
+
+type Schedule struct {
+	mock.Mock
+}
+
+func NewSchedule(t mock.TestingT) *Schedule {
+	return &Schedule{}
+}
+
+func (m *Schedule) CreateSurveySchedule(ctx context.Context, userID, teamID int, input model.CreateSurveyScheduleInput) (model.SurveySchedule, error) {
+	args := m.Called(userID, teamID, input)
+	return args.Get(0).(model.SurveySchedule), args.Error(1)
+}
+
+func (m *Schedule) GetSurveySchedules(ctx context.Context, userID, teamID int) ([]model.SurveySchedule, error) {
+	args := m.Called(userID, teamID)
+	return args.Get(0).([]model.SurveySchedule), args.Error(1)
+}
+
+func (m *Schedule) UpdateSurveySchedule(ctx context.Context, userID, scheduleID int, input model.UpdateSurveyScheduleInput) (model.SurveySchedule, error) {
+	args := m.Called(userID, scheduleID, input)
+	return args.Get(0).(model.SurveySchedule), args.Error(1)
+}
+
+func (m *Schedule) DeleteSurveySchedule(ctx context.Context, userID, scheduleID int) error {
+	args := m.Called(userID, scheduleID)
+	return args.Error(0)
+}
+
+func (m *Schedule) RunDueSchedules(ctx context.Context) (model.SchedulerRun, error) {
+	args := m.Called()
+	return args.Get(0).(model.SchedulerRun), args.Error(1)
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/teamdetected/internal/model"
+)
+
+type SSO struct {
+	mock.Mock
+}
+
+func NewSSO(t mock.TestingT) *SSO {
+	return &SSO{}
+}
+
+func (m *SSO) GetSSOConfig(ctx context.Context, userID, companyID int) (model.SSOConfig, error) {
+	args := m.Called(userID, companyID)
+	return args.Get(0).(model.SSOConfig), args.Error(1)
+}
+
+func (m *SSO) SaveSSOConfig(ctx context.Context, userID int, config model.SSOConfig) error {
+	args := m.Called(userID, config)
+	return args.Error(0)
+}
+
+func (m *SSO) VerifySSODomain(ctx context.Context, userID, companyID int) (model.SSOConfig, error) {
+	args := m.Called(userID, companyID)
+	return args.Get(0).(model.SSOConfig), args.Error(1)
+}
+
+func (m *SSO) BeginSSOLogin(ctx context.Context, companyID int) (string, error) {
+	args := m.Called(companyID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *SSO) BeginSSOLink(ctx context.Context, userID, companyID int) (string, error) {
+	args := m.Called(userID, companyID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *SSO) CompleteSSOLogin(ctx context.Context, state, code string) (model.SignInResult, error) {
+	args := m.Called(state, code)
+	return args.Get(0).(model.SignInResult), args.Error(1)
+}
@@ -1,6 +1,10 @@
 package mocks
 
 import (
+	"context"
+
+	"time"
+
 	"github.com/stretchr/testify/mock"
 	"github.com/teamdetected/internal/model"
 )
@@ -13,22 +17,57 @@ func NewTeam(t mock.TestingT) *Team {
 	return &Team{}
 }
 
-func (m *Team) CreateTeam(team model.Team) (int, error) {
+func (m *Team) CreateTeam(ctx context.Context, team model.Team) (int, error) {
 	args := m.Called(team)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *Team) GetTeamByID(id int) (model.Team, error) {
-	args := m.Called(id)
+func (m *Team) GetTeamByID(ctx context.Context, userID, id int) (model.Team, error) {
+	args := m.Called(userID, id)
 	return args.Get(0).(model.Team), args.Error(1)
 }
 
-func (m *Team) GetTeamsByCompanyID(companyID int) ([]model.Team, error) {
-	args := m.Called(companyID)
-	return args.Get(0).([]model.Team), args.Error(1)
+func (m *Team) GetTeamsByCompanyID(ctx context.Context, userID, companyID int, query model.ListQuery) (model.Page[model.Team], error) {
+	args := m.Called(userID, companyID, query)
+	return args.Get(0).(model.Page[model.Team]), args.Error(1)
+}
+
+func (m *Team) UpdateTeam(ctx context.Context, userID, id int, input model.UpdateTeamInput, expectedUpdatedAt *time.Time) (model.Team, error) {
+	args := m.Called(userID, id, input, expectedUpdatedAt)
+	return args.Get(0).(model.Team), args.Error(1)
+}
+
+func (m *Team) DeleteTeam(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *Team) RestoreTeam(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
 }
 
-func (m *Team) DeleteTeam(id int) error {
-	args := m.Called(id)
+func (m *Team) ArchiveTeam(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
 	return args.Error(0)
 }
+
+func (m *Team) UnarchiveTeam(ctx context.Context, userID, id int) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *Team) GetTeamDescendants(ctx context.Context, userID, id int) ([]model.Team, error) {
+	args := m.Called(userID, id)
+	return args.Get(0).([]model.Team), args.Error(1)
+}
+
+func (m *Team) SetTeamParent(ctx context.Context, userID, id int, parentID *int, expectedUpdatedAt *time.Time) (model.Team, error) {
+	args := m.Called(userID, id, parentID, expectedUpdatedAt)
+	return args.Get(0).(model.Team), args.Error(1)
+}
+
+func (m *Team) GetTeamResults(ctx context.Context, userID, id int, rollUp bool) (model.TeamResults, error) {
+	args := m.Called(userID, id, rollUp)
+	return args.Get(0).(model.TeamResults), args.Error(1)
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/teamdetected/internal/model"
+)
+
+type TwoFactor struct {
+	mock.Mock
+}
+
+func NewTwoFactor(t mock.TestingT) *TwoFactor {
+	return &TwoFactor{}
+}
+
+func (m *TwoFactor) EnrollTwoFactor(ctx context.Context, userID int) (model.TwoFactorEnrollment, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.TwoFactorEnrollment), args.Error(1)
+}
+
+func (m *TwoFactor) ActivateTwoFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *TwoFactor) DisableTwoFactor(ctx context.Context, userID int, code string) error {
+	args := m.Called(userID, code)
+	return args.Error(0)
+}
+
+func (m *TwoFactor) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *TwoFactor) VerifyTwoFactor(ctx context.Context, challengeToken, code, recoveryCode string) (string, error) {
+	args := m.Called(challengeToken, code, recoveryCode)
+	return args.String(0), args.Error(1)
+}
+
+func (m *TwoFactor) GetTwoFactorPolicies(ctx context.Context) ([]model.TwoFactorPolicy, error) {
+	args := m.Called()
+	return args.Get(0).([]model.TwoFactorPolicy), args.Error(1)
+}
+
+func (m *TwoFactor) SetTwoFactorPolicy(ctx context.Context, role string, required bool) error {
+	args := m.Called(role, required)
+	return args.Error(0)
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/teamdetected/internal/model"
+)
+
+type Webhook struct {
+	mock.Mock
+}
+
+func NewWebhook(t mock.TestingT) *Webhook {
+	return &Webhook{}
+}
+
+func (m *Webhook) CreateWebhook(ctx context.Context, userID, companyID int, input model.CreateWebhookInput) (model.WebhookSubscription, error) {
+	args := m.Called(userID, companyID, input)
+	return args.Get(0).(model.WebhookSubscription), args.Error(1)
+}
+
+func (m *Webhook) GetWebhooks(ctx context.Context, userID, companyID int) ([]model.WebhookSubscription, error) {
+	args := m.Called(userID, companyID)
+	return args.Get(0).([]model.WebhookSubscription), args.Error(1)
+}
+
+func (m *Webhook) UpdateWebhook(ctx context.Context, userID, companyID, webhookID int, input model.UpdateWebhookInput) (model.WebhookSubscription, error) {
+	args := m.Called(userID, companyID, webhookID, input)
+	return args.Get(0).(model.WebhookSubscription), args.Error(1)
+}
+
+func (m *Webhook) DeleteWebhook(ctx context.Context, userID, companyID, webhookID int) error {
+	args := m.Called(userID, companyID, webhookID)
+	return args.Error(0)
+}
+
+func (m *Webhook) GetWebhookDeliveries(ctx context.Context, userID, companyID, webhookID int, query model.ListQuery) (model.Page[model.WebhookDelivery], error) {
+	args := m.Called(userID, companyID, webhookID, query)
+	return args.Get(0).(model.Page[model.WebhookDelivery]), args.Error(1)
+}
+
+func (m *Webhook) RedeliverWebhook(ctx context.Context, userID, companyID, webhookID, deliveryID int) (model.WebhookDelivery, error) {
+	args := m.Called(userID, companyID, webhookID, deliveryID)
+	return args.Get(0).(model.WebhookDelivery), args.Error(1)
+}
+
+func (m *Webhook) DeliverWebhooks(ctx context.Context) (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+)
+
+// Notifier delivers a notification to a user through one or more channels.
+type Notifier interface {
+	Notify(notification model.Notification) error
+}
+
+// PartialDeliveryError reports channels that failed although at least one
+// other channel delivered the notification, so retrying all of them would
+// notify the user twice.
+type PartialDeliveryError struct {
+	Err error
+}
+
+func (e *PartialDeliveryError) Error() string { return "partially delivered: " + e.Err.Error() }
+func (e *PartialDeliveryError) Unwrap() error { return e.Err }
+
+// Notifiers fans a notification out to every channel. All channels are tried
+// and their errors are joined; if some channel succeeded the result is a
+// *PartialDeliveryError.
+type Notifiers []Notifier
+
+func (n Notifiers) Notify(notification model.Notification) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) < len(n) {
+		return &PartialDeliveryError{Err: errors.Join(errs...)}
+	}
+	return errors.Join(errs...)
+}
+
+// EmailNotifier sends the notification's subject and body by email.
+type EmailNotifier struct {
+	mailer Mailer
+}
+
+func NewEmailNotifier(mailer Mailer) *EmailNotifier {
+	return &EmailNotifier{mailer: mailer}
+}
+
+func (n *EmailNotifier) Notify(notification model.Notification) error {
+	return n.mailer.Send(notification.Email, notification.Subject, notification.Body)
+}
+
+// WebhookNotifier posts the notification as JSON to a fixed URL, e.g. a chat
+// bot that forwards it to the user.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) Notify(notification model.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook responded with %s", resp.Status)
+	}
+	return nil
+}
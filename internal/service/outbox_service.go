@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/teamdetected/internal/events"
+	"github.com/teamdetected/internal/repository"
+)
+
+// Outbox relay tuning. An event that a sink rejects is retried after 10s,
+// 20s, 40s and so on, at least every outboxMaxBackoff, until every sink
+// accepts it.
+const (
+	outboxBatchSize  = 100
+	outboxLease      = time.Minute
+	outboxMinBackoff = 10 * time.Second
+	outboxMaxBackoff = time.Hour
+	// outboxRetention is how long published events are kept for inspection.
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+type OutboxService struct {
+	repo  repository.Outbox
+	sinks events.Sink
+}
+
+func NewOutboxService(repo repository.Outbox, sinks events.Sink) *OutboxService {
+	return &OutboxService{repo: repo, sinks: sinks}
+}
+
+// RelayEvents publishes a batch of due outbox events to the sinks and
+// returns how many were published. An event is only marked published once
+// every sink accepted it, so sinks see each event at least once and possibly
+// more often. It is safe to call from every instance.
+func (s *OutboxService) RelayEvents(ctx context.Context) (int, error) {
+	now := time.Now()
+	claimed, err := s.repo.ClaimOutboxEvents(ctx, now, outboxLease, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range claimed {
+		if err := s.sinks.Publish(ctx, event.Event); err != nil {
+			next := now.Add(retryBackoff(event.Attempts, outboxMinBackoff, outboxMaxBackoff))
+			log.Printf("relay %s event %s (attempt %d): %v", event.Type, event.ID, event.Attempts, err)
+			if err := s.repo.RecordOutboxFailure(ctx, event.ID, err.Error(), next); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err := s.repo.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+			return published, fmt.Errorf("mark event %s published: %w", event.ID, err)
+		}
+		published++
+	}
+
+	if _, err := s.repo.PurgePublishedOutboxEvents(ctx, now.Add(-outboxRetention)); err != nil {
+		return published, err
+	}
+	return published, nil
+}
+
+// retryBackoff is the wait after the given number of failed attempts: min,
+// doubled for every further attempt and capped at max.
+func retryBackoff(attempts int, min, max time.Duration) time.Duration {
+	backoff := min
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicAddressOnly(t *testing.T) {
+	testTable := []struct {
+		address string
+		allowed bool
+	}{
+		{address: "93.184.216.34:443", allowed: true},
+		{address: "[2606:2800:220:1::1]:443", allowed: true},
+		{address: "127.0.0.1:443"},
+		{address: "[::1]:443"},
+		{address: "10.0.0.5:443"},
+		{address: "172.16.0.1:443"},
+		{address: "192.168.1.1:443"},
+		{address: "169.254.169.254:80"},
+		{address: "[fe80::1]:443"},
+		{address: "[fd00::1]:443"},
+		{address: "0.0.0.0:443"},
+		{address: "[::ffff:127.0.0.1]:443"},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.address, func(t *testing.T) {
+			err := publicAddressOnly("tcp", testCase.address, nil)
+			if testCase.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errPrivateAddress)
+			}
+		})
+	}
+}
+
+func TestNewPublicClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	_, err := newPublicClient(time.Second).Post(server.URL, "application/json", nil)
+
+	assert.ErrorIs(t, err, errPrivateAddress)
+}
+
+func TestNewPublicClient_NoRedirects(t *testing.T) {
+	client := newPublicClient(time.Second)
+	client.Transport = http.DefaultTransport
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	resp, err := client.Post(server.URL, "application/json", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// DefaultReminderOffsets are the reminder stages before a survey closes when
+// none are configured.
+var DefaultReminderOffsets = []time.Duration{48 * time.Hour, 4 * time.Hour}
+
+type ReminderService struct {
+	repo     repository.Reminder
+	notifier Notifier
+	offsets  []int
+}
+
+func NewReminderService(repo repository.Reminder, notifier Notifier, offsets []time.Duration) *ReminderService {
+	minutes := make([]int, 0, len(offsets))
+	for _, offset := range offsets {
+		if offset > 0 {
+			minutes = append(minutes, int(offset/time.Minute))
+		}
+	}
+	return &ReminderService{repo: repo, notifier: notifier, offsets: minutes}
+}
+
+// SendDueReminders notifies every team member with unanswered questions whose
+// survey reached a reminder stage. Each reminder is claimed before it is sent,
+// so parallel instances never send it twice. A reminder no channel delivered
+// is released and retried on the next run; one that reached the user through
+// some channel keeps its claim and the failed channels are only reported. It
+// returns how many reminders were sent.
+func (s *ReminderService) SendDueReminders(ctx context.Context) (int, error) {
+	if len(s.offsets) == 0 {
+		return 0, nil
+	}
+
+	reminders, err := s.repo.GetDueSurveyReminders(ctx, time.Now(), s.offsets)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, reminder := range reminders {
+		claimed, err := s.repo.ClaimSurveyReminder(ctx, reminder)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.notifier.Notify(reminderNotification(reminder)); err != nil {
+			errs = append(errs, fmt.Errorf("remind user %d of survey %d: %w", reminder.UserID, reminder.SurveyID, err))
+			var partial *PartialDeliveryError
+			if errors.As(err, &partial) {
+				sent++
+				continue
+			}
+			if err := s.repo.ReleaseSurveyReminder(ctx, reminder); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+func reminderNotification(reminder model.SurveyReminder) model.Notification {
+	title := reminder.SurveyTitle
+	if title == "" {
+		title = fmt.Sprintf("#%d", reminder.SurveyID)
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nthe survey %q closes at %s UTC. You have answered %d of %d questions.\n",
+		reminder.Name, title, reminder.ClosesAt.UTC().Format("2006-01-02 15:04"), reminder.Answered, reminder.Questions)
+
+	return model.Notification{
+		Kind:     model.NotificationSurveyReminder,
+		UserID:   reminder.UserID,
+		Email:    reminder.Email,
+		Name:     reminder.Name,
+		Subject:  "Reminder: survey " + title + " closes soon",
+		Body:     body,
+		SurveyID: reminder.SurveyID,
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+)
+
+type reminderKey struct {
+	surveyID, userID, offset int
+}
+
+type fakeReminderRepo struct {
+	due     []model.SurveyReminder
+	claimed map[reminderKey]bool
+}
+
+func (r *fakeReminderRepo) GetDueSurveyReminders(ctx context.Context, now time.Time, offsetMinutes []int) ([]model.SurveyReminder, error) {
+	return r.due, nil
+}
+
+func (r *fakeReminderRepo) ClaimSurveyReminder(ctx context.Context, reminder model.SurveyReminder) (bool, error) {
+	key := reminderKey{reminder.SurveyID, reminder.UserID, reminder.OffsetMinutes}
+	if r.claimed[key] {
+		return false, nil
+	}
+	r.claimed[key] = true
+	return true, nil
+}
+
+func (r *fakeReminderRepo) ReleaseSurveyReminder(ctx context.Context, reminder model.SurveyReminder) error {
+	delete(r.claimed, reminderKey{reminder.SurveyID, reminder.UserID, reminder.OffsetMinutes})
+	return nil
+}
+
+type notifierFunc func(notification model.Notification) error
+
+func (f notifierFunc) Notify(notification model.Notification) error { return f(notification) }
+
+func TestReminderService_SendDueReminders(t *testing.T) {
+	ok := notifierFunc(func(model.Notification) error { return nil })
+	failing := notifierFunc(func(model.Notification) error { return errors.New("channel down") })
+	reminder := model.SurveyReminder{SurveyID: 1, UserID: 2, OffsetMinutes: 240, Questions: 5}
+	key := reminderKey{1, 2, 240}
+
+	testTable := []struct {
+		name            string
+		notifier        Notifier
+		alreadyClaimed  bool
+		expectedSent    int
+		expectedCalls   int
+		expectErr       bool
+		expectedClaimed bool
+	}{
+		{
+			name:            "OK",
+			notifier:        Notifiers{ok, ok},
+			expectedSent:    1,
+			expectedCalls:   1,
+			expectedClaimed: true,
+		},
+		{
+			name:            "Claimed By Another Instance",
+			notifier:        Notifiers{ok},
+			alreadyClaimed:  true,
+			expectedClaimed: true,
+		},
+		{
+			name:          "Every Channel Failed",
+			notifier:      Notifiers{failing, failing},
+			expectedCalls: 1,
+			expectErr:     true,
+		},
+		{
+			name:            "Some Channel Delivered",
+			notifier:        Notifiers{ok, failing},
+			expectedSent:    1,
+			expectedCalls:   1,
+			expectErr:       true,
+			expectedClaimed: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &fakeReminderRepo{due: []model.SurveyReminder{reminder}, claimed: map[reminderKey]bool{}}
+			if testCase.alreadyClaimed {
+				repo.claimed[key] = true
+			}
+			calls := 0
+			notifier := notifierFunc(func(notification model.Notification) error {
+				calls++
+				return testCase.notifier.Notify(notification)
+			})
+			s := NewReminderService(repo, notifier, DefaultReminderOffsets)
+
+			sent, err := s.SendDueReminders(context.Background())
+
+			assert.Equal(t, testCase.expectErr, err != nil)
+			assert.Equal(t, testCase.expectedSent, sent)
+			assert.Equal(t, testCase.expectedCalls, calls)
+			assert.Equal(t, testCase.expectedClaimed, repo.claimed[key])
+		})
+	}
+}
+
+func TestNotifiers_Notify(t *testing.T) {
+	ok := notifierFunc(func(model.Notification) error { return nil })
+	failing := notifierFunc(func(model.Notification) error { return errors.New("channel down") })
+
+	assert.NoError(t, Notifiers{ok, ok}.Notify(model.Notification{}))
+
+	var partial *PartialDeliveryError
+	err := Notifiers{ok, failing}.Notify(model.Notification{})
+	assert.ErrorAs(t, err, &partial)
+
+	err = Notifiers{failing, failing}.Notify(model.Notification{})
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &partial))
+}
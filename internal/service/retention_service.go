@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// DefaultSoftDeleteRetention is how long deleted companies, teams and surveys
+// stay restorable when no retention is configured.
+const DefaultSoftDeleteRetention = 30 * 24 * time.Hour
+
+type RetentionService struct {
+	companies repository.Company
+	teams     repository.Team
+	surveys   repository.Survey
+	retention time.Duration
+}
+
+func NewRetentionService(companies repository.Company, teams repository.Team, surveys repository.Survey, retention time.Duration) *RetentionService {
+	return &RetentionService{companies: companies, teams: teams, surveys: surveys, retention: retention}
+}
+
+// PurgeDeleted hard-deletes everything soft-deleted before the retention
+// window. Children go first so no foreign key points at a purged parent.
+func (s *RetentionService) PurgeDeleted(ctx context.Context) (model.PurgeResult, error) {
+	var result model.PurgeResult
+	before := time.Now().Add(-s.retention)
+
+	var err error
+	if result.Surveys, err = s.surveys.PurgeDeletedSurveys(ctx, before); err != nil {
+		return result, err
+	}
+	if result.Teams, err = s.teams.PurgeDeletedTeams(ctx, before); err != nil {
+		return result, err
+	}
+	if result.Companies, err = s.companies.PurgeDeletedCompanies(ctx, before); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// lifecycleError maps a missing row from a delete, restore or archive call to
+// model.ErrNotFound.
+func lifecycleError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/teamdetected/internal/cron"
+	"github.com/teamdetected/internal/metrics"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// minScheduleInterval keeps a rule such as "* * * * *" from opening a new
+// survey every minute.
+const minScheduleInterval = time.Hour
+
+type ScheduleService struct {
+	repo        repository.Schedule
+	teamRepo    repository.Team
+	companyRepo repository.Company
+}
+
+func NewScheduleService(repo repository.Schedule, teamRepo repository.Team, companyRepo repository.Company) *ScheduleService {
+	return &ScheduleService{repo: repo, teamRepo: teamRepo, companyRepo: companyRepo}
+}
+
+// teamRole returns the requester's role in the company owning the team.
+func (s *ScheduleService) teamRole(ctx context.Context, userID, teamID int) (model.CompanyRole, error) {
+	team, err := s.teamRepo.GetTeamByID(ctx, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", model.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	role, err := s.companyRepo.GetCompanyMemberRole(ctx, team.CompanyID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", model.ErrForbidden
+	}
+	return role, err
+}
+
+// requireScheduleManager lets owners, admins and managers of the team's
+// company manage its schedules.
+func (s *ScheduleService) requireScheduleManager(ctx context.Context, userID, teamID int) error {
+	role, err := s.teamRole(ctx, userID, teamID)
+	if err != nil {
+		return err
+	}
+	if role == model.CompanyRoleViewer {
+		return model.ErrForbidden
+	}
+	return nil
+}
+
+func (s *ScheduleService) CreateSurveySchedule(ctx context.Context, userID, teamID int, input model.CreateSurveyScheduleInput) (model.SurveySchedule, error) {
+	if err := s.requireScheduleManager(ctx, userID, teamID); err != nil {
+		return model.SurveySchedule{}, err
+	}
+
+	schedule := model.SurveySchedule{
+		TeamID:        teamID,
+		Rule:          input.Rule,
+		Timezone:      input.Timezone,
+		TitleTemplate: input.TitleTemplate,
+		DurationHours: input.DurationHours,
+		Enabled:       true,
+		CreatedBy:     userID,
+	}
+	if err := prepareSchedule(&schedule, time.Now()); err != nil {
+		return model.SurveySchedule{}, err
+	}
+
+	created, err := s.repo.CreateSurveySchedule(ctx, schedule)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.SurveySchedule{}, model.ErrNotFound
+	}
+	return created, err
+}
+
+func (s *ScheduleService) GetSurveySchedules(ctx context.Context, userID, teamID int) ([]model.SurveySchedule, error) {
+	if _, err := s.teamRole(ctx, userID, teamID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetSurveySchedulesByTeamID(ctx, teamID)
+}
+
+// UpdateSurveySchedule applies a partial update. The next run is recomputed
+// from now whenever the rule, timezone or enabled flag changes.
+func (s *ScheduleService) UpdateSurveySchedule(ctx context.Context, userID, scheduleID int, input model.UpdateSurveyScheduleInput) (model.SurveySchedule, error) {
+	schedule, err := s.getSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		return model.SurveySchedule{}, err
+	}
+
+	reschedule := false
+	if input.Rule != nil && *input.Rule != schedule.Rule {
+		schedule.Rule = *input.Rule
+		reschedule = true
+	}
+	if input.Timezone != nil && *input.Timezone != schedule.Timezone {
+		schedule.Timezone = *input.Timezone
+		reschedule = true
+	}
+	if input.Enabled != nil && *input.Enabled != schedule.Enabled {
+		schedule.Enabled = *input.Enabled
+		reschedule = true
+	}
+	if input.TitleTemplate != nil {
+		schedule.TitleTemplate = *input.TitleTemplate
+	}
+	if input.DurationHours != nil {
+		schedule.DurationHours = *input.DurationHours
+	}
+
+	next := schedule.NextRunAt
+	if err := prepareSchedule(&schedule, time.Now()); err != nil {
+		return model.SurveySchedule{}, err
+	}
+	if !reschedule {
+		schedule.NextRunAt = next
+	}
+
+	updated, err := s.repo.UpdateSurveySchedule(ctx, schedule)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.SurveySchedule{}, model.ErrNotFound
+	}
+	return updated, err
+}
+
+func (s *ScheduleService) DeleteSurveySchedule(ctx context.Context, userID, scheduleID int) error {
+	if _, err := s.getSchedule(ctx, userID, scheduleID); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.DeleteSurveySchedule(ctx, scheduleID))
+}
+
+// getSchedule loads a schedule the requester may manage.
+func (s *ScheduleService) getSchedule(ctx context.Context, userID, scheduleID int) (model.SurveySchedule, error) {
+	schedule, err := s.repo.GetSurveyScheduleByID(ctx, scheduleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.SurveySchedule{}, model.ErrNotFound
+	}
+	if err != nil {
+		return model.SurveySchedule{}, err
+	}
+
+	if err := s.requireScheduleManager(ctx, userID, schedule.TeamID); err != nil {
+		return model.SurveySchedule{}, err
+	}
+	return schedule, nil
+}
+
+// RunDueSchedules opens the surveys of every schedule that is due. It is safe
+// to call from every instance; only the scheduler leader does any work.
+func (s *ScheduleService) RunDueSchedules(ctx context.Context) (model.SchedulerRun, error) {
+	now := time.Now()
+	run, err := s.repo.RunDueSurveySchedules(ctx, now, func(schedule model.SurveySchedule) (model.SurveyWave, error) {
+		return planWave(schedule, now)
+	})
+	if err == nil {
+		metrics.SurveysCreated.WithLabelValues("schedule").Add(float64(len(run.Opened)))
+	}
+	return run, err
+}
+
+// planWave describes the survey a due schedule opens at now. A schedule that
+// missed runs, e.g. while no instance was up, fires once and then continues
+// from now.
+func planWave(schedule model.SurveySchedule, now time.Time) (model.SurveyWave, error) {
+	rule, loc, err := parseSchedule(schedule)
+	if err != nil {
+		return model.SurveyWave{}, err
+	}
+
+	next := rule.Next(schedule.NextRunAt.In(loc))
+	if !next.After(now) {
+		next = rule.Next(now.In(loc))
+	}
+
+	return model.SurveyWave{
+		Title:     strings.ReplaceAll(schedule.TitleTemplate, "{date}", now.In(loc).Format("2006-01-02")),
+		ClosesAt:  now.Add(time.Duration(schedule.DurationHours) * time.Hour),
+		NextRunAt: next,
+	}, nil
+}
+
+// prepareSchedule normalizes and validates schedule and sets its next run
+// after now.
+func prepareSchedule(schedule *model.SurveySchedule, now time.Time) error {
+	schedule.Rule = strings.TrimSpace(schedule.Rule)
+	schedule.Timezone = strings.TrimSpace(schedule.Timezone)
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	schedule.TitleTemplate = strings.TrimSpace(schedule.TitleTemplate)
+	if schedule.TitleTemplate == "" {
+		schedule.TitleTemplate = model.DefaultSurveyTitleTemplate
+	}
+	if len(schedule.TitleTemplate) > 200 || schedule.DurationHours <= 0 {
+		return model.ErrInvalidInput
+	}
+
+	rule, loc, err := parseSchedule(*schedule)
+	if err != nil {
+		return err
+	}
+
+	first := rule.Next(now.In(loc))
+	if rule.Next(first).Sub(first) < minScheduleInterval {
+		return model.ErrInvalidInput
+	}
+	schedule.NextRunAt = first
+
+	return nil
+}
+
+func parseSchedule(schedule model.SurveySchedule) (cron.Schedule, *time.Location, error) {
+	rule, err := cron.Parse(schedule.Rule)
+	if err != nil {
+		return nil, nil, model.ErrInvalidInput
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, nil, model.ErrInvalidInput
+	}
+	return rule, loc, nil
+}
@@ -1,54 +1,229 @@
 package service
 
 import (
+	"context"
+	"time"
+
+	"github.com/teamdetected/internal/events"
 	"github.com/teamdetected/internal/model"
 	"github.com/teamdetected/internal/repository"
 )
 
 type Service struct {
 	Authorization
+	TwoFactor
+	SSO
+	APIKey
+	Account
 	Company
 	Team
 	Survey
+	Retention
+	Import
+	Schedule
+	Reminder
+	Webhook
+	Outbox
+	Admin
 }
 
 type Authorization interface {
-	CreateUser(user model.User) (int, error)
-	GetUser(email, password string) (model.User, error)
-	GenerateToken(email, password string) (string, error)
-	DeleteUser(id int) error
+	CreateUser(ctx context.Context, user model.User) (int, error)
+	GetUser(ctx context.Context, email, password string) (model.User, error)
+	GenerateToken(ctx context.Context, email, password string) (model.SignInResult, error)
+	AuthenticateToken(ctx context.Context, token string) (model.AccessClaims, error)
+	DeleteUser(ctx context.Context, requester model.Requester, id, transferTo int) error
+}
+
+type TwoFactor interface {
+	EnrollTwoFactor(ctx context.Context, userID int) (model.TwoFactorEnrollment, error)
+	ActivateTwoFactor(ctx context.Context, userID int, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID int, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+	VerifyTwoFactor(ctx context.Context, challengeToken, code, recoveryCode string) (string, error)
+	GetTwoFactorPolicies(ctx context.Context) ([]model.TwoFactorPolicy, error)
+	SetTwoFactorPolicy(ctx context.Context, role string, required bool) error
+}
+
+type SSO interface {
+	GetSSOConfig(ctx context.Context, userID, companyID int) (model.SSOConfig, error)
+	SaveSSOConfig(ctx context.Context, userID int, config model.SSOConfig) error
+	VerifySSODomain(ctx context.Context, userID, companyID int) (model.SSOConfig, error)
+	BeginSSOLogin(ctx context.Context, companyID int) (string, error)
+	BeginSSOLink(ctx context.Context, userID, companyID int) (string, error)
+	CompleteSSOLogin(ctx context.Context, state, code string) (model.SignInResult, error)
+}
+
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, userID int, input model.CreateAPIKeyInput) (model.CreatedAPIKey, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id int) error
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (model.APIKeyPrincipal, error)
+}
+
+type Account interface {
+	GetProfile(ctx context.Context, userID int) (model.User, error)
+	UpdateProfile(ctx context.Context, userID int, input model.UpdateProfileInput) (model.User, error)
+	ChangePassword(ctx context.Context, userID int, input model.ChangePasswordInput) error
+	RequestEmailChange(ctx context.Context, userID int, input model.ChangeEmailInput) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	GetMemberships(ctx context.Context, userID int) (model.UserMemberships, error)
+	GetNotificationSettings(ctx context.Context, userID int) (model.NotificationSettings, error)
+	UpdateNotificationSettings(ctx context.Context, userID int, input model.UpdateNotificationSettingsInput) (model.NotificationSettings, error)
 }
 
 type Company interface {
-	CreateCompany(company model.Company) (int, error)
-	GetCompanyByID(id int) (model.Company, error)
-	GetCompaniesByUserID(userID int) ([]model.Company, error)
-	DeleteCompany(id int) error
+	CreateCompany(ctx context.Context, company model.Company) (int, error)
+	CreateCompanyWithTeam(ctx context.Context, company model.Company, team model.Team) (int, int, error)
+	GetCompanyByID(ctx context.Context, userID, id int) (model.Company, error)
+	GetCompaniesByUserID(ctx context.Context, userID int, query model.ListQuery) (model.Page[model.Company], error)
+	UpdateCompany(ctx context.Context, userID, id int, input model.UpdateCompanyInput, expectedUpdatedAt *time.Time) (model.Company, error)
+	DeleteCompany(ctx context.Context, userID, id int) error
+	RestoreCompany(ctx context.Context, userID, id int) error
+	ArchiveCompany(ctx context.Context, userID, id int) error
+	UnarchiveCompany(ctx context.Context, userID, id int) error
+	GetCompanyMembers(ctx context.Context, userID, companyID int) ([]model.CompanyMember, error)
+	AddCompanyMember(ctx context.Context, userID, companyID int, input model.AddCompanyMemberInput) error
+	UpdateCompanyMember(ctx context.Context, userID, companyID, memberID int, input model.UpdateCompanyMemberInput) error
+	RemoveCompanyMember(ctx context.Context, userID, companyID, memberID int) error
+	TransferCompanyOwnership(ctx context.Context, userID, companyID, newOwnerID int) error
+	AcceptCompanyInvitation(ctx context.Context, userID int, token string) (int, error)
 }
 
 type Team interface {
-	CreateTeam(team model.Team) (int, error)
-	GetTeamByID(id int) (model.Team, error)
-	GetTeamsByCompanyID(companyID int) ([]model.Team, error)
-	DeleteTeam(id int) error
+	CreateTeam(ctx context.Context, team model.Team) (int, error)
+	GetTeamByID(ctx context.Context, userID, id int) (model.Team, error)
+	GetTeamsByCompanyID(ctx context.Context, userID, companyID int, query model.ListQuery) (model.Page[model.Team], error)
+	UpdateTeam(ctx context.Context, userID, id int, input model.UpdateTeamInput, expectedUpdatedAt *time.Time) (model.Team, error)
+	DeleteTeam(ctx context.Context, userID, id int) error
+	RestoreTeam(ctx context.Context, userID, id int) error
+	ArchiveTeam(ctx context.Context, userID, id int) error
+	UnarchiveTeam(ctx context.Context, userID, id int) error
+	GetTeamDescendants(ctx context.Context, userID, id int) ([]model.Team, error)
+	SetTeamParent(ctx context.Context, userID, id int, parentID *int, expectedUpdatedAt *time.Time) (model.Team, error)
+	GetTeamResults(ctx context.Context, userID, id int, rollUp bool) (model.TeamResults, error)
 }
 
 type Survey interface {
-	CreateSurvey(survey model.Survey) (int, error)
-	GetSurveyByID(id int) (model.Survey, error)
-	GetSurveysByTeamID(teamID int) ([]model.Survey, error)
-	DeleteSurvey(id int) error
-	CreateSurveyResponse(response model.SurveyResponse) (int, error)
-	GetSurveyResponses(surveyID int) ([]model.SurveyResponse, error)
-	GetSurveyOptions() ([]model.SurveyOption, error)
-	GetSurveyQuestions() ([]model.SurveyQuestion, error)
+	CreateSurvey(ctx context.Context, survey model.Survey) (int, error)
+	GetSurveyByID(ctx context.Context, userID, id int) (model.Survey, error)
+	GetSurveysByTeamID(ctx context.Context, userID, teamID int, query model.ListQuery) (model.Page[model.Survey], error)
+	UpdateSurvey(ctx context.Context, userID, id int, input model.UpdateSurveyInput, expectedUpdatedAt *time.Time) (model.Survey, error)
+	DeleteSurvey(ctx context.Context, userID, id int) error
+	RestoreSurvey(ctx context.Context, userID, id int) error
+	ArchiveSurvey(ctx context.Context, userID, id int) error
+	UnarchiveSurvey(ctx context.Context, userID, id int) error
+	CreateSurveyResponse(ctx context.Context, response model.SurveyResponse) (int, error)
+	SubmitSurveyResponses(ctx context.Context, userID, surveyID int, answers []model.SurveyAnswer) ([]int, error)
+	GetSurveyResponses(ctx context.Context, userID, surveyID int, query model.ListQuery) (model.Page[model.SurveyResponse], error)
+	GetSurveyOptions(ctx context.Context) ([]model.SurveyOption, error)
+	GetSurveyQuestions(ctx context.Context) ([]model.SurveyQuestion, error)
+	GetSurveyActivity(ctx context.Context) (model.SurveyActivity, error)
+}
+
+type Import interface {
+	ImportCompanyStructure(ctx context.Context, userID, companyID int, fileName string, data []byte, dryRun bool) (model.ImportReport, error)
+}
+
+type Schedule interface {
+	CreateSurveySchedule(ctx context.Context, userID, teamID int, input model.CreateSurveyScheduleInput) (model.SurveySchedule, error)
+	GetSurveySchedules(ctx context.Context, userID, teamID int) ([]model.SurveySchedule, error)
+	UpdateSurveySchedule(ctx context.Context, userID, scheduleID int, input model.UpdateSurveyScheduleInput) (model.SurveySchedule, error)
+	DeleteSurveySchedule(ctx context.Context, userID, scheduleID int) error
+	RunDueSchedules(ctx context.Context) (model.SchedulerRun, error)
+}
+
+type Reminder interface {
+	SendDueReminders(ctx context.Context) (int, error)
 }
 
-func NewService(repos *repository.Repository) *Service {
+type Webhook interface {
+	CreateWebhook(ctx context.Context, userID, companyID int, input model.CreateWebhookInput) (model.WebhookSubscription, error)
+	GetWebhooks(ctx context.Context, userID, companyID int) ([]model.WebhookSubscription, error)
+	UpdateWebhook(ctx context.Context, userID, companyID, webhookID int, input model.UpdateWebhookInput) (model.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, userID, companyID, webhookID int) error
+	GetWebhookDeliveries(ctx context.Context, userID, companyID, webhookID int, query model.ListQuery) (model.Page[model.WebhookDelivery], error)
+	RedeliverWebhook(ctx context.Context, userID, companyID, webhookID, deliveryID int) (model.WebhookDelivery, error)
+	DeliverWebhooks(ctx context.Context) (int, error)
+}
+
+type Outbox interface {
+	RelayEvents(ctx context.Context) (int, error)
+}
+
+// Admin is the operator interface used by the command line.
+type Admin interface {
+	GetCompanies(ctx context.Context, query model.ListQuery) (model.Page[model.Company], error)
+	GetCompany(ctx context.Context, companyID int) (model.Company, error)
+	GetTeams(ctx context.Context, companyID int, query model.ListQuery) (model.Page[model.Team], error)
+	GetTeam(ctx context.Context, teamID int) (model.Team, error)
+	GetSurveys(ctx context.Context, teamID int, query model.ListQuery) (model.Page[model.Survey], error)
+	SetSurveyStatus(ctx context.Context, surveyID int, status string) (model.Survey, error)
+	GetTeamResults(ctx context.Context, teamID int, rollUp bool) (model.TeamResults, error)
+	GetCompanyMembers(ctx context.Context, companyID int) ([]model.CompanyMember, error)
+	ExportCompany(ctx context.Context, companyID int) (model.CompanyExport, error)
+	RotateWebhookSecret(ctx context.Context, webhookID int) (model.WebhookSubscription, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int) error
+	RotateAPIKey(ctx context.Context, keyID int) (model.CreatedAPIKey, error)
+}
+
+type Retention interface {
+	PurgeDeleted(ctx context.Context) (model.PurgeResult, error)
+}
+
+// Options configures NewService; zero fields fall back to defaults.
+type Options struct {
+	// Retention is how long soft-deleted companies, teams and surveys can be
+	// restored before they are purged.
+	Retention time.Duration
+	Mailer    Mailer
+	// Notifier delivers reminders; it defaults to email through Mailer.
+	Notifier Notifier
+	// ReminderOffsets are how long before a survey closes its reminders go
+	// out.
+	ReminderOffsets []time.Duration
+	// EventSinks receive outbox events in addition to the webhook queue.
+	EventSinks []events.Sink
+	Tokens     TokenConfig
+}
+
+// NewService wires the services.
+func NewService(repos *repository.Repository, options Options) *Service {
+	if options.Retention <= 0 {
+		options.Retention = DefaultSoftDeleteRetention
+	}
+	if options.Mailer == nil {
+		options.Mailer = NewLogMailer()
+	}
+	if options.Notifier == nil {
+		options.Notifier = NewEmailNotifier(options.Mailer)
+	}
+	if options.ReminderOffsets == nil {
+		options.ReminderOffsets = DefaultReminderOffsets
+	}
+	retention := options.Retention
+
+	tokens := newTokens(options.Tokens)
+	authService := NewAuthService(repos.Authorization, repos.TwoFactor, repos.Transactor, tokens)
+	webhookService := NewWebhookService(repos.Webhook, repos.Company)
+	sinks := append(events.Sinks{webhookService}, options.EventSinks...)
+
 	return &Service{
-		Authorization: NewAuthService(repos.Authorization),
-		Company:       NewCompanyService(repos.Company),
-		Team:          NewTeamService(repos.Team),
-		Survey:        NewSurveyService(repos.Survey),
+		Authorization: authService,
+		TwoFactor:     NewTwoFactorService(repos.TwoFactor, repos.Authorization, tokens),
+		SSO:           NewSSOService(repos.SSO, repos.Authorization, repos.Company, authService),
+		APIKey:        NewAPIKeyService(repos.APIKey, repos.Authorization),
+		Account:       NewAccountService(repos.Authorization, options.Mailer),
+		Company:       NewCompanyService(repos.Company, repos.Authorization, repos.Transactor, retention, options.Mailer),
+		Team:          NewTeamService(repos.Team, repos.Company, retention),
+		Survey:        NewSurveyService(repos.Survey, repos.Team, repos.Company, repos.Transactor, retention),
+		Retention:     NewRetentionService(repos.Company, repos.Team, repos.Survey, retention),
+		Import:        NewImportService(repos.Import, repos.Company, options.Mailer),
+		Schedule:      NewScheduleService(repos.Schedule, repos.Team, repos.Company),
+		Reminder:      NewReminderService(repos.Reminder, options.Notifier, options.ReminderOffsets),
+		Webhook:       webhookService,
+		Outbox:        NewOutboxService(repos.Outbox, sinks),
+		Admin:         NewAdminService(repos.Company, repos.Team, repos.Survey, repos.Webhook, repos.APIKey),
 	}
 }
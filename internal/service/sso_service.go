@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/oidc"
+	"github.com/teamdetected/internal/repository"
+)
+
+const (
+	ssoLoginTTL    = 10 * time.Minute
+	ssoHTTPTimeout = 10 * time.Second
+	ssoDefaultRole = model.CompanyRoleViewer
+	ssoProviderTTL = time.Hour
+
+	// The company proves it owns its email domain by publishing
+	// ssoDomainRecordPrefix + token in a TXT record at
+	// ssoDomainRecordLabel + "." + domain.
+	ssoDomainRecordLabel  = "_teamdetector-verification"
+	ssoDomainRecordPrefix = "teamdetector-verification="
+)
+
+// errInsecureProvider is returned for an issuer or discovered endpoint that is
+// not an https URL.
+var errInsecureProvider = errors.New("sso provider endpoint is not https")
+
+type cachedProvider struct {
+	provider  *oidc.Provider
+	fetchedAt time.Time
+}
+
+type SSOService struct {
+	repo        repository.SSO
+	usersRepo   repository.Authorization
+	companyRepo repository.Company
+	auth        *AuthService
+	httpClient  *http.Client
+	lookupTXT   func(ctx context.Context, name string) ([]string, error)
+
+	mu        sync.Mutex
+	providers map[string]cachedProvider
+}
+
+func NewSSOService(repo repository.SSO, usersRepo repository.Authorization, companyRepo repository.Company, auth *AuthService) *SSOService {
+	return &SSOService{
+		repo:        repo,
+		usersRepo:   usersRepo,
+		companyRepo: companyRepo,
+		auth:        auth,
+		httpClient:  newPublicClient(ssoHTTPTimeout),
+		lookupTXT:   net.DefaultResolver.LookupTXT,
+		providers:   make(map[string]cachedProvider),
+	}
+}
+
+func (s *SSOService) GetSSOConfig(ctx context.Context, userID, companyID int) (model.SSOConfig, error) {
+	if err := s.checkCompanyAdmin(ctx, userID, companyID); err != nil {
+		return model.SSOConfig{}, err
+	}
+
+	config, err := s.repo.GetSSOConfig(ctx, companyID)
+	if err != nil {
+		return model.SSOConfig{}, err
+	}
+
+	return withDomainRecord(config), nil
+}
+
+func (s *SSOService) SaveSSOConfig(ctx context.Context, userID int, config model.SSOConfig) error {
+	err := s.checkCompanyAdmin(ctx, userID, config.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	if !isHTTPSURL(config.Issuer) {
+		return fmt.Errorf("%w: issuer must be an https url", model.ErrInvalidInput)
+	}
+
+	config.EmailDomain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(config.EmailDomain), "@"))
+	if config.AutoProvision && config.EmailDomain == "" {
+		return model.ErrInvalidInput
+	}
+
+	if config.DefaultRole == "" {
+		config.DefaultRole = ssoDefaultRole
+	}
+	// The role provisioned users get in the company; their platform role is
+	// always model.UserRoleTeam.
+	switch config.DefaultRole {
+	case model.CompanyRoleViewer, model.CompanyRoleManager:
+	default:
+		return model.ErrInvalidInput
+	}
+
+	// The repository keeps the stored token while the domain is unchanged.
+	config.DomainVerificationToken, err = oidc.RandomState()
+	if err != nil {
+		return err
+	}
+
+	return s.repo.SaveSSOConfig(ctx, config)
+}
+
+// VerifySSODomain looks up the company's domain verification TXT record and,
+// if it carries the expected token, marks the email domain as verified.
+func (s *SSOService) VerifySSODomain(ctx context.Context, userID, companyID int) (model.SSOConfig, error) {
+	if err := s.checkCompanyAdmin(ctx, userID, companyID); err != nil {
+		return model.SSOConfig{}, err
+	}
+
+	config, err := s.repo.GetSSOConfig(ctx, companyID)
+	if err != nil {
+		return model.SSOConfig{}, err
+	}
+	if config.EmailDomain == "" {
+		return model.SSOConfig{}, model.ErrInvalidInput
+	}
+	if config.DomainVerifiedAt != nil {
+		return withDomainRecord(config), nil
+	}
+
+	record := domainRecord(config)
+	values, err := s.lookupTXT(ctx, record.Name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return model.SSOConfig{}, model.ErrSSODomainNotVerified
+	}
+	if err != nil {
+		return model.SSOConfig{}, err
+	}
+	if !slices.Contains(values, record.Value) {
+		return model.SSOConfig{}, model.ErrSSODomainNotVerified
+	}
+
+	if err := s.repo.MarkSSODomainVerified(ctx, companyID, config.EmailDomain); err != nil {
+		return model.SSOConfig{}, err
+	}
+
+	config, err = s.repo.GetSSOConfig(ctx, companyID)
+	if err != nil {
+		return model.SSOConfig{}, err
+	}
+
+	return withDomainRecord(config), nil
+}
+
+func domainRecord(config model.SSOConfig) model.TXTRecord {
+	return model.TXTRecord{
+		Name:  ssoDomainRecordLabel + "." + config.EmailDomain,
+		Value: ssoDomainRecordPrefix + config.DomainVerificationToken,
+	}
+}
+
+// withDomainRecord fills in the TXT record an admin still has to publish.
+func withDomainRecord(config model.SSOConfig) model.SSOConfig {
+	if config.EmailDomain != "" && config.DomainVerifiedAt == nil {
+		record := domainRecord(config)
+		config.DomainVerificationRecord = &record
+	}
+	return config
+}
+
+// BeginSSOLogin starts an authorization code login with the company's
+// identity provider and returns the URL to redirect the browser to.
+func (s *SSOService) BeginSSOLogin(ctx context.Context, companyID int) (string, error) {
+	return s.beginLogin(ctx, companyID, 0)
+}
+
+// BeginSSOLink starts the same flow for a signed-in company member; the
+// callback links the provider identity to userID. This is the only way an
+// existing account gets SSO.
+func (s *SSOService) BeginSSOLink(ctx context.Context, userID, companyID int) (string, error) {
+	_, err := s.companyRepo.GetCompanyMemberRole(ctx, companyID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", model.ErrForbidden
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return s.beginLogin(ctx, companyID, userID)
+}
+
+func (s *SSOService) beginLogin(ctx context.Context, companyID, linkUserID int) (string, error) {
+	config, err := s.enabledConfig(ctx, companyID)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := s.client(ctx, config)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := oidc.RandomState()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomState()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.CreateLoginState(ctx, model.OIDCLoginState{
+		State:        state,
+		CompanyID:    companyID,
+		LinkUserID:   linkUserID,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(ssoLoginTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return client.AuthCodeURL(state, nonce, oidc.CodeChallengeS256(verifier)), nil
+}
+
+// CompleteSSOLogin handles the provider callback: it redeems the code, verifies
+// the ID token and signs the matching local user in, or for a link request
+// the user who started it.
+func (s *SSOService) CompleteSSOLogin(ctx context.Context, state, code string) (model.SignInResult, error) {
+	loginState, err := s.repo.ConsumeLoginState(ctx, state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.SignInResult{}, model.ErrSSOLoginExpired
+	}
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	config, err := s.enabledConfig(ctx, loginState.CompanyID)
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	client, err := s.client(ctx, config)
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ssoHTTPTimeout)
+	defer cancel()
+
+	token, err := client.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, loginState.Nonce)
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	var user model.User
+	if loginState.LinkUserID != 0 {
+		user, err = s.linkUser(ctx, loginState.LinkUserID, claims)
+	} else {
+		user, err = s.resolveUser(ctx, config, claims)
+	}
+	if err != nil {
+		return model.SignInResult{}, err
+	}
+
+	return s.auth.signIn(ctx, user)
+}
+
+// resolveUser maps a provider identity to a local user: an already linked
+// identity wins, otherwise a new account is created when the company allows
+// auto-provisioning and has verified that it owns the email's domain. An
+// existing account is never taken over by email; its owner links it with
+// BeginSSOLink.
+func (s *SSOService) resolveUser(ctx context.Context, config model.SSOConfig, claims oidc.Claims) (model.User, error) {
+	user, err := s.repo.GetUserByIdentity(ctx, claims.Issuer, claims.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return model.User{}, model.ErrSSOUserNotAllowed
+	}
+	// Without a verified domain the company's provider could assert any address.
+	if config.EmailDomain == "" || config.DomainVerifiedAt == nil ||
+		!strings.HasSuffix(model.NormalizeEmail(claims.Email), "@"+config.EmailDomain) {
+		return model.User{}, model.ErrSSOUserNotAllowed
+	}
+
+	_, err = s.usersRepo.GetUserByEmail(ctx, claims.Email)
+	if err == nil {
+		return model.User{}, model.ErrSSOUserNotAllowed
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, err
+	}
+
+	if !config.AutoProvision {
+		return model.User{}, model.ErrSSOUserNotAllowed
+	}
+
+	// SSO users never log in with a password, so they get an unusable random one.
+	password, err := oidc.RandomState()
+	if err != nil {
+		return model.User{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+
+	user = model.User{
+		Email:    claims.Email,
+		Password: password,
+		Name:     name,
+		Role:     string(model.UserRoleTeam),
+	}
+
+	user.ID, err = s.repo.ProvisionUser(ctx, user, externalIdentity(claims), config.CompanyID, config.DefaultRole)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+// linkUser links a provider identity to the user who asked for it. An identity
+// already linked to someone else is a conflict.
+func (s *SSOService) linkUser(ctx context.Context, userID int, claims oidc.Claims) (model.User, error) {
+	linked, err := s.repo.GetUserByIdentity(ctx, claims.Issuer, claims.Subject)
+	switch {
+	case err == nil && linked.ID != userID:
+		return model.User{}, model.ErrConflict
+	case err == nil:
+		return linked, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return model.User{}, err
+	}
+
+	user, err := s.usersRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, model.ErrSSOUserNotAllowed
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if err := s.repo.LinkIdentity(ctx, user.ID, externalIdentity(claims)); err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+func externalIdentity(claims oidc.Claims) model.ExternalIdentity {
+	return model.ExternalIdentity{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		Name:    claims.Name,
+	}
+}
+
+func (s *SSOService) enabledConfig(ctx context.Context, companyID int) (model.SSOConfig, error) {
+	config, err := s.repo.GetSSOConfig(ctx, companyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.SSOConfig{}, model.ErrSSONotConfigured
+	}
+	if err != nil {
+		return model.SSOConfig{}, err
+	}
+	if !config.Enabled {
+		return model.SSOConfig{}, model.ErrSSONotConfigured
+	}
+
+	return config, nil
+}
+
+// client returns an OIDC client for config, caching provider discovery per
+// issuer. The issuer and every endpoint it publishes must be https; requests
+// to them go through the public-only client.
+func (s *SSOService) client(ctx context.Context, config model.SSOConfig) (*oidc.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached, ok := s.providers[config.Issuer]
+	if !ok || time.Since(cached.fetchedAt) > ssoProviderTTL {
+		if !isHTTPSURL(config.Issuer) {
+			return nil, fmt.Errorf("%w: %s", errInsecureProvider, config.Issuer)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, ssoHTTPTimeout)
+		defer cancel()
+
+		provider, err := oidc.Discover(ctx, s.httpClient, config.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		for _, endpoint := range []string{provider.AuthorizationEndpoint, provider.TokenEndpoint, provider.JWKSURI} {
+			if !isHTTPSURL(endpoint) {
+				return nil, fmt.Errorf("%w: %s", errInsecureProvider, endpoint)
+			}
+		}
+		cached = cachedProvider{provider: provider, fetchedAt: time.Now()}
+		s.providers[config.Issuer] = cached
+	}
+
+	return oidc.NewClient(cached.provider, oidc.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  config.RedirectURL,
+	}), nil
+}
+
+func (s *SSOService) checkCompanyAdmin(ctx context.Context, userID, companyID int) error {
+	return requireCompanyAccess(ctx, s.companyRepo, userID, companyID, accessAdmin)
+}
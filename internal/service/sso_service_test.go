@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/oidc"
+	"github.com/teamdetected/internal/repository"
+)
+
+const testIssuer = "https://idp.example.com"
+
+var domainVerifiedAt = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ssoStore backs the repository fakes of the SSO tests. Company 1 is the one
+// the provider belongs to.
+type ssoStore struct {
+	users       map[int]model.User
+	members     map[int]model.CompanyRole
+	identities  map[string]int
+	provisioned []model.User
+	config      model.SSOConfig
+}
+
+func newSSOStore() *ssoStore {
+	return &ssoStore{
+		users: map[int]model.User{
+			1: {ID: 1, Email: "linked@example.com"},
+			2: {ID: 2, Email: "member@example.com"},
+			3: {ID: 3, Email: "outsider@example.com"},
+		},
+		members:    map[int]model.CompanyRole{1: model.CompanyRoleViewer, 2: model.CompanyRoleViewer},
+		identities: map[string]int{testIssuer + "|sub-1": 1},
+	}
+}
+
+type fakeSSORepo struct {
+	repository.SSO
+	store *ssoStore
+}
+
+func (r fakeSSORepo) GetUserByIdentity(ctx context.Context, issuer, subject string) (model.User, error) {
+	id, ok := r.store.identities[issuer+"|"+subject]
+	if !ok {
+		return model.User{}, sql.ErrNoRows
+	}
+	return r.store.users[id], nil
+}
+
+func (r fakeSSORepo) LinkIdentity(ctx context.Context, userID int, identity model.ExternalIdentity) error {
+	r.store.identities[identity.Issuer+"|"+identity.Subject] = userID
+	return nil
+}
+
+func (r fakeSSORepo) ProvisionUser(ctx context.Context, user model.User, identity model.ExternalIdentity, companyID int, role model.CompanyRole) (int, error) {
+	user.ID = len(r.store.users) + 1
+	r.store.users[user.ID] = user
+	r.store.members[user.ID] = role
+	r.store.identities[identity.Issuer+"|"+identity.Subject] = user.ID
+	r.store.provisioned = append(r.store.provisioned, user)
+	return user.ID, nil
+}
+
+func (r fakeSSORepo) GetSSOConfig(ctx context.Context, companyID int) (model.SSOConfig, error) {
+	if companyID != r.store.config.CompanyID {
+		return model.SSOConfig{}, sql.ErrNoRows
+	}
+	return r.store.config, nil
+}
+
+func (r fakeSSORepo) SaveSSOConfig(ctx context.Context, config model.SSOConfig) error {
+	r.store.config = config
+	return nil
+}
+
+func (r fakeSSORepo) MarkSSODomainVerified(ctx context.Context, companyID int, domain string) error {
+	if r.store.config.EmailDomain == domain {
+		r.store.config.DomainVerifiedAt = &domainVerifiedAt
+	}
+	return nil
+}
+
+type fakeUsersRepo struct {
+	repository.Authorization
+	store *ssoStore
+}
+
+func (r fakeUsersRepo) GetUserByID(ctx context.Context, id int) (model.User, error) {
+	user, ok := r.store.users[id]
+	if !ok {
+		return model.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (r fakeUsersRepo) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	for _, user := range r.store.users {
+		if user.Email == model.NormalizeEmail(email) {
+			return user, nil
+		}
+	}
+	return model.User{}, sql.ErrNoRows
+}
+
+type fakeCompanyRepo struct {
+	repository.Company
+	store *ssoStore
+}
+
+func (r fakeCompanyRepo) GetCompanyMemberRole(ctx context.Context, companyID, userID int) (model.CompanyRole, error) {
+	role, ok := r.store.members[userID]
+	if companyID != 1 || !ok {
+		return "", sql.ErrNoRows
+	}
+	return role, nil
+}
+
+func newTestSSOService(store *ssoStore) *SSOService {
+	return NewSSOService(fakeSSORepo{store: store}, fakeUsersRepo{store: store}, fakeCompanyRepo{store: store}, nil)
+}
+
+func TestSSOService_resolveUser(t *testing.T) {
+	testTable := []struct {
+		name            string
+		config          model.SSOConfig
+		claims          oidc.Claims
+		expectedUserID  int
+		expectedErr     error
+		expectedLinked  bool
+		expectProvision bool
+	}{
+		{
+			name:           "Linked Identity",
+			config:         model.SSOConfig{CompanyID: 1},
+			claims:         oidc.Claims{Issuer: testIssuer, Subject: "sub-1"},
+			expectedUserID: 1,
+			expectedLinked: true,
+		},
+		{
+			name: "Existing Member Is Not Linked By Email",
+			config: model.SSOConfig{CompanyID: 1, EmailDomain: "example.com", AutoProvision: true,
+				DomainVerifiedAt: &domainVerifiedAt},
+			claims:      oidc.Claims{Issuer: testIssuer, Subject: "sub-2", Email: "member@example.com", EmailVerified: true},
+			expectedErr: model.ErrSSOUserNotAllowed,
+		},
+		{
+			name: "Existing User With Other Case",
+			config: model.SSOConfig{CompanyID: 1, EmailDomain: "example.com", AutoProvision: true,
+				DomainVerifiedAt: &domainVerifiedAt},
+			claims:      oidc.Claims{Issuer: testIssuer, Subject: "sub-3", Email: "Outsider@Example.com", EmailVerified: true},
+			expectedErr: model.ErrSSOUserNotAllowed,
+		},
+		{
+			name: "Existing User Outside Company",
+			config: model.SSOConfig{CompanyID: 1, EmailDomain: "example.com", AutoProvision: true,
+				DomainVerifiedAt: &domainVerifiedAt},
+			claims:      oidc.Claims{Issuer: testIssuer, Subject: "sub-3", Email: "outsider@example.com", EmailVerified: true},
+			expectedErr: model.ErrSSOUserNotAllowed,
+		},
+		{
+			name:        "Unverified Email",
+			config:      model.SSOConfig{CompanyID: 1, EmailDomain: "example.com", AutoProvision: true, DomainVerifiedAt: &domainVerifiedAt},
+			claims:      oidc.Claims{Issuer: testIssuer, Subject: "sub-4", Email: "new@example.com"},
+			expectedErr: model.ErrSSOUserNotAllowed,
+		},
+		{
+			name: "New User With Auto-Provisioning",
+			config: model.SSOConfig{CompanyID: 1, EmailDomain: "example.com", AutoProvision: true,
+				DefaultRole: model.CompanyRoleManager, DomainVerifiedAt: &domainVerifiedAt},
+			claims:          oidc.Claims{Issuer: testIssuer, Subject: "sub-4", Email: "new@example.com", EmailVerified: true},
+			expectedUserID:  4,
+			expectedLinked:  true,
+			expectProvision: true,
+		},
+		{
+			name:        "New User With Unverified Domain",
+			config:      model.SSOConfig{CompanyID: 1, EmailDomain: "example.com", AutoProvision: true},
+			claims:      oidc.Claims{Issuer: testIssuer, Subject: "sub-4", Email: "new@example.com", EmailVerified: true},
+			expectedErr: model.ErrSSOUserNotAllowed,
+		},
+		{
+			name: "New User With Other Domain",
+			config: model.SSOConfig{CompanyID: 1, EmailDomain: "corp.example.org", AutoProvision: true,
+				DomainVerifiedAt: &domainVerifiedAt},
+			claims:      oidc.Claims{Issuer: testIssuer, Subject: "sub-4", Email: "new@example.com", EmailVerified: true},
+			expectedErr: model.ErrSSOUserNotAllowed,
+		},
+		{
+			name:        "New User Without Auto-Provisioning",
+			config:      model.SSOConfig{CompanyID: 1, EmailDomain: "example.com", DomainVerifiedAt: &domainVerifiedAt},
+			claims:      oidc.Claims{Issuer: testIssuer, Subject: "sub-4", Email: "new@example.com", EmailVerified: true},
+			expectedErr: model.ErrSSOUserNotAllowed,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := newSSOStore()
+			s := newTestSSOService(store)
+
+			user, err := s.resolveUser(context.Background(), testCase.config, testCase.claims)
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
+			assert.Equal(t, testCase.expectedUserID, user.ID)
+			_, linked := store.identities[testCase.claims.Issuer+"|"+testCase.claims.Subject]
+			assert.Equal(t, testCase.expectedLinked, linked)
+			assert.Equal(t, testCase.expectProvision, len(store.provisioned) == 1)
+			if testCase.expectProvision {
+				assert.Equal(t, string(model.UserRoleTeam), store.provisioned[0].Role)
+				assert.Equal(t, testCase.config.DefaultRole, store.members[user.ID])
+			}
+		})
+	}
+}
+
+func TestSSOService_linkUser(t *testing.T) {
+	testTable := []struct {
+		name           string
+		userID         int
+		subject        string
+		expectedUserID int
+		expectedErr    error
+	}{
+		{
+			name:           "OK",
+			userID:         3,
+			subject:        "sub-3",
+			expectedUserID: 3,
+		},
+		{
+			name:           "Already Linked To User",
+			userID:         1,
+			subject:        "sub-1",
+			expectedUserID: 1,
+		},
+		{
+			name:        "Linked To Another User",
+			userID:      3,
+			subject:     "sub-1",
+			expectedErr: model.ErrConflict,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := newSSOStore()
+			s := newTestSSOService(store)
+
+			claims := oidc.Claims{Issuer: testIssuer, Subject: testCase.subject, Email: "someone@elsewhere.test"}
+			user, err := s.linkUser(context.Background(), testCase.userID, claims)
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
+			assert.Equal(t, testCase.expectedUserID, user.ID)
+			if testCase.expectedErr == nil {
+				assert.Equal(t, testCase.userID, store.identities[testIssuer+"|"+testCase.subject])
+			}
+		})
+	}
+}
+
+func TestSSOService_VerifySSODomain(t *testing.T) {
+	testTable := []struct {
+		name         string
+		userID       int
+		records      map[string][]string
+		expectedErr  error
+		expectVerify bool
+	}{
+		{
+			name:   "OK",
+			userID: 1,
+			records: map[string][]string{
+				"_teamdetector-verification.example.com": {"v=spf1 -all", "teamdetector-verification=token"},
+			},
+			expectVerify: true,
+		},
+		{
+			name:        "Wrong Token",
+			userID:      1,
+			records:     map[string][]string{"_teamdetector-verification.example.com": {"teamdetector-verification=other"}},
+			expectedErr: model.ErrSSODomainNotVerified,
+		},
+		{
+			name:        "No Record",
+			userID:      1,
+			expectedErr: model.ErrSSODomainNotVerified,
+		},
+		{
+			name:        "Not A Company Admin",
+			userID:      2,
+			expectedErr: model.ErrForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			store := newSSOStore()
+			store.members[1] = model.CompanyRoleOwner
+			store.config = model.SSOConfig{CompanyID: 1, EmailDomain: "example.com", DomainVerificationToken: "token"}
+			s := newTestSSOService(store)
+			s.lookupTXT = func(ctx context.Context, name string) ([]string, error) {
+				values, ok := testCase.records[name]
+				if !ok {
+					return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+				}
+				return values, nil
+			}
+
+			config, err := s.VerifySSODomain(context.Background(), testCase.userID, 1)
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
+			assert.Equal(t, testCase.expectVerify, store.config.DomainVerifiedAt != nil)
+			if testCase.expectVerify {
+				assert.NotNil(t, config.DomainVerifiedAt)
+				assert.Nil(t, config.DomainVerificationRecord)
+			}
+		})
+	}
+}
+
+func TestSSOService_SaveSSOConfig_DefaultRole(t *testing.T) {
+	testTable := []struct {
+		role         model.CompanyRole
+		expectedRole model.CompanyRole
+		expectedErr  error
+	}{
+		{role: "", expectedRole: model.CompanyRoleViewer},
+		{role: model.CompanyRoleManager, expectedRole: model.CompanyRoleManager},
+		{role: model.CompanyRoleAdmin, expectedErr: model.ErrInvalidInput},
+		{role: model.CompanyRoleOwner, expectedErr: model.ErrInvalidInput},
+		{role: "team", expectedErr: model.ErrInvalidInput},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(string(testCase.role), func(t *testing.T) {
+			store := newSSOStore()
+			store.members[1] = model.CompanyRoleOwner
+			s := newTestSSOService(store)
+
+			err := s.SaveSSOConfig(context.Background(), 1, model.SSOConfig{CompanyID: 1, Issuer: testIssuer, DefaultRole: testCase.role})
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
+			assert.Equal(t, testCase.expectedRole, store.config.DefaultRole)
+		})
+	}
+}
+
+func TestSSOService_SaveSSOConfig_Issuer(t *testing.T) {
+	store := newSSOStore()
+	store.members[1] = model.CompanyRoleOwner
+	s := newTestSSOService(store)
+
+	err := s.SaveSSOConfig(context.Background(), 1, model.SSOConfig{CompanyID: 1, Issuer: "http://idp.example.com"})
+
+	assert.ErrorIs(t, err, model.ErrInvalidInput)
+}
+
+func TestSSOService_client(t *testing.T) {
+	var endpoints map[string]string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(endpoints)
+	}))
+	defer server.Close()
+
+	testTable := []struct {
+		name        string
+		issuer      string
+		endpoints   map[string]string
+		trustServer bool
+		expectedErr error
+	}{
+		{
+			name:   "OK",
+			issuer: server.URL,
+			endpoints: map[string]string{
+				"authorization_endpoint": server.URL + "/authorize",
+				"token_endpoint":         server.URL + "/token",
+				"jwks_uri":               server.URL + "/jwks",
+			},
+			trustServer: true,
+		},
+		{
+			name:   "Plain HTTP Endpoint",
+			issuer: server.URL,
+			endpoints: map[string]string{
+				"authorization_endpoint": server.URL + "/authorize",
+				"token_endpoint":         "http://169.254.169.254/token",
+				"jwks_uri":               server.URL + "/jwks",
+			},
+			trustServer: true,
+			expectedErr: errInsecureProvider,
+		},
+		{
+			name:        "Plain HTTP Issuer",
+			issuer:      "http://idp.example.com",
+			expectedErr: errInsecureProvider,
+		},
+		{
+			name:        "Private Address",
+			issuer:      server.URL,
+			expectedErr: errPrivateAddress,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			endpoints = map[string]string{"issuer": server.URL}
+			for key, value := range testCase.endpoints {
+				endpoints[key] = value
+			}
+			s := newTestSSOService(newSSOStore())
+			if testCase.trustServer {
+				s.httpClient = server.Client()
+			}
+
+			_, err := s.client(context.Background(), model.SSOConfig{Issuer: testCase.issuer})
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
@@ -1,19 +1,58 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/teamdetected/internal/metrics"
 	"github.com/teamdetected/internal/model"
 	"github.com/teamdetected/internal/repository"
 )
 
 type SurveyService struct {
-	repo repository.Survey
+	repo        repository.Survey
+	teamRepo    repository.Team
+	companyRepo repository.Company
+	tx          repository.Transactor
+	retention   time.Duration
+}
+
+func NewSurveyService(repo repository.Survey, teamRepo repository.Team, companyRepo repository.Company, tx repository.Transactor, retention time.Duration) *SurveyService {
+	return &SurveyService{repo: repo, teamRepo: teamRepo, companyRepo: companyRepo, tx: tx, retention: retention}
+}
+
+// requireTeamAccess checks the requester's role in the company owning the team.
+func (s *SurveyService) requireTeamAccess(ctx context.Context, userID, teamID int, a access) error {
+	companyID, err := s.teamRepo.GetTeamCompanyID(ctx, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return requireCompanyAccess(ctx, s.companyRepo, userID, companyID, a)
 }
 
-func NewSurveyService(repo repository.Survey) *SurveyService {
-	return &SurveyService{repo: repo}
+// requireAccess checks the requester's role in the company owning the
+// survey, which may be deleted or archived.
+func (s *SurveyService) requireAccess(ctx context.Context, userID, surveyID int, a access) error {
+	companyID, err := s.repo.GetSurveyCompanyID(ctx, surveyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return requireCompanyAccess(ctx, s.companyRepo, userID, companyID, a)
 }
 
-func (s *SurveyService) CreateSurvey(survey model.Survey) (int, error) {
+// CreateSurvey opens a survey on behalf of survey.CreatedBy, who must manage
+// the team's company.
+func (s *SurveyService) CreateSurvey(ctx context.Context, survey model.Survey) (int, error) {
 	if survey.TeamID == 0 {
 		return 0, model.ErrInvalidInput
 	}
@@ -21,37 +60,160 @@ func (s *SurveyService) CreateSurvey(survey model.Survey) (int, error) {
 		return 0, model.ErrInvalidInput
 	}
 
-	survey.Status = "active"
-	return s.repo.CreateSurvey(survey)
+	if err := s.requireTeamAccess(ctx, survey.CreatedBy, survey.TeamID, accessManage); err != nil {
+		return 0, err
+	}
+
+	survey.Status = model.SurveyStatusActive
+	id, err := s.repo.CreateSurvey(ctx, survey)
+	if err == nil {
+		metrics.SurveysCreated.WithLabelValues("api").Inc()
+	}
+	return id, err
+}
+
+func (s *SurveyService) GetSurveyByID(ctx context.Context, userID, id int) (model.Survey, error) {
+	if err := s.requireAccess(ctx, userID, id, accessRead); err != nil {
+		return model.Survey{}, err
+	}
+
+	return s.repo.GetSurveyByID(ctx, id)
+}
+
+func (s *SurveyService) GetSurveysByTeamID(ctx context.Context, userID, teamID int, query model.ListQuery) (model.Page[model.Survey], error) {
+	switch query.Status {
+	case "", model.SurveyStatusActive, model.SurveyStatusCompleted:
+	default:
+		return model.Page[model.Survey]{}, model.ErrInvalidInput
+	}
+
+	if err := s.requireTeamAccess(ctx, userID, teamID, accessRead); err != nil {
+		return model.Page[model.Survey]{}, err
+	}
+
+	return s.repo.GetSurveysByTeamID(ctx, teamID, query)
+}
+
+func (s *SurveyService) UpdateSurvey(ctx context.Context, userID, id int, input model.UpdateSurveyInput, expectedUpdatedAt *time.Time) (model.Survey, error) {
+	if input.Status != nil {
+		switch *input.Status {
+		case model.SurveyStatusActive, model.SurveyStatusCompleted:
+		default:
+			return model.Survey{}, model.ErrInvalidInput
+		}
+	}
+
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return model.Survey{}, err
+	}
+
+	survey, err := s.repo.UpdateSurvey(ctx, id, input, expectedUpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Survey{}, model.ErrNotFound
+	}
+	return survey, err
+}
+
+func (s *SurveyService) DeleteSurvey(ctx context.Context, userID, id int) error {
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.DeleteSurvey(ctx, id))
 }
 
-func (s *SurveyService) GetSurveyByID(id int) (model.Survey, error) {
-	return s.repo.GetSurveyByID(id)
+func (s *SurveyService) RestoreSurvey(ctx context.Context, userID, id int) error {
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.RestoreSurvey(ctx, id, time.Now().Add(-s.retention)))
 }
 
-func (s *SurveyService) GetSurveysByTeamID(teamID int) ([]model.Survey, error) {
-	return s.repo.GetSurveysByTeamID(teamID)
+func (s *SurveyService) ArchiveSurvey(ctx context.Context, userID, id int) error {
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.ArchiveSurvey(ctx, id))
 }
 
-func (s *SurveyService) DeleteSurvey(id int) error {
-	return s.repo.DeleteSurvey(id)
+func (s *SurveyService) UnarchiveSurvey(ctx context.Context, userID, id int) error {
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.UnarchiveSurvey(ctx, id))
 }
 
-func (s *SurveyService) CreateSurveyResponse(response model.SurveyResponse) (int, error) {
+func (s *SurveyService) CreateSurveyResponse(ctx context.Context, response model.SurveyResponse) (int, error) {
 	if response.SurveyID == 0 || response.UserID == 0 || response.QuestionID == 0 || response.OptionID == 0 {
 		return 0, model.ErrInvalidInput
 	}
-	return s.repo.CreateSurveyResponse(response)
+
+	id, err := s.repo.CreateSurveyResponse(ctx, response)
+	if err == nil {
+		metrics.SurveyResponsesSubmitted.Inc()
+	}
+	return id, err
+}
+
+// SubmitSurveyResponses stores all answers of a user to a survey at once. If
+// any answer is rejected none is stored.
+func (s *SurveyService) SubmitSurveyResponses(ctx context.Context, userID, surveyID int, answers []model.SurveyAnswer) ([]int, error) {
+	if userID == 0 || surveyID == 0 || len(answers) == 0 {
+		return nil, model.ErrInvalidInput
+	}
+	for _, answer := range answers {
+		if answer.QuestionID == 0 || answer.OptionID == 0 {
+			return nil, model.ErrInvalidInput
+		}
+	}
+
+	var ids []int
+	err := s.tx.WithinTx(ctx, func(repos *repository.Repository) error {
+		ids = make([]int, 0, len(answers))
+		for _, answer := range answers {
+			id, err := repos.Survey.CreateSurveyResponse(ctx, model.SurveyResponse{
+				SurveyID:   surveyID,
+				UserID:     userID,
+				QuestionID: answer.QuestionID,
+				OptionID:   answer.OptionID,
+			})
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.SurveyResponsesSubmitted.Add(float64(len(ids)))
+	return ids, nil
+}
+
+// GetSurveyResponses lists individual answers, so viewers, who only see team
+// results, may not read them.
+func (s *SurveyService) GetSurveyResponses(ctx context.Context, userID, surveyID int, query model.ListQuery) (model.Page[model.SurveyResponse], error) {
+	if err := s.requireAccess(ctx, userID, surveyID, accessManage); err != nil {
+		return model.Page[model.SurveyResponse]{}, err
+	}
+
+	return s.repo.GetSurveyResponses(ctx, surveyID, query)
 }
 
-func (s *SurveyService) GetSurveyResponses(surveyID int) ([]model.SurveyResponse, error) {
-	return s.repo.GetSurveyResponses(surveyID)
+func (s *SurveyService) GetSurveyOptions(ctx context.Context) ([]model.SurveyOption, error) {
+	return s.repo.GetSurveyOptions(ctx)
 }
 
-func (s *SurveyService) GetSurveyOptions() ([]model.SurveyOption, error) {
-	return s.repo.GetSurveyOptions()
+func (s *SurveyService) GetSurveyQuestions(ctx context.Context) ([]model.SurveyQuestion, error) {
+	return s.repo.GetSurveyQuestions(ctx)
 }
 
-func (s *SurveyService) GetSurveyQuestions() ([]model.SurveyQuestion, error) {
-	return s.repo.GetSurveyQuestions()
+// GetSurveyActivity summarises the active surveys for monitoring.
+func (s *SurveyService) GetSurveyActivity(ctx context.Context) (model.SurveyActivity, error) {
+	return s.repo.GetSurveyActivity(ctx)
 }
@@ -1,30 +1,157 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/teamdetected/internal/model"
 	"github.com/teamdetected/internal/repository"
 )
 
 type TeamService struct {
-	repo repository.Team
+	repo        repository.Team
+	companyRepo repository.Company
+	retention   time.Duration
+}
+
+func NewTeamService(repo repository.Team, companyRepo repository.Company, retention time.Duration) *TeamService {
+	return &TeamService{repo: repo, companyRepo: companyRepo, retention: retention}
+}
+
+// requireAccess checks the requester's role in the company owning the team,
+// which may be deleted or archived.
+func (s *TeamService) requireAccess(ctx context.Context, userID, teamID int, a access) error {
+	companyID, err := s.repo.GetTeamCompanyID(ctx, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return requireCompanyAccess(ctx, s.companyRepo, userID, companyID, a)
+}
+
+// CreateTeam creates a team on behalf of team.CreatedBy, who must manage the
+// company. It fails with model.ErrInvalidInput when the company is deleted or
+// the parent team does not belong to it.
+func (s *TeamService) CreateTeam(ctx context.Context, team model.Team) (int, error) {
+	if err := requireCompanyAccess(ctx, s.companyRepo, team.CreatedBy, team.CompanyID, accessManage); err != nil {
+		return 0, err
+	}
+
+	id, err := s.repo.CreateTeam(ctx, team)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrInvalidInput
+	}
+	return id, err
 }
 
-func NewTeamService(repo repository.Team) *TeamService {
-	return &TeamService{repo: repo}
+func (s *TeamService) GetTeamByID(ctx context.Context, userID, id int) (model.Team, error) {
+	if err := s.requireAccess(ctx, userID, id, accessRead); err != nil {
+		return model.Team{}, err
+	}
+
+	return s.repo.GetTeamByID(ctx, id)
 }
 
-func (s *TeamService) CreateTeam(team model.Team) (int, error) {
-	return s.repo.CreateTeam(team)
+func (s *TeamService) GetTeamsByCompanyID(ctx context.Context, userID, companyID int, query model.ListQuery) (model.Page[model.Team], error) {
+	if err := requireCompanyAccess(ctx, s.companyRepo, userID, companyID, accessRead); err != nil {
+		return model.Page[model.Team]{}, err
+	}
+
+	return s.repo.GetTeamsByCompanyID(ctx, companyID, query)
 }
 
-func (s *TeamService) GetTeamByID(id int) (model.Team, error) {
-	return s.repo.GetTeamByID(id)
+func (s *TeamService) UpdateTeam(ctx context.Context, userID, id int, input model.UpdateTeamInput, expectedUpdatedAt *time.Time) (model.Team, error) {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return model.Team{}, model.ErrInvalidInput
+		}
+		input.Name = &name
+	}
+
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return model.Team{}, err
+	}
+
+	team, err := s.repo.UpdateTeam(ctx, id, input, expectedUpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Team{}, model.ErrNotFound
+	}
+	return team, err
 }
 
-func (s *TeamService) GetTeamsByCompanyID(companyID int) ([]model.Team, error) {
-	return s.repo.GetTeamsByCompanyID(companyID)
+func (s *TeamService) DeleteTeam(ctx context.Context, userID, id int) error {
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.DeleteTeam(ctx, id))
 }
 
-func (s *TeamService) DeleteTeam(id int) error {
-	return s.repo.DeleteTeam(id)
+func (s *TeamService) RestoreTeam(ctx context.Context, userID, id int) error {
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.RestoreTeam(ctx, id, time.Now().Add(-s.retention)))
+}
+
+func (s *TeamService) ArchiveTeam(ctx context.Context, userID, id int) error {
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.ArchiveTeam(ctx, id))
+}
+
+func (s *TeamService) UnarchiveTeam(ctx context.Context, userID, id int) error {
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.UnarchiveTeam(ctx, id))
+}
+
+func (s *TeamService) GetTeamDescendants(ctx context.Context, userID, id int) ([]model.Team, error) {
+	if err := s.requireAccess(ctx, userID, id, accessRead); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetTeamByID(ctx, id); err != nil {
+		return nil, lifecycleError(err)
+	}
+
+	return s.repo.GetTeamDescendants(ctx, id)
+}
+
+func (s *TeamService) SetTeamParent(ctx context.Context, userID, id int, parentID *int, expectedUpdatedAt *time.Time) (model.Team, error) {
+	if parentID != nil && *parentID == id {
+		return model.Team{}, model.ErrInvalidInput
+	}
+
+	if err := s.requireAccess(ctx, userID, id, accessManage); err != nil {
+		return model.Team{}, err
+	}
+
+	team, err := s.repo.SetTeamParent(ctx, id, parentID, expectedUpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Team{}, model.ErrNotFound
+	}
+	return team, err
+}
+
+func (s *TeamService) GetTeamResults(ctx context.Context, userID, id int, rollUp bool) (model.TeamResults, error) {
+	if err := s.requireAccess(ctx, userID, id, accessRead); err != nil {
+		return model.TeamResults{}, err
+	}
+	if _, err := s.repo.GetTeamByID(ctx, id); err != nil {
+		return model.TeamResults{}, lifecycleError(err)
+	}
+
+	return s.repo.GetTeamResults(ctx, id, rollUp)
 }
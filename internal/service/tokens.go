@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/teamdetected/internal/model"
+)
+
+const (
+	// DefaultAccessTokenTTL and DefaultChallengeTokenTTL are used when
+	// TokenConfig leaves them zero.
+	DefaultAccessTokenTTL    = 12 * time.Hour
+	DefaultChallengeTokenTTL = 5 * time.Minute
+
+	tokenPurposeTwoFactorChallenge = "2fa_challenge"
+)
+
+// TokenConfig configures the JWTs issued at login.
+type TokenConfig struct {
+	// SigningKey is the HMAC key access and challenge tokens are signed with.
+	SigningKey string
+	// PreviousKeys still verify tokens signed before the signing key was
+	// rotated. They can be dropped once AccessTTL has passed since.
+	PreviousKeys []string
+	AccessTTL    time.Duration
+	ChallengeTTL time.Duration
+}
+
+// tokens issues and checks the JWTs shared by AuthService and
+// TwoFactorService.
+type tokens struct {
+	key          []byte
+	previousKeys [][]byte
+	accessTTL    time.Duration
+	challengeTTL time.Duration
+}
+
+func newTokens(config TokenConfig) *tokens {
+	if config.AccessTTL <= 0 {
+		config.AccessTTL = DefaultAccessTokenTTL
+	}
+	if config.ChallengeTTL <= 0 {
+		config.ChallengeTTL = DefaultChallengeTokenTTL
+	}
+	previousKeys := make([][]byte, len(config.PreviousKeys))
+	for i, key := range config.PreviousKeys {
+		previousKeys[i] = []byte(key)
+	}
+	return &tokens{
+		key:          []byte(config.SigningKey),
+		previousKeys: previousKeys,
+		accessTTL:    config.AccessTTL,
+		challengeTTL: config.ChallengeTTL,
+	}
+}
+
+// newAccessToken signs an API access token. Tokens issued while the role
+// policy requires two-factor authentication that the user has not set up yet
+// are limited to the enrollment endpoints.
+func (t *tokens) newAccessToken(user model.User, twoFactorSetupRequired bool) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"role":    user.Role,
+		"exp":     time.Now().Add(t.accessTTL).Unix(),
+	}
+	if twoFactorSetupRequired {
+		claims["2fa_setup_required"] = true
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(t.key)
+}
+
+// newChallengeToken issues a login challenge bound to the user's current
+// challenge version; see model.TwoFactorSecret.
+func (t *tokens) newChallengeToken(userID, version int) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"version": version,
+		"purpose": tokenPurposeTwoFactorChallenge,
+		"exp":     time.Now().Add(t.challengeTTL).Unix(),
+	})
+
+	return token.SignedString(t.key)
+}
+
+// parse verifies a token with the signing key or, failing that, with any
+// previous key.
+func (t *tokens) parse(rawToken string) (jwt.MapClaims, error) {
+	token, err := parseWithKey(rawToken, t.key)
+	for _, key := range t.previousKeys {
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+		token, err = parseWithKey(rawToken, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// parseAccessToken checks an access token. Its errors are meant for the
+// client.
+func (t *tokens) parseAccessToken(rawToken string) (model.AccessClaims, error) {
+	claims, err := t.parse(rawToken)
+	if err != nil {
+		return model.AccessClaims{}, err
+	}
+
+	// Challenge tokens from the first login step are not access tokens.
+	if _, ok := claims["purpose"]; ok {
+		return model.AccessClaims{}, errors.New("invalid token purpose")
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return model.AccessClaims{}, errors.New("invalid user id")
+	}
+
+	role, _ := claims["role"].(string)
+	setupRequired, _ := claims["2fa_setup_required"].(bool)
+
+	return model.AccessClaims{UserID: int(userID), Role: role, TwoFactorSetupRequired: setupRequired}, nil
+}
+
+// parseChallengeToken returns the user and challenge version of a login
+// challenge.
+func (t *tokens) parseChallengeToken(challengeToken string) (int, int, error) {
+	claims, err := t.parse(challengeToken)
+	if err != nil || claims["purpose"] != tokenPurposeTwoFactorChallenge {
+		return 0, 0, model.ErrUnauthorized
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, 0, model.ErrUnauthorized
+	}
+	version, ok := claims["version"].(float64)
+	if !ok {
+		return 0, 0, model.ErrUnauthorized
+	}
+
+	return int(userID), int(version), nil
+}
+
+func parseWithKey(rawToken string, key []byte) (*jwt.Token, error) {
+	return jwt.Parse(rawToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key, nil
+	})
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/model"
+)
+
+const (
+	oldSigningKey   = "old-signing-key-0123456789abcdefghij"
+	newSigningKey   = "new-signing-key-0123456789abcdefghij"
+	otherSigningKey = "other-signing-key-0123456789abcdefgh"
+)
+
+func TestTokens_RotatedSigningKey(t *testing.T) {
+	user := model.User{ID: 7, Email: "user@example.com", Role: "user"}
+
+	oldToken, err := newTokens(TokenConfig{SigningKey: oldSigningKey}).newAccessToken(user, false)
+	require.NoError(t, err)
+	foreignToken, err := newTokens(TokenConfig{SigningKey: otherSigningKey}).newAccessToken(user, false)
+	require.NoError(t, err)
+
+	rotated := newTokens(TokenConfig{SigningKey: newSigningKey, PreviousKeys: []string{oldSigningKey}})
+	newToken, err := rotated.newAccessToken(user, false)
+	require.NoError(t, err)
+
+	claims, err := rotated.parseAccessToken(newToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+
+	claims, err = rotated.parseAccessToken(oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+
+	_, err = rotated.parseAccessToken(foreignToken)
+	assert.Error(t, err)
+
+	_, err = newTokens(TokenConfig{SigningKey: newSigningKey}).parseAccessToken(oldToken)
+	assert.Error(t, err)
+}
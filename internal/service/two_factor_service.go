@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// Second-factor brute force protection: twoFactorMaxAttempts wrong codes in a
+// row lock the second factor for twoFactorLockout.
+const (
+	twoFactorMaxAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+type TwoFactorService struct {
+	repo      repository.TwoFactor
+	usersRepo repository.Authorization
+	tokens    *tokens
+}
+
+func NewTwoFactorService(repo repository.TwoFactor, usersRepo repository.Authorization, tokens *tokens) *TwoFactorService {
+	return &TwoFactorService{repo: repo, usersRepo: usersRepo, tokens: tokens}
+}
+
+// EnrollTwoFactor generates a new pending TOTP secret. It only takes effect
+// after ActivateTwoFactor confirms a code from the authenticator app.
+func (s *TwoFactorService) EnrollTwoFactor(ctx context.Context, userID int) (model.TwoFactorEnrollment, error) {
+	user, err := s.usersRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return model.TwoFactorEnrollment{}, err
+	}
+	if user.TwoFactorEnabled {
+		return model.TwoFactorEnrollment{}, model.ErrTwoFactorAlreadyActive
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return model.TwoFactorEnrollment{}, err
+	}
+
+	if err := s.repo.SetTwoFactorSecret(ctx, userID, secret); err != nil {
+		return model.TwoFactorEnrollment{}, err
+	}
+
+	return model.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+func (s *TwoFactorService) ActivateTwoFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	secret, err := s.repo.GetTwoFactorSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Enabled {
+		return nil, model.ErrTwoFactorAlreadyActive
+	}
+	if secret.Secret == "" {
+		return nil, model.ErrTwoFactorNotEnrolled
+	}
+
+	if err := s.checkCode(ctx, secret, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.EnableTwoFactor(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *TwoFactorService) DisableTwoFactor(ctx context.Context, userID int, code string) error {
+	user, err := s.usersRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	required, err := s.repo.IsTwoFactorRequired(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return model.ErrTwoFactorPolicy
+	}
+
+	secret, err := s.enabledSecret(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkCode(ctx, secret, code); err != nil {
+		return err
+	}
+
+	return s.repo.DisableTwoFactor(ctx, userID)
+}
+
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	secret, err := s.enabledSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCode(ctx, secret, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifyTwoFactor completes a two-step login: the challenge token issued by
+// GenerateToken is exchanged for an access token given either a current TOTP
+// code or an unused recovery code. A challenge completes one login at most and
+// is void once wrong codes lock the second factor.
+func (s *TwoFactorService) VerifyTwoFactor(ctx context.Context, challengeToken, code, recoveryCode string) (string, error) {
+	userID, version, err := s.tokens.parseChallengeToken(challengeToken)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := s.enabledSecret(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if version != secret.ChallengeVersion {
+		return "", model.ErrUnauthorized
+	}
+
+	switch {
+	case code != "":
+		if err := s.checkCode(ctx, secret, code); err != nil {
+			return "", err
+		}
+	case recoveryCode != "":
+		if err := s.checkRecoveryCode(ctx, secret, recoveryCode); err != nil {
+			return "", err
+		}
+	default:
+		return "", model.ErrInvalidInput
+	}
+
+	user, err := s.usersRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return s.tokens.newAccessToken(user, false)
+}
+
+func (s *TwoFactorService) GetTwoFactorPolicies(ctx context.Context) ([]model.TwoFactorPolicy, error) {
+	return s.repo.GetTwoFactorPolicies(ctx)
+}
+
+func (s *TwoFactorService) SetTwoFactorPolicy(ctx context.Context, role string, required bool) error {
+	switch model.UserRole(role) {
+	case model.UserRoleAdmin, model.UserRoleManager, model.UserRoleTeam:
+	default:
+		return model.ErrInvalidInput
+	}
+
+	return s.repo.SetTwoFactorPolicy(ctx, role, required)
+}
+
+func (s *TwoFactorService) enabledSecret(ctx context.Context, userID int) (model.TwoFactorSecret, error) {
+	secret, err := s.repo.GetTwoFactorSecret(ctx, userID)
+	if err != nil {
+		return model.TwoFactorSecret{}, err
+	}
+	if !secret.Enabled {
+		return model.TwoFactorSecret{}, model.ErrTwoFactorNotEnrolled
+	}
+
+	return secret, nil
+}
+
+// checkCode validates a TOTP code and records its time step so the same code
+// cannot be used twice.
+func (s *TwoFactorService) checkCode(ctx context.Context, secret model.TwoFactorSecret, code string) error {
+	if twoFactorLocked(secret) {
+		return model.ErrTwoFactorLocked
+	}
+
+	step, ok := validateTOTP(secret.Secret, code, time.Now(), secret.LastStep)
+	if !ok {
+		return s.recordFailure(ctx, secret.UserID)
+	}
+
+	accepted, err := s.repo.UpdateTwoFactorLastStep(ctx, secret.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return s.recordFailure(ctx, secret.UserID)
+	}
+
+	return s.repo.ResetTwoFactorFailures(ctx, secret.UserID)
+}
+
+func (s *TwoFactorService) checkRecoveryCode(ctx context.Context, secret model.TwoFactorSecret, recoveryCode string) error {
+	if twoFactorLocked(secret) {
+		return model.ErrTwoFactorLocked
+	}
+
+	ok, err := s.repo.ConsumeRecoveryCode(ctx, secret.UserID, hashRecoveryCode(recoveryCode))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.recordFailure(ctx, secret.UserID)
+	}
+
+	return s.repo.ResetTwoFactorFailures(ctx, secret.UserID)
+}
+
+// recordFailure counts a wrong code and returns the error to report for it.
+func (s *TwoFactorService) recordFailure(ctx context.Context, userID int) error {
+	nowLocked, err := s.repo.RecordTwoFactorFailure(ctx, userID, twoFactorMaxAttempts, twoFactorLockout)
+	if err != nil {
+		return err
+	}
+	if nowLocked {
+		return model.ErrTwoFactorLocked
+	}
+	return model.ErrInvalidTwoFactorCode
+}
+
+func twoFactorLocked(secret model.TwoFactorSecret) bool {
+	return secret.LockedUntil != nil && time.Now().Before(*secret.LockedUntil)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+)
+
+// fakeTwoFactorRepo keeps the TOTP state of a single user the way
+// TwoFactorPostgres does.
+type fakeTwoFactorRepo struct {
+	repository.TwoFactor
+	secret   model.TwoFactorSecret
+	failures int
+}
+
+func (r *fakeTwoFactorRepo) GetTwoFactorSecret(ctx context.Context, userID int) (model.TwoFactorSecret, error) {
+	return r.secret, nil
+}
+
+func (r *fakeTwoFactorRepo) UpdateTwoFactorLastStep(ctx context.Context, userID int, step int64) (bool, error) {
+	if step <= r.secret.LastStep {
+		return false, nil
+	}
+	r.secret.LastStep = step
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepo) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeTwoFactorRepo) RecordTwoFactorFailure(ctx context.Context, userID, maxAttempts int, lockout time.Duration) (bool, error) {
+	r.failures++
+	if r.failures < maxAttempts {
+		return false, nil
+	}
+	r.failures = 0
+	lockedUntil := time.Now().Add(lockout)
+	r.secret.LockedUntil = &lockedUntil
+	r.secret.ChallengeVersion++
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepo) ResetTwoFactorFailures(ctx context.Context, userID int) error {
+	r.failures = 0
+	r.secret.LockedUntil = nil
+	r.secret.ChallengeVersion++
+	return nil
+}
+
+func newTestTwoFactorService(t *testing.T) (*TwoFactorService, *fakeTwoFactorRepo) {
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+
+	repo := &fakeTwoFactorRepo{secret: model.TwoFactorSecret{UserID: 1, Secret: secret, Enabled: true}}
+	s := NewTwoFactorService(repo, fakeUsersRepo{store: newSSOStore()}, newTokens(TokenConfig{SigningKey: newSigningKey}))
+	return s, repo
+}
+
+func currentCode(t *testing.T, secret string) string {
+	code, err := totpCode(secret, totpStep(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestTwoFactorService_VerifyTwoFactor_SingleUseChallenge(t *testing.T) {
+	s, repo := newTestTwoFactorService(t)
+	challenge, err := s.tokens.newChallengeToken(1, repo.secret.ChallengeVersion)
+	require.NoError(t, err)
+
+	token, err := s.VerifyTwoFactor(context.Background(), challenge, currentCode(t, repo.secret.Secret), "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	repo.secret.LastStep = 0
+	_, err = s.VerifyTwoFactor(context.Background(), challenge, currentCode(t, repo.secret.Secret), "")
+	assert.ErrorIs(t, err, model.ErrUnauthorized)
+}
+
+func TestTwoFactorService_VerifyTwoFactor_Lockout(t *testing.T) {
+	s, repo := newTestTwoFactorService(t)
+	challenge, err := s.tokens.newChallengeToken(1, repo.secret.ChallengeVersion)
+	require.NoError(t, err)
+
+	for i := 1; i < twoFactorMaxAttempts; i++ {
+		_, err := s.VerifyTwoFactor(context.Background(), challenge, "", "wrong-recovery-code")
+		assert.ErrorIs(t, err, model.ErrInvalidTwoFactorCode)
+	}
+	_, err = s.VerifyTwoFactor(context.Background(), challenge, "", "wrong-recovery-code")
+	assert.ErrorIs(t, err, model.ErrTwoFactorLocked)
+
+	// The lockout voids the challenge, and a new one waits for the lockout to end.
+	_, err = s.VerifyTwoFactor(context.Background(), challenge, currentCode(t, repo.secret.Secret), "")
+	assert.ErrorIs(t, err, model.ErrUnauthorized)
+
+	challenge, err = s.tokens.newChallengeToken(1, repo.secret.ChallengeVersion)
+	require.NoError(t, err)
+	_, err = s.VerifyTwoFactor(context.Background(), challenge, currentCode(t, repo.secret.Secret), "")
+	assert.ErrorIs(t, err, model.ErrTwoFactorLocked)
+}
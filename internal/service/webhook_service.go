@@ -0,0 +1,328 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/teamdetected/internal/model"
+	"github.com/teamdetected/internal/repository"
+	"github.com/teamdetected/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Webhook delivery tuning. A failing delivery is retried after 30s, 1m, 2m
+// and so on, capped at webhookMaxBackoff, and given up after
+// webhookMaxAttempts.
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookBatchSize   = 50
+	webhookMinBackoff  = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	webhookMaxAttempts = 8
+	// webhookLease keeps a claimed delivery from being picked up again while
+	// it is being sent.
+	webhookLease = time.Minute
+)
+
+// Webhook request headers. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	webhookEventHeader     = "X-Webhook-Event"
+	webhookDeliveryHeader  = "X-Webhook-Delivery"
+	webhookTimestampHeader = "X-Webhook-Timestamp"
+	webhookSignatureHeader = "X-Webhook-Signature"
+)
+
+type WebhookService struct {
+	repo        repository.Webhook
+	companyRepo repository.Company
+	client      *http.Client
+}
+
+func NewWebhookService(repo repository.Webhook, companyRepo repository.Company) *WebhookService {
+	return &WebhookService{
+		repo:        repo,
+		companyRepo: companyRepo,
+		client:      newPublicClient(webhookTimeout),
+	}
+}
+
+// webhookURL accepts absolute https URLs only.
+func webhookURL(raw string) error {
+	if !isHTTPSURL(raw) {
+		return fmt.Errorf("%w: webhook url must be an https url", model.ErrInvalidInput)
+	}
+	return nil
+}
+
+// requireWebhookManager lets owners and admins of the company manage its
+// webhooks.
+func (s *WebhookService) requireWebhookManager(ctx context.Context, userID, companyID int) error {
+	role, err := s.companyRepo.GetCompanyMemberRole(ctx, companyID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+	if role != model.CompanyRoleOwner && role != model.CompanyRoleAdmin {
+		return model.ErrForbidden
+	}
+	return nil
+}
+
+// CreateWebhook subscribes url to the company's events. The returned
+// subscription carries the signing secret, which is never shown again.
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID, companyID int, input model.CreateWebhookInput) (model.WebhookSubscription, error) {
+	if err := s.requireWebhookManager(ctx, userID, companyID); err != nil {
+		return model.WebhookSubscription{}, err
+	}
+	if err := webhookURL(input.URL); err != nil {
+		return model.WebhookSubscription{}, err
+	}
+	events, err := webhookEvents(input.Events)
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	created, err := s.repo.CreateWebhook(ctx, model.WebhookSubscription{
+		CompanyID: companyID,
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    events,
+		CreatedBy: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.WebhookSubscription{}, model.ErrNotFound
+	}
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	created.Secret = secret
+	return created, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, userID, companyID int) ([]model.WebhookSubscription, error) {
+	if err := s.requireWebhookManager(ctx, userID, companyID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetWebhooksByCompanyID(ctx, companyID)
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userID, companyID, webhookID int, input model.UpdateWebhookInput) (model.WebhookSubscription, error) {
+	subscription, err := s.getWebhook(ctx, userID, companyID, webhookID)
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	if input.URL != nil {
+		if err := webhookURL(*input.URL); err != nil {
+			return model.WebhookSubscription{}, err
+		}
+		subscription.URL = *input.URL
+	}
+	if input.Events != nil {
+		if subscription.Events, err = webhookEvents(input.Events); err != nil {
+			return model.WebhookSubscription{}, err
+		}
+	}
+	if input.Active != nil {
+		subscription.Active = *input.Active
+	}
+
+	updated, err := s.repo.UpdateWebhook(ctx, subscription)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.WebhookSubscription{}, model.ErrNotFound
+	}
+	return updated, err
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, companyID, webhookID int) error {
+	if _, err := s.getWebhook(ctx, userID, companyID, webhookID); err != nil {
+		return err
+	}
+
+	return lifecycleError(s.repo.DeleteWebhook(ctx, webhookID))
+}
+
+func (s *WebhookService) GetWebhookDeliveries(ctx context.Context, userID, companyID, webhookID int, query model.ListQuery) (model.Page[model.WebhookDelivery], error) {
+	switch query.Status {
+	case "", model.WebhookDeliveryPending, model.WebhookDeliverySucceeded, model.WebhookDeliveryFailed:
+	default:
+		return model.Page[model.WebhookDelivery]{}, model.ErrInvalidInput
+	}
+	if _, err := s.getWebhook(ctx, userID, companyID, webhookID); err != nil {
+		return model.Page[model.WebhookDelivery]{}, err
+	}
+
+	return s.repo.GetWebhookDeliveries(ctx, webhookID, query)
+}
+
+// RedeliverWebhook queues the delivery's event again as a new delivery.
+func (s *WebhookService) RedeliverWebhook(ctx context.Context, userID, companyID, webhookID, deliveryID int) (model.WebhookDelivery, error) {
+	if _, err := s.getWebhook(ctx, userID, companyID, webhookID); err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.repo.GetWebhookDeliveryByID(ctx, deliveryID)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && delivery.SubscriptionID != webhookID {
+		return model.WebhookDelivery{}, model.ErrNotFound
+	}
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	return s.repo.RedeliverWebhook(ctx, deliveryID)
+}
+
+// getWebhook loads a subscription of the company the requester may manage.
+func (s *WebhookService) getWebhook(ctx context.Context, userID, companyID, webhookID int) (model.WebhookSubscription, error) {
+	if err := s.requireWebhookManager(ctx, userID, companyID); err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	subscription, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && subscription.CompanyID != companyID {
+		return model.WebhookSubscription{}, model.ErrNotFound
+	}
+	return subscription, err
+}
+
+// Publish makes the webhook queue an event sink: the event is queued for
+// every subscription of its company that receives its type.
+func (s *WebhookService) Publish(ctx context.Context, event model.Event) error {
+	_, err := s.repo.EnqueueWebhookEvent(ctx, event)
+	return err
+}
+
+// DeliverWebhooks sends a batch of due deliveries and returns how many
+// succeeded. It is safe to call from every instance.
+func (s *WebhookService) DeliverWebhooks(ctx context.Context) (int, error) {
+	now := time.Now()
+	deliveries, err := s.repo.ClaimWebhookDeliveries(ctx, now, webhookLease, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	succeeded := 0
+	for _, delivery := range deliveries {
+		attempt := s.send(ctx, delivery, now)
+		if attempt.Status == model.WebhookDeliverySucceeded {
+			succeeded++
+		}
+		if err := s.repo.RecordWebhookAttempt(ctx, attempt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return succeeded, errors.Join(errs...)
+}
+
+// send posts a claimed delivery and describes the outcome. Any 2xx response
+// is a success; everything else is retried with backoff until the attempts
+// run out.
+func (s *WebhookService) send(ctx context.Context, delivery model.WebhookDelivery, now time.Time) model.WebhookAttempt {
+	attempt := model.WebhookAttempt{DeliveryID: delivery.ID, Status: model.WebhookDeliverySucceeded, NextAttemptAt: now}
+
+	// The subscriber sees the delivery as part of the worker's trace.
+	ctx, span := tracing.Tracer().Start(ctx, "POST webhook", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("webhook.delivery_id", delivery.ID), attribute.String("webhook.event", delivery.EventType)))
+	defer span.End()
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err == nil {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(webhookEventHeader, delivery.EventType)
+		req.Header.Set(webhookDeliveryHeader, strconv.Itoa(delivery.ID))
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookSignatureHeader, SignWebhook(delivery.Secret, timestamp, delivery.Payload))
+
+		var resp *http.Response
+		if resp, err = s.client.Do(req); err == nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+
+			code := resp.StatusCode
+			attempt.StatusCode = &code
+			if code < 200 || code > 299 {
+				err = fmt.Errorf("endpoint responded with %s", resp.Status)
+			}
+		}
+	}
+	if err == nil {
+		return attempt
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "delivery failed")
+
+	message := err.Error()
+	attempt.Error = &message
+	attempt.Status = model.WebhookDeliveryPending
+	attempt.NextAttemptAt = now.Add(retryBackoff(delivery.Attempts, webhookMinBackoff, webhookMaxBackoff))
+	if delivery.Attempts >= webhookMaxAttempts {
+		attempt.Status = model.WebhookDeliveryFailed
+	}
+	return attempt
+}
+
+// SignWebhook returns the X-Webhook-Signature value of a payload sent at
+// timestamp. Receivers recompute it with their secret to verify a request.
+func SignWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookEvents validates and deduplicates an event filter.
+func webhookEvents(events []string) ([]string, error) {
+	var unique []string
+	for _, event := range events {
+		if !slices.Contains(model.WebhookEventTypes, event) {
+			return nil, model.ErrInvalidInput
+		}
+		if !slices.Contains(unique, event) {
+			unique = append(unique, event)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, model.ErrInvalidInput
+	}
+	return unique, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
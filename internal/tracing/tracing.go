@@ -0,0 +1,68 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started by the
+// HTTP middleware, the background workers and the repository for every SQL
+// statement, and reach each other through the request context. They are
+// exported over OTLP/HTTP; without an endpoint the global tracer provider
+// stays the no-op default and tracing costs next to nothing.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer of this application.
+const instrumentation = "github.com/teamdetected"
+
+type Config struct {
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://collector:4318.
+	// Empty disables exporting.
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the share of new traces recorded. Requests that arrive
+	// with a sampled parent are always recorded.
+	SampleRatio float64
+}
+
+// Setup installs the W3C trace-context propagator and, when cfg.Endpoint is
+// set, a tracer provider exporting to it. The returned function flushes and
+// stops the provider.
+func Setup(ctx context.Context, cfg Config) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if cfg.Endpoint == "" {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the application tracer from the global provider, so spans
+// go wherever Setup, or a test, pointed it.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
@@ -0,0 +1,46 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teamdetected/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{})
+	require.NoError(t, err)
+
+	_, span := tracing.Tracer().Start(context.Background(), "noop")
+	assert.False(t, span.IsRecording())
+	span.End()
+
+	// Trace context is still propagated so traces continue across the
+	// instance.
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestSetup_Enabled(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    "http://127.0.0.1:4318",
+		ServiceName: "teamdetected-test",
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+	assert.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+
+	_, span := tracing.Tracer().Start(context.Background(), "recorded")
+	assert.True(t, span.IsRecording())
+
+	// Shut down without ending the span so nothing is exported to the
+	// collector that is not running.
+	assert.NoError(t, shutdown(context.Background()))
+}
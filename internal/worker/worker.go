@@ -0,0 +1,76 @@
+// Package worker runs background jobs on a fixed interval.
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/teamdetected/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Every runs job immediately and then once per interval until ctx is done.
+// A run in progress when ctx is done is not cancelled but allowed to finish,
+// so the job receives ctx's values without its cancellation. Errors are
+// logged and do not stop the loop.
+func Every(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	runCtx := context.WithoutCancel(ctx)
+	for {
+		run(runCtx, name, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run runs job once in its own trace, so the queries of a run are grouped.
+func run(ctx context.Context, name string, job func(ctx context.Context) error) {
+	ctx, span := tracing.Tracer().Start(ctx, "worker "+name, trace.WithNewRoot())
+	defer span.End()
+
+	if err := job(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "job failed")
+		log.Printf("worker %s: %v", name, err)
+	}
+}
+
+// Group runs workers in the background and waits for them to stop.
+type Group struct {
+	wg sync.WaitGroup
+}
+
+// Every starts a worker like the package-level Every.
+func (g *Group) Every(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		Every(ctx, name, interval, job)
+	}()
+}
+
+// Wait blocks until every worker has returned or ctx is done, whichever
+// comes first, and returns ctx's error in the latter case.
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
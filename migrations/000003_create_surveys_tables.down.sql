@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS survey_responses;
+DROP TABLE IF EXISTS survey_options;
+DROP TABLE IF EXISTS survey_questions;
+DROP TABLE IF EXISTS surveys;
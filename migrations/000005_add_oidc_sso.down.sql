@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS oidc_login_states;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS company_sso_configs;
+DROP TABLE IF EXISTS company_members;
@@ -0,0 +1,8 @@
+-- Anonymized users cannot be told apart from live ones once deleted_at is
+-- gone; their responses are kept.
+ALTER TABLE survey_responses DROP CONSTRAINT IF EXISTS survey_responses_user_id_fkey;
+ALTER TABLE survey_responses
+    ADD CONSTRAINT survey_responses_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,9 @@
+-- Deleted users are anonymized instead of removed so survey results and
+-- company ownership survive account deletion.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Responses must never disappear together with a user row.
+ALTER TABLE survey_responses DROP CONSTRAINT IF EXISTS survey_responses_user_id_fkey;
+ALTER TABLE survey_responses
+    ADD CONSTRAINT survey_responses_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
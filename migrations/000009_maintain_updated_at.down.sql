@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS surveys_set_updated_at ON surveys;
+DROP TRIGGER IF EXISTS teams_set_updated_at ON teams;
+DROP TRIGGER IF EXISTS companies_set_updated_at ON companies;
+DROP TRIGGER IF EXISTS users_set_updated_at ON users;
+
+DROP FUNCTION IF EXISTS set_updated_at();
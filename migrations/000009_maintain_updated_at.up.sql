@@ -0,0 +1,24 @@
+-- Keep updated_at current on every UPDATE; it doubles as the ETag version
+-- for optimistic concurrency.
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS users_set_updated_at ON users;
+CREATE TRIGGER users_set_updated_at BEFORE UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+DROP TRIGGER IF EXISTS companies_set_updated_at ON companies;
+CREATE TRIGGER companies_set_updated_at BEFORE UPDATE ON companies
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+DROP TRIGGER IF EXISTS teams_set_updated_at ON teams;
+CREATE TRIGGER teams_set_updated_at BEFORE UPDATE ON teams
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+DROP TRIGGER IF EXISTS surveys_set_updated_at ON surveys;
+CREATE TRIGGER surveys_set_updated_at BEFORE UPDATE ON surveys
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
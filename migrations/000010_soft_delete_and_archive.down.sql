@@ -0,0 +1,12 @@
+-- Soft-deleted rows become live again.
+ALTER TABLE surveys
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS archived_at;
+
+ALTER TABLE teams
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS archived_at;
+
+ALTER TABLE companies
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_company_members_user_id;
+DROP INDEX IF EXISTS idx_company_members_owner;
+
+ALTER TABLE company_members DROP COLUMN IF EXISTS role;
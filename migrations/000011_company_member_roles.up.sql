@@ -0,0 +1,12 @@
+-- Company membership roles. Every company has exactly one owner; creators of
+-- existing companies become their owners.
+ALTER TABLE company_members
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'viewer'
+        CHECK (role IN ('owner', 'admin', 'manager', 'viewer'));
+
+INSERT INTO company_members (company_id, user_id, role)
+SELECT id, created_by, 'owner' FROM companies
+ON CONFLICT (company_id, user_id) DO UPDATE SET role = 'owner';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_company_members_owner ON company_members(company_id) WHERE role = 'owner';
+CREATE INDEX IF NOT EXISTS idx_company_members_user_id ON company_members(user_id);
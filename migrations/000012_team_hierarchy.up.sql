@@ -0,0 +1,5 @@
+-- Teams form a tree inside their company: a department is a team whose
+-- sub-teams point at it through parent_id.
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES teams(id);
+
+CREATE INDEX IF NOT EXISTS idx_teams_parent_id ON teams(parent_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_surveys_team_id_created_at;
+DROP INDEX IF EXISTS idx_teams_company_id;
@@ -0,0 +1,3 @@
+-- Indexes backing the per-company counters computed in company queries.
+CREATE INDEX IF NOT EXISTS idx_teams_company_id ON teams(company_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_surveys_team_id_created_at ON surveys(team_id, created_at DESC) WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE surveys
+    DROP COLUMN IF EXISTS schedule_id,
+    DROP COLUMN IF EXISTS closes_at,
+    DROP COLUMN IF EXISTS title;
+
+DROP TABLE IF EXISTS survey_schedules;
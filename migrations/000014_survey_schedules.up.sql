@@ -0,0 +1,30 @@
+-- Recurring survey schedules: each run opens a new survey ("wave") for the
+-- team and completes the previous wave of the same schedule.
+CREATE TABLE IF NOT EXISTS survey_schedules (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    rule VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    title_template VARCHAR(255) NOT NULL,
+    duration_hours INTEGER NOT NULL CHECK (duration_hours > 0),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    created_by INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_survey_schedules_team_id ON survey_schedules(team_id);
+CREATE INDEX IF NOT EXISTS idx_survey_schedules_next_run_at ON survey_schedules(next_run_at) WHERE enabled;
+
+DROP TRIGGER IF EXISTS survey_schedules_set_updated_at ON survey_schedules;
+CREATE TRIGGER survey_schedules_set_updated_at BEFORE UPDATE ON survey_schedules
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+ALTER TABLE surveys ADD COLUMN IF NOT EXISTS title VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE surveys ADD COLUMN IF NOT EXISTS closes_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE surveys ADD COLUMN IF NOT EXISTS schedule_id INTEGER REFERENCES survey_schedules(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_surveys_schedule_id ON surveys(schedule_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_surveys_closes_at ON surveys(closes_at) WHERE status = 'active';
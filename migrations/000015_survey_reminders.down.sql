@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS survey_reminders;
+
+ALTER TABLE users DROP COLUMN IF EXISTS survey_reminders;
@@ -0,0 +1,13 @@
+-- Users opt out of survey reminders with survey_reminders = FALSE.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS survey_reminders BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- One row per reminder sent. offset_minutes is the reminder stage: how long
+-- before closes_at it was due. A stage counts as sent once any stage at or
+-- below it was sent, so a late survey does not trigger every stage at once.
+CREATE TABLE IF NOT EXISTS survey_reminders (
+    survey_id INTEGER NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    offset_minutes INTEGER NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (survey_id, user_id, offset_minutes)
+);
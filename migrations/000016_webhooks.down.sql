@@ -0,0 +1,4 @@
+ALTER TABLE surveys DROP COLUMN IF EXISTS answered_at;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
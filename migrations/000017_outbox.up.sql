@@ -0,0 +1,27 @@
+-- Domain events are written to the outbox in the transaction that changes
+-- the data and relayed to the event sinks afterwards. company_id and team_id
+-- are copied rather than referenced so events outlive purged rows.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    event_type VARCHAR(64) NOT NULL,
+    company_id INTEGER NOT NULL,
+    team_id INTEGER,
+    data JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(next_attempt_at) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events(published_at) WHERE published_at IS NOT NULL;
+
+-- The relay delivers at least once, so the webhook sink may see an event
+-- twice. Only one original delivery per subscription and event is queued;
+-- manual redeliveries point at the delivery they repeat.
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS redelivery_of INTEGER;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event
+    ON webhook_deliveries(subscription_id, event_id) WHERE redelivery_of IS NULL;
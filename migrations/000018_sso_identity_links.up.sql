@@ -0,0 +1,4 @@
+-- A login state started by a signed-in user links the provider identity to
+-- that user instead of signing someone in by email.
+ALTER TABLE oidc_login_states
+    ADD COLUMN IF NOT EXISTS link_user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
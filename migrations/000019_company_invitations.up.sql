@@ -0,0 +1,15 @@
+-- Imports invite people who are not members of the company yet instead of
+-- creating accounts for them. An invitation is accepted by a signed-in user
+-- with the invited email; re-importing an email replaces its invitation.
+CREATE TABLE IF NOT EXISTS company_invitations (
+    id SERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    team_id INTEGER REFERENCES teams(id) ON DELETE SET NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (company_id, email)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE company_sso_configs
+    DROP COLUMN IF EXISTS domain_verified_at,
+    DROP COLUMN IF EXISTS domain_verification_token;
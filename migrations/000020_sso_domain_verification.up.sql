@@ -0,0 +1,9 @@
+-- Auto-provisioning trusts the provider for every address in email_domain, so
+-- the company has to prove it owns the domain with a DNS TXT record carrying
+-- domain_verification_token. Changing the domain issues a new token.
+ALTER TABLE company_sso_configs
+    ADD COLUMN IF NOT EXISTS domain_verification_token VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS domain_verified_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE company_sso_configs SET domain_verification_token = md5(random()::text)
+WHERE domain_verification_token = '';
@@ -0,0 +1,3 @@
+ALTER TABLE company_sso_configs ALTER COLUMN default_role SET DEFAULT 'team';
+
+UPDATE company_sso_configs SET default_role = 'team' WHERE default_role = 'viewer';
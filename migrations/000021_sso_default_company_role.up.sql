@@ -0,0 +1,5 @@
+-- default_role is the company membership role of provisioned users, not their
+-- platform role; provisioned users always get the platform role 'team'.
+UPDATE company_sso_configs SET default_role = 'viewer' WHERE default_role NOT IN ('manager', 'viewer');
+
+ALTER TABLE company_sso_configs ALTER COLUMN default_role SET DEFAULT 'viewer';
@@ -0,0 +1,8 @@
+-- Failed two-factor attempts. Too many lock the second factor for a while and
+-- bump totp_challenge_version, which also changes on every successful login:
+-- challenge tokens carry the version they were issued for, so each one
+-- completes at most one login and dies with the lockout.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS totp_locked_until TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS totp_challenge_version INTEGER NOT NULL DEFAULT 0;
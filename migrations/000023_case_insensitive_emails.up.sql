@@ -0,0 +1,7 @@
+-- Emails are case-insensitive and stored in lower case. Accounts whose emails
+-- differ only in case make the first UPDATE fail and have to be merged by hand
+-- before this migration can run.
+UPDATE users SET email = LOWER(email) WHERE email <> LOWER(email);
+UPDATE email_change_requests SET new_email = LOWER(new_email) WHERE new_email <> LOWER(new_email);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email));
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations so the binary can apply them
+// without the files on disk. Every version has an NNNNNN_name.up.sql and an
+// NNNNNN_name.down.sql file.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS